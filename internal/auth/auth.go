@@ -3,19 +3,35 @@ package auth
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/haukened/tsky/internal/config"
+	"github.com/haukened/tsky/internal/logging"
 	"github.com/haukened/tsky/internal/utils"
 )
 
-const BASE_AUTH_URI = "https://%s/xrpc/com.atproto.server.createSession"
+const (
+	BASE_AUTH_URI   = "https://%s/xrpc/com.atproto.server.createSession"
+	BASE_LOGOUT_URI = "https://%s/xrpc/com.atproto.server.deleteSession"
+)
+
+var logger = logging.For("auth")
+
+// ErrAuthFactorRequired means the account has email 2FA enabled, log in again with the emailed code.
+var ErrAuthFactorRequired = errors.New("a sign in code was sent to your email")
 
 type RequestBody struct {
-	Identifier string `json:"identifier"`
-	Password   string `json:"password"`
+	Identifier      string `json:"identifier"`
+	Password        string `json:"password"`
+	AuthFactorToken string `json:"authFactorToken,omitempty"`
+}
+
+type errorBody struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
 }
 
 type AuthResponse struct {
@@ -45,14 +61,27 @@ type AuthResponse struct {
 	Active          bool   `json:"active"`
 }
 
+// LoginWithPassword creates a session from the identifier and app password in the config.
+// If the account has 2FA enabled it returns ErrAuthFactorRequired and keeps the password,
+// so the login can be completed with LoginWithCode.
 func LoginWithPassword(c *config.Config) (err error) {
+	return login(c, "")
+}
+
+// LoginWithCode completes a login that returned ErrAuthFactorRequired, using the emailed code.
+func LoginWithCode(c *config.Config, code string) (err error) {
+	return login(c, code)
+}
+
+func login(c *config.Config, code string) (err error) {
 	// Create the URL
 	postURL := fmt.Sprintf(BASE_AUTH_URI, c.Server)
 
 	// Create the body
 	body := RequestBody{
-		Identifier: c.Identifier,
-		Password:   c.AppPassword,
+		Identifier:      c.Identifier,
+		Password:        c.AppPassword,
+		AuthFactorToken: code,
 	}
 
 	// Marshal the body
@@ -72,24 +101,42 @@ func LoginWithPassword(c *config.Config) (err error) {
 	req.Header.Set("User-Agent", utils.UserAgent())
 
 	// Send the request
-	client := &http.Client{}
+	logger.Debug("sending login request", "url", postURL, "identifier", c.Identifier)
+	client := &http.Client{Timeout: utils.HTTP_TIMEOUT}
 	resp, err := client.Do(req)
 	if err != nil {
 		return
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("failed to login, status code: %d", resp.StatusCode)
-		return
-	}
-
 	// read the response body
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return
 	}
 
+	if resp.StatusCode != http.StatusOK {
+		var e errorBody
+		json.Unmarshal(bodyBytes, &e)
+		if e.Error == "AuthFactorTokenRequired" {
+			// keep the password, it is sent again along with the code
+			logger.Info("login needs a sign in code", "identifier", c.Identifier)
+			err = ErrAuthFactorRequired
+			return
+		}
+		logger.Warn("login request failed", "status", resp.Status, "error", e.Error)
+		c.AppPassword = ""
+		if e.Message != "" {
+			err = fmt.Errorf("failed to login: %s", e.Message)
+			return
+		}
+		err = fmt.Errorf("failed to login, status code: %d", resp.StatusCode)
+		return
+	}
+
+	// the password is never needed again, don't keep it around
+	c.AppPassword = ""
+
 	// Unmarshal the response
 	var authResponse AuthResponse
 	err = json.Unmarshal(bodyBytes, &authResponse)
@@ -98,9 +145,48 @@ func LoginWithPassword(c *config.Config) (err error) {
 	}
 
 	// set the access and refresh tokens
-	c.AccessJwt = authResponse.AccessJwt
-	c.RefreshJwt = authResponse.RefreshJwt
-	c.Did = authResponse.Did
+	c.SetSession(authResponse.AccessJwt, authResponse.RefreshJwt, authResponse.Did)
+	logger.Info("logged in", "did", authResponse.Did)
 
 	return
 }
+
+// Logout revokes the refresh token of the current session and removes it from the config.
+// The local session is cleared even if the server could not be reached, and that is only logged,
+// as the user is logged out either way.
+func Logout(c *config.Config) error {
+	refreshJwt, _ := c.Session()
+	// Revoke logs why it failed
+	Revoke(c.Server, refreshJwt)
+
+	// clear the local session regardless
+	c.SetSession("", "", "")
+	logger.Info("logged out", "identifier", c.Identifier)
+	return c.Save()
+}
+
+// Revoke ends the session of refreshJwt on server, it does nothing if there is no session.
+func Revoke(server, refreshJwt string) (err error) {
+	if refreshJwt != "" {
+		var req *http.Request
+		req, err = http.NewRequest("POST", fmt.Sprintf(BASE_LOGOUT_URI, server), nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("Authorization", "Bearer "+refreshJwt)
+		req.Header.Set("User-Agent", utils.UserAgent())
+		client := &http.Client{Timeout: utils.HTTP_TIMEOUT}
+		var resp *http.Response
+		resp, err = client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				err = fmt.Errorf("failed to revoke session, status code: %d", resp.StatusCode)
+			}
+		}
+		if err != nil {
+			logger.Warn("unable to revoke session", "err", err)
+		}
+	}
+	return
+}
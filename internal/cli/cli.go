@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/config"
+	"github.com/haukened/tsky/internal/logging"
+	"github.com/haukened/tsky/internal/tokensvc"
+	"github.com/haukened/tsky/internal/utils"
+)
+
+var logger = logging.For("cli")
+
+var (
+	ErrNotLoggedIn = errors.New("not logged in, run tsky login first")
+	ErrUsage       = errors.New("invalid usage")
+)
+
+// Command is a non interactive tsky subcommand.
+type Command struct {
+	Name  string
+	Args  string
+	Short string
+	Run   func(e *Env, args []string) error
+}
+
+// Env is everything a command needs, so commands never touch the process directly.
+type Env struct {
+	Conf   *config.Config
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	client *client.Client
+}
+
+// Client returns a client for the stored session, refreshing it on first use.
+func (e *Env) Client() (*client.Client, error) {
+	if e.client != nil {
+		return e.client, nil
+	}
+	if refreshJwt, _ := e.Conf.Session(); refreshJwt == "" || utils.IsJwtExpired(refreshJwt) {
+		return nil, ErrNotLoggedIn
+	}
+	tokSvc, err := tokensvc.NewRefresher(e.Conf)
+	if err != nil {
+		return nil, err
+	}
+	e.client = client.New(tokSvc)
+	return e.client, nil
+}
+
+var commands []*Command
+
+func init() {
+	commands = []*Command{
+		{Name: "login", Args: "[-identifier handle] [-password-stdin] [-code code]", Short: "log in with an app password", Run: runLogin},
+		{Name: "logout", Short: "revoke and forget the stored session", Run: runLogout},
+		{Name: "whoami", Args: "[output flags]", Short: "show the logged in account", Run: runWhoami},
+		{Name: "post", Args: "[-file path] [-image path -alt text]... [-lang code]... <text|->", Short: "publish a post, long text becomes a thread", Run: runPost},
+		{Name: "timeline", Args: "[-limit n] [-cursor c] [output flags]", Short: "show the home timeline", Run: runTimeline},
+		{Name: "notifications", Args: "[-limit n] [-cursor c] [output flags]", Short: "show notifications", Run: runNotifications},
+		{Name: "profile", Args: "[output flags] <handle>", Short: "show a profile", Run: runProfile},
+		{Name: "help", Args: "[command]", Short: "show this help", Run: runHelp},
+	}
+}
+
+// Lookup returns the command with the given name, or nil.
+func Lookup(name string) *Command {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// Run executes the command named by args[0] and returns the process exit code.
+func Run(c *config.Config, args []string) int {
+	e := &Env{
+		Conf:   c,
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+	return e.Run(args)
+}
+
+// Run executes the command named by args[0] in this environment and returns the exit code.
+func (e *Env) Run(args []string) int {
+	cmd := Lookup(args[0])
+	if cmd == nil {
+		fmt.Fprintf(e.Stderr, "tsky: unknown command %q\n\n", args[0])
+		usage(e.Stderr)
+		return EXIT_USAGE
+	}
+	logger.Debug("running command", "command", cmd.Name)
+	err := cmd.Run(e, args[1:])
+	code := exitCode(err)
+	switch code {
+	case EXIT_OK:
+	case EXIT_USAGE:
+		if err != ErrUsage {
+			fmt.Fprintf(e.Stderr, "error: %s\n", err)
+		}
+		fmt.Fprintf(e.Stderr, "usage: tsky %s %s\n", cmd.Name, cmd.Args)
+	default:
+		logger.Error("command failed", "command", cmd.Name, "err", err, "exit", code)
+		fmt.Fprintf(e.Stderr, "error: %s\n", err)
+	}
+	return code
+}
+
+// flags returns a flag set for a command that writes its usage to the environment.
+func (e *Env) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.Stderr)
+	fs.Usage = func() {
+		cmd := Lookup(name)
+		fmt.Fprintf(e.Stderr, "usage: tsky %s %s\n\n%s\n", cmd.Name, cmd.Args, cmd.Short)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: tsky [-log-level level] [command] [args]")
+	fmt.Fprintln(w, "\nwith no command tsky starts the full screen interface.")
+	fmt.Fprintln(w, "\ncommands:")
+	width := 0
+	for _, cmd := range commands {
+		width = max(width, len(cmd.Name))
+	}
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s%s  %s\n", cmd.Name, strings.Repeat(" ", width-len(cmd.Name)), cmd.Short)
+	}
+}
+
+func runHelp(e *Env, args []string) error {
+	if len(args) > 0 {
+		if cmd := Lookup(args[0]); cmd != nil {
+			fmt.Fprintf(e.Stdout, "usage: tsky %s %s\n\n%s\n", cmd.Name, cmd.Args, cmd.Short)
+			return nil
+		}
+	}
+	usage(e.Stdout)
+	return nil
+}
@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/haukened/tsky/internal/auth"
+)
+
+const APP_PASSWORD_ENV = "TSKY_APP_PASSWORD"
+
+var ErrNoPassword = errors.New("no app password, use -password-stdin or set " + APP_PASSWORD_ENV)
+
+func runLogin(e *Env, args []string) error {
+	fs := e.flags("login")
+	identifier := fs.String("identifier", e.Conf.Identifier, "handle or email to log in as")
+	passwordStdin := fs.Bool("password-stdin", false, "read the app password from the first line of stdin")
+	code := fs.String("code", "", "sign in code from your email, for accounts with 2FA")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	in := bufio.NewReader(e.Stdin)
+	if *identifier == "" {
+		fmt.Fprint(e.Stderr, "Username: ")
+		line, err := in.ReadString('\n')
+		if err != nil && line == "" {
+			return err
+		}
+		*identifier = strings.TrimSpace(line)
+	}
+	password, err := readPassword(e, in, *passwordStdin)
+	if err != nil {
+		return err
+	}
+	e.Conf.Identifier = *identifier
+	e.Conf.AppPassword = password
+	if *code != "" {
+		err = auth.LoginWithCode(e.Conf, *code)
+	} else {
+		err = auth.LoginWithPassword(e.Conf)
+	}
+	if errors.Is(err, auth.ErrAuthFactorRequired) {
+		// ask for the emailed code, unless it can't be typed in
+		if *passwordStdin {
+			return fmt.Errorf("%w, run tsky login again with -code", err)
+		}
+		fmt.Fprintf(e.Stderr, "%s\nCode: ", err)
+		line, readErr := in.ReadString('\n')
+		if readErr != nil && line == "" {
+			return readErr
+		}
+		err = auth.LoginWithCode(e.Conf, strings.TrimSpace(line))
+	}
+	if err != nil {
+		return err
+	}
+	if err := e.Conf.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.Stdout, "Logged in as %s (%s)\n", e.Conf.Identifier, e.Conf.Did)
+	return nil
+}
+
+// readPassword takes the app password from stdin, the environment, or a terminal prompt, in that order.
+func readPassword(e *Env, in *bufio.Reader, fromStdin bool) (string, error) {
+	if fromStdin {
+		line, err := in.ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		return strings.TrimSpace(line), nil
+	}
+	if password := os.Getenv(APP_PASSWORD_ENV); password != "" {
+		return password, nil
+	}
+	if f, ok := e.Stdin.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		fmt.Fprint(e.Stderr, "App password: ")
+		password, err := term.ReadPassword(f.Fd())
+		fmt.Fprintln(e.Stderr)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(password)), nil
+	}
+	return "", ErrNoPassword
+}
+
+func runLogout(e *Env, args []string) error {
+	if err := e.flags("logout").Parse(args); err != nil {
+		return err
+	}
+	if refreshJwt, _ := e.Conf.Session(); refreshJwt == "" {
+		return ErrNotLoggedIn
+	}
+	if err := auth.Logout(e.Conf); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.Stdout, "Logged out %s\n", e.Conf.Identifier)
+	return nil
+}
+
+func runWhoami(e *Env, args []string) error {
+	fs := e.flags("whoami")
+	out := outputFlags(fs)
+	if err := parse(fs, out, args); err != nil {
+		return err
+	}
+	c, err := e.Client()
+	if err != nil {
+		return err
+	}
+	session, err := c.GetSession()
+	if err != nil {
+		return err
+	}
+	return write(out, e.Stdout, sessionView(session, e.Conf.Server))
+}
+
+func runTimeline(e *Env, args []string) error {
+	fs := e.flags("timeline")
+	limit := fs.Int("limit", 25, "number of posts to show, at most 100")
+	cursor := fs.String("cursor", "", "continue from the cursor of a previous page")
+	out := outputFlags(fs)
+	if err := parse(fs, out, args); err != nil {
+		return err
+	}
+	c, err := e.Client()
+	if err != nil {
+		return err
+	}
+	feed, err := c.GetTimeline(*limit, *cursor)
+	if err != nil {
+		return err
+	}
+	return write(out, e.Stdout, feedView(feed))
+}
+
+func runNotifications(e *Env, args []string) error {
+	fs := e.flags("notifications")
+	limit := fs.Int("limit", 25, "number of notifications to show, at most 100")
+	cursor := fs.String("cursor", "", "continue from the cursor of a previous page")
+	out := outputFlags(fs)
+	if err := parse(fs, out, args); err != nil {
+		return err
+	}
+	c, err := e.Client()
+	if err != nil {
+		return err
+	}
+	notifications, err := c.ListNotifications(*limit, *cursor)
+	if err != nil {
+		return err
+	}
+	return write(out, e.Stdout, notificationsView(notifications))
+}
+
+func runProfile(e *Env, args []string) error {
+	fs := e.flags("profile")
+	out := outputFlags(fs)
+	if err := parse(fs, out, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return ErrUsage
+	}
+	c, err := e.Client()
+	if err != nil {
+		return err
+	}
+	profile, err := c.GetProfile(strings.TrimPrefix(fs.Arg(0), "@"))
+	if err != nil {
+		return err
+	}
+	return write(out, e.Stdout, profileView(profile))
+}
+
+// parse parses the flags of a read command and validates its output flags.
+func parse(fs *flag.FlagSet, out *output, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return out.validate()
+}
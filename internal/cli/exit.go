@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"net/http"
+	"strings"
+
+	"github.com/haukened/tsky/internal/auth"
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/tokensvc"
+)
+
+// Exit codes are part of the command line interface, scripts depend on them so never renumber them.
+const (
+	EXIT_OK           = 0
+	EXIT_ERROR        = 1
+	EXIT_USAGE        = 2
+	EXIT_AUTH         = 3
+	EXIT_NOT_FOUND    = 4
+	EXIT_RATE_LIMITED = 5
+	EXIT_UNAVAILABLE  = 6
+)
+
+// xrpcNotFound are the XRPC error names that mean the thing asked for does not exist.
+var xrpcNotFound = map[string]bool{
+	"NotFound":        true,
+	"RecordNotFound":  true,
+	"RepoNotFound":    true,
+	"ActorNotFound":   true,
+	"ProfileNotFound": true,
+	"AccountNotFound": true,
+	"UnknownFeed":     true,
+	"UnknownList":     true,
+	"BlobNotFound":    true,
+}
+
+// xrpcAuth are the XRPC error names that mean the session is missing or no longer valid.
+var xrpcAuth = map[string]bool{
+	"AuthRequired":            true,
+	"ExpiredToken":            true,
+	"InvalidToken":            true,
+	"AccountTakedown":         true,
+	"AuthFactorTokenRequired": true,
+}
+
+// exitCode maps an error returned by a command to the process exit code.
+func exitCode(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return EXIT_OK
+	}
+	if errors.Is(err, ErrUsage) {
+		return EXIT_USAGE
+	}
+	if errors.Is(err, ErrNotLoggedIn) || errors.Is(err, ErrNoPassword) || errors.Is(err, auth.ErrAuthFactorRequired) || errors.Is(err, tokensvc.ErrUnableToRefreshToken) {
+		return EXIT_AUTH
+	}
+	if errors.Is(err, client.ErrHttpError) || errors.Is(err, tokensvc.ErrHttpError) {
+		return EXIT_UNAVAILABLE
+	}
+	var xerr *client.XRPCError
+	if errors.As(err, &xerr) {
+		switch {
+		case xrpcAuth[xerr.Name] || xerr.StatusCode == http.StatusUnauthorized || xerr.StatusCode == http.StatusForbidden:
+			return EXIT_AUTH
+		case xrpcNotFound[xerr.Name] || xerr.StatusCode == http.StatusNotFound || notFoundMessage(xerr):
+			return EXIT_NOT_FOUND
+		case xerr.StatusCode == http.StatusTooManyRequests:
+			return EXIT_RATE_LIMITED
+		case xerr.StatusCode >= 500:
+			return EXIT_UNAVAILABLE
+		}
+	}
+	return EXIT_ERROR
+}
+
+// notFoundMessage catches the lookups that report a missing actor or handle as a plain InvalidRequest.
+func notFoundMessage(xerr *client.XRPCError) bool {
+	msg := strings.ToLower(xerr.Message)
+	return xerr.Name == "InvalidRequest" &&
+		(strings.Contains(msg, "not found") || strings.Contains(msg, "unable to resolve"))
+}
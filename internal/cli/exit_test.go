@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/tokensvc"
+)
+
+func TestExitCode(t *testing.T) {
+	xrpc := func(status int, name, message string) error {
+		// commands wrap what the client returns, the code must still be found
+		return fmt.Errorf("fetching: %w", &client.XRPCError{StatusCode: status, Name: name, Message: message})
+	}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"no error", nil, EXIT_OK},
+		{"help", flag.ErrHelp, EXIT_OK},
+		{"usage", fmt.Errorf("%w: missing text", ErrUsage), EXIT_USAGE},
+		{"not logged in", ErrNotLoggedIn, EXIT_AUTH},
+		{"refresh rejected", tokensvc.ErrUnableToRefreshToken, EXIT_AUTH},
+		{"no connection", tokensvc.ErrHttpError, EXIT_UNAVAILABLE},
+		{"expired token", xrpc(400, "ExpiredToken", "Token has expired"), EXIT_AUTH},
+		{"unauthorized status", xrpc(401, "", ""), EXIT_AUTH},
+		{"forbidden status", xrpc(403, "Forbidden", ""), EXIT_AUTH},
+		{"record not found", xrpc(400, "RecordNotFound", "Could not locate record"), EXIT_NOT_FOUND},
+		{"not found status", xrpc(404, "", ""), EXIT_NOT_FOUND},
+		{"unknown actor", xrpc(400, "InvalidRequest", "Profile not found"), EXIT_NOT_FOUND},
+		{"unresolved handle", xrpc(400, "InvalidRequest", "Unable to resolve handle"), EXIT_NOT_FOUND},
+		{"other invalid request", xrpc(400, "InvalidRequest", "Input/text must not be longer than 3000 characters"), EXIT_ERROR},
+		{"rate limited", xrpc(429, "RateLimitExceeded", ""), EXIT_RATE_LIMITED},
+		{"server error", xrpc(502, "", ""), EXIT_UNAVAILABLE},
+		{"anything else", errors.New("boom"), EXIT_ERROR},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
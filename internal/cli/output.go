@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/haukened/tsky/internal/utils"
+)
+
+const (
+	FORMAT_TEXT     = "text"
+	FORMAT_TABLE    = "table"
+	FORMAT_JSON     = "json"
+	FORMAT_NDJSON   = "ndjson"
+	FORMAT_TEMPLATE = "template"
+)
+
+var formats = []string{FORMAT_TEXT, FORMAT_TABLE, FORMAT_JSON, FORMAT_NDJSON, FORMAT_TEMPLATE}
+
+// templateFuncs are available to -template in addition to the text/template builtins.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"ago":  utils.RelativeTime,
+	"join": strings.Join,
+	"oneline": func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	},
+}
+
+// output holds the -output and -template flags shared by every read command.
+type output struct {
+	format string
+	text   string
+	tmpl   *template.Template
+}
+
+// view describes how to render one command's result in every format.
+// For a single object items holds just that object.
+type view[T any] struct {
+	whole  any
+	items  []T
+	text   func(io.Writer)
+	header []string
+	row    func(T) []string
+}
+
+func outputFlags(fs *flag.FlagSet) *output {
+	o := &output{}
+	fs.StringVar(&o.format, "output", FORMAT_TEXT, "output format: "+strings.Join(formats, ", "))
+	fs.StringVar(&o.text, "template", "", "Go text/template executed for each item, implies -output template")
+	return o
+}
+
+// validate checks the flags once they are parsed, so bad usage fails before any request is made.
+func (o *output) validate() error {
+	if o.text != "" {
+		o.format = FORMAT_TEMPLATE
+	}
+	switch o.format {
+	case FORMAT_TEXT, FORMAT_TABLE, FORMAT_JSON, FORMAT_NDJSON:
+		return nil
+	case FORMAT_TEMPLATE:
+		if o.text == "" {
+			return fmt.Errorf("%w: -output template needs -template", ErrUsage)
+		}
+		tmpl, err := template.New("output").Funcs(templateFuncs).Parse(o.text)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrUsage, err)
+		}
+		o.tmpl = tmpl
+		return nil
+	}
+	return fmt.Errorf("%w: unknown output format %q, use one of %s", ErrUsage, o.format, strings.Join(formats, ", "))
+}
+
+func write[T any](o *output, w io.Writer, v view[T]) error {
+	switch o.format {
+	case FORMAT_JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v.whole)
+	case FORMAT_NDJSON:
+		enc := json.NewEncoder(w)
+		for _, item := range v.items {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FORMAT_TABLE:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(v.header, "\t"))
+		for _, item := range v.items {
+			fmt.Fprintln(tw, strings.Join(v.row(item), "\t"))
+		}
+		return tw.Flush()
+	case FORMAT_TEMPLATE:
+		for _, item := range v.items {
+			if err := o.tmpl.Execute(w, item); err != nil {
+				return err
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+	}
+	v.text(w)
+	return nil
+}
+
+// cell flattens text to a single line of at most n runes for a table column.
+func cell(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
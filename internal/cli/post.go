@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/richtext"
+)
+
+const (
+	MAX_IMAGES      = 4
+	MAX_IMAGE_BYTES = 1000000
+)
+
+var (
+	ErrTooManyImages = fmt.Errorf("a post can have at most %d images", MAX_IMAGES)
+	ErrTooManyAlts   = errors.New("there are more -alt flags than -image flags")
+	ErrTextAndFile   = errors.New("use either text arguments or -file, not both")
+)
+
+// stringList is a flag that can be repeated, collecting every value in order.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func runPost(e *Env, args []string) error {
+	fs := e.flags("post")
+	file := fs.String("file", "", "read the post text from a file, - for stdin")
+	var images, alts, langs stringList
+	fs.Var(&images, "image", "attach an image, can be repeated up to 4 times")
+	fs.Var(&alts, "alt", "alt text for the image in the same position, can be repeated")
+	fs.Var(&langs, "lang", "language of the post, e.g. en, can be repeated")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(images) > MAX_IMAGES {
+		return fmt.Errorf("%w: %w", ErrUsage, ErrTooManyImages)
+	}
+	if len(alts) > len(images) {
+		return fmt.Errorf("%w: %w", ErrUsage, ErrTooManyAlts)
+	}
+	text, err := postText(e, *file, fs.Args())
+	if err != nil {
+		return err
+	}
+	if text == "" && len(images) == 0 {
+		return ErrUsage
+	}
+
+	c, err := e.Client()
+	if err != nil {
+		return err
+	}
+	var embed *messages.RecordEmbed
+	if len(images) > 0 {
+		embed, err = uploadImages(c, images, alts)
+		if err != nil {
+			return err
+		}
+	}
+
+	// long text becomes a thread, the images go on the first post
+	parts := richtext.Split(text, richtext.MAX_GRAPHEMES)
+	if len(parts) == 0 {
+		parts = []string{""}
+	}
+	if len(parts) > 1 {
+		logger.Info("posting as a thread", "posts", len(parts))
+	}
+	resolve := richtext.CachedResolver(c.ResolveHandle)
+	var root, parent messages.StrongRef
+	for i, part := range parts {
+		post := messages.PostRecord{
+			Text:   part,
+			Facets: richtext.Facets(part, resolve),
+			Langs:  langs,
+		}
+		if i == 0 {
+			post.Embed = embed
+		} else {
+			post.Reply = &messages.ReplyRef{Root: root, Parent: parent}
+		}
+		ref, err := c.CreatePost(post)
+		if err != nil {
+			if i > 0 {
+				return fmt.Errorf("thread stopped after %d of %d posts: %w", i, len(parts), err)
+			}
+			return err
+		}
+		if i == 0 {
+			root = ref
+		}
+		parent = ref
+		fmt.Fprintln(e.Stdout, ref.URI)
+	}
+	return nil
+}
+
+// postText takes the text from -file, or from the arguments where a single - means stdin.
+func postText(e *Env, file string, args []string) (string, error) {
+	if file != "" && len(args) > 0 {
+		return "", fmt.Errorf("%w: %w", ErrUsage, ErrTextAndFile)
+	}
+	var data []byte
+	var err error
+	switch {
+	case file == "-" || (len(args) == 1 && args[0] == "-"):
+		data, err = io.ReadAll(e.Stdin)
+	case file != "":
+		data, err = os.ReadFile(file)
+	default:
+		return strings.TrimSpace(strings.Join(args, " ")), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// uploadImages uploads every image and returns the images embed for them.
+func uploadImages(c *client.Client, paths, alts []string) (*messages.RecordEmbed, error) {
+	embed := &messages.RecordEmbed{Type: messages.EMBED_IMAGES}
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > MAX_IMAGE_BYTES {
+			return nil, fmt.Errorf("%s is %d bytes, images can be at most %d", filepath.Base(path), len(data), MAX_IMAGE_BYTES)
+		}
+		mimeType := http.DetectContentType(data)
+		if !strings.HasPrefix(mimeType, "image/") {
+			return nil, fmt.Errorf("%s does not look like an image (%s)", filepath.Base(path), mimeType)
+		}
+		blob, err := c.UploadBlob(mimeType, data)
+		if err != nil {
+			return nil, err
+		}
+		img := messages.EmbedImage{Image: blob}
+		if i < len(alts) {
+			img.Alt = alts[i]
+		}
+		// the aspect ratio lets clients lay the image out before it loads, it is fine to skip it
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+			img.AspectRatio = &messages.AspectRatio{Width: cfg.Width, Height: cfg.Height}
+		}
+		logger.Debug("uploaded image", "path", path, "mime", mimeType, "size", len(data))
+		embed.Images = append(embed.Images, img)
+	}
+	return embed, nil
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/utils"
+)
+
+// author formats a profile as "Display Name (@handle)", or just the handle if there is no display name.
+func author(p messages.ProfileViewBasic) string {
+	if p.DisplayName == "" {
+		return "@" + p.Handle
+	}
+	return fmt.Sprintf("%s (@%s)", p.DisplayName, p.Handle)
+}
+
+func sessionView(s messages.SessionMessage, server string) view[messages.SessionMessage] {
+	return view[messages.SessionMessage]{
+		whole: s,
+		items: []messages.SessionMessage{s},
+		text: func(w io.Writer) {
+			fmt.Fprintf(w, "%s (%s) on %s\n", s.Handle, s.Did, server)
+		},
+		header: []string{"HANDLE", "DID", "EMAIL", "ACTIVE"},
+		row: func(s messages.SessionMessage) []string {
+			return []string{s.Handle, s.Did, s.Email, strconv.FormatBool(s.Active)}
+		},
+	}
+}
+
+func feedView(feed messages.FeedMessage) view[messages.FeedViewPost] {
+	return view[messages.FeedViewPost]{
+		whole: feed,
+		items: feed.Feed,
+		text: func(w io.Writer) {
+			for _, item := range feed.Feed {
+				post := item.Post
+				if item.Reason != nil && item.Reason.Type == messages.REASON_REPOST {
+					fmt.Fprintf(w, "⟲ reposted by %s\n", author(item.Reason.By))
+				}
+				if item.Reply != nil {
+					fmt.Fprintf(w, "↩ reply to @%s\n", item.Reply.Parent.Author.Handle)
+				}
+				fmt.Fprintf(w, "%s · %s\n", author(post.Author), utils.RelativeTime(post.Record.CreatedAt))
+				fmt.Fprintln(w, post.Record.Text)
+				fmt.Fprintf(w, "↩ %d  ⟲ %d  ♥ %d  %s\n\n", post.ReplyCount, post.RepostCount, post.LikeCount, post.URI)
+			}
+			if feed.Cursor != "" {
+				fmt.Fprintf(w, "cursor: %s\n", feed.Cursor)
+			}
+		},
+		header: []string{"AUTHOR", "AGE", "REPLIES", "REPOSTS", "LIKES", "TEXT", "URI"},
+		row: func(item messages.FeedViewPost) []string {
+			post := item.Post
+			return []string{
+				"@" + post.Author.Handle,
+				utils.RelativeTime(post.Record.CreatedAt),
+				strconv.Itoa(post.ReplyCount),
+				strconv.Itoa(post.RepostCount),
+				strconv.Itoa(post.LikeCount),
+				cell(post.Record.Text, 60),
+				post.URI,
+			}
+		},
+	}
+}
+
+func notificationsView(n messages.NotificationsMessage) view[messages.Notification] {
+	return view[messages.Notification]{
+		whole: n,
+		items: n.Notifications,
+		text: func(w io.Writer) {
+			for _, item := range n.Notifications {
+				unread := " "
+				if !item.IsRead {
+					unread = "•"
+				}
+				fmt.Fprintf(w, "%s %s %s · %s\n", unread, author(item.Author), item.Reason, utils.RelativeTime(item.IndexedAt))
+				if text := item.Text(); text != "" {
+					fmt.Fprintf(w, "  %s\n", strings.ReplaceAll(text, "\n", "\n  "))
+				}
+			}
+			if n.Cursor != "" {
+				fmt.Fprintf(w, "cursor: %s\n", n.Cursor)
+			}
+		},
+		header: []string{"READ", "AUTHOR", "REASON", "AGE", "TEXT", "URI"},
+		row: func(item messages.Notification) []string {
+			return []string{
+				strconv.FormatBool(item.IsRead),
+				"@" + item.Author.Handle,
+				item.Reason,
+				utils.RelativeTime(item.IndexedAt),
+				cell(item.Text(), 60),
+				item.URI,
+			}
+		},
+	}
+}
+
+func profileView(p messages.ProfileMessage) view[messages.ProfileMessage] {
+	return view[messages.ProfileMessage]{
+		whole: p,
+		items: []messages.ProfileMessage{p},
+		text: func(w io.Writer) {
+			if p.DisplayName != "" {
+				fmt.Fprintln(w, p.DisplayName)
+			}
+			fmt.Fprintf(w, "@%s (%s)\n", p.Handle, p.Did)
+			if p.Description != "" {
+				fmt.Fprintf(w, "\n%s\n\n", p.Description)
+			}
+			fmt.Fprintf(w, "%d followers · %d following · %d posts\n", p.FollowersCount, p.FollowsCount, p.PostsCount)
+			if !p.CreatedAt.IsZero() {
+				fmt.Fprintf(w, "joined %s\n", p.CreatedAt.Local().Format("January 2006"))
+			}
+		},
+		header: []string{"HANDLE", "DID", "NAME", "FOLLOWERS", "FOLLOWS", "POSTS"},
+		row: func(p messages.ProfileMessage) []string {
+			return []string{
+				"@" + p.Handle,
+				p.Did,
+				p.DisplayName,
+				strconv.Itoa(p.FollowersCount),
+				strconv.Itoa(p.FollowsCount),
+				strconv.Itoa(p.PostsCount),
+			}
+		},
+	}
+}
@@ -0,0 +1,428 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/haukened/tsky/internal/messages"
+)
+
+// GetSession describes the logged in account.
+func (c *Client) GetSession() (messages.SessionMessage, error) {
+	var out messages.SessionMessage
+	err := c.Query("com.atproto.server.getSession", nil, &out)
+	return out, err
+}
+
+// ResolveHandle returns the DID a handle points at.
+func (c *Client) ResolveHandle(handle string) (string, error) {
+	var out struct {
+		Did string `json:"did"`
+	}
+	err := c.Query("com.atproto.identity.resolveHandle", url.Values{"handle": {handle}}, &out)
+	return out.Did, err
+}
+
+// GetProfile loads the full profile of an actor, by handle or DID.
+func (c *Client) GetProfile(actor string) (messages.ProfileMessage, error) {
+	var out messages.ProfileMessage
+	err := c.Query("app.bsky.actor.getProfile", url.Values{"actor": {actor}}, &out)
+	return out, err
+}
+
+// GetTimeline loads a page of the home timeline, pass the cursor of the previous page to continue.
+func (c *Client) GetTimeline(limit int, cursor string) (messages.FeedMessage, error) {
+	var out messages.FeedMessage
+	err := c.Query("app.bsky.feed.getTimeline", pageParams(limit, cursor), &out)
+	return out, err
+}
+
+// GetAuthorFeed loads a page of the posts of actor, which ones is up to filter, one of the AUTHOR_FEED constants.
+func (c *Client) GetAuthorFeed(actor, filter string, limit int, cursor string) (messages.FeedMessage, error) {
+	var out messages.FeedMessage
+	params := pageParams(limit, cursor)
+	params.Set("actor", actor)
+	params.Set("filter", filter)
+	err := c.Query("app.bsky.feed.getAuthorFeed", params, &out)
+	return out, err
+}
+
+// GetActorLikes loads a page of the posts actor has liked. Only the logged in account's likes can be seen.
+func (c *Client) GetActorLikes(actor string, limit int, cursor string) (messages.FeedMessage, error) {
+	var out messages.FeedMessage
+	params := pageParams(limit, cursor)
+	params.Set("actor", actor)
+	err := c.Query("app.bsky.feed.getActorLikes", params, &out)
+	return out, err
+}
+
+// PostSearch is what to search posts for. Query is the text to find, the other fields narrow it down
+// and are left out when empty: Sort is one of the SEARCH_SORT constants, Author is a handle or DID,
+// Since is an RFC 3339 time and Lang a language code.
+type PostSearch struct {
+	Query  string
+	Sort   string
+	Author string
+	Since  string
+	Lang   string
+}
+
+// SearchPosts loads a page of the posts that match search.
+func (c *Client) SearchPosts(search PostSearch, limit int, cursor string) (messages.SearchPostsMessage, error) {
+	var out messages.SearchPostsMessage
+	params := pageParams(limit, cursor)
+	params.Set("q", search.Query)
+	for name, value := range map[string]string{"sort": search.Sort, "author": search.Author, "since": search.Since, "lang": search.Lang} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	err := c.Query("app.bsky.feed.searchPosts", params, &out)
+	return out, err
+}
+
+// SearchActors loads a page of the accounts that match query.
+func (c *Client) SearchActors(query string, limit int, cursor string) (messages.ActorsMessage, error) {
+	var out messages.ActorsMessage
+	params := pageParams(limit, cursor)
+	params.Set("q", query)
+	err := c.Query("app.bsky.actor.searchActors", params, &out)
+	return out, err
+}
+
+// SearchActorsTypeahead suggests accounts for the start of a name or handle.
+func (c *Client) SearchActorsTypeahead(query string, limit int) ([]messages.ProfileView, error) {
+	var out messages.ActorsMessage
+	params := pageParams(limit, "")
+	params.Set("q", query)
+	err := c.Query("app.bsky.actor.searchActorsTypeahead", params, &out)
+	return out.Actors, err
+}
+
+// GetSuggestedFeeds loads a page of the custom feeds suggested for the logged in account.
+func (c *Client) GetSuggestedFeeds(limit int, cursor string) (messages.FeedGeneratorsMessage, error) {
+	var out messages.FeedGeneratorsMessage
+	err := c.Query("app.bsky.feed.getSuggestedFeeds", pageParams(limit, cursor), &out)
+	return out, err
+}
+
+// SearchFeeds loads a page of the custom feeds that match query. There is no search in the feed lexicons
+// yet, so this uses the popular feeds query that the official app searches with.
+func (c *Client) SearchFeeds(query string, limit int, cursor string) (messages.FeedGeneratorsMessage, error) {
+	var out messages.FeedGeneratorsMessage
+	params := pageParams(limit, cursor)
+	params.Set("query", query)
+	err := c.Query("app.bsky.unspecced.getPopularFeedGenerators", params, &out)
+	return out, err
+}
+
+// GetFeed loads a page of the custom feed at uri.
+func (c *Client) GetFeed(uri string, limit int, cursor string) (messages.FeedMessage, error) {
+	var out messages.FeedMessage
+	params := pageParams(limit, cursor)
+	params.Set("feed", uri)
+	err := c.Query("app.bsky.feed.getFeed", params, &out)
+	return out, err
+}
+
+// GetFeedGenerators describes the custom feeds at uris.
+func (c *Client) GetFeedGenerators(uris ...string) ([]messages.GeneratorView, error) {
+	var out messages.FeedGeneratorsMessage
+	err := c.Query("app.bsky.feed.getFeedGenerators", url.Values{"feeds": uris}, &out)
+	return out.Feeds, err
+}
+
+// GetPreferences loads the preferences of the logged in account.
+func (c *Client) GetPreferences() (messages.Preferences, error) {
+	var out messages.PreferencesMessage
+	err := c.Query("app.bsky.actor.getPreferences", nil, &out)
+	return out.Preferences, err
+}
+
+// PutPreferences replaces the preferences of the logged in account.
+func (c *Client) PutPreferences(prefs messages.Preferences) error {
+	return c.Procedure("app.bsky.actor.putPreferences", messages.PreferencesMessage{Preferences: prefs}, nil)
+}
+
+// PutSavedFeeds replaces the saved feeds. The preferences are loaded again first so that whatever else
+// was changed elsewhere in the meantime is kept.
+func (c *Client) PutSavedFeeds(items []messages.SavedFeed) error {
+	prefs, err := c.GetPreferences()
+	if err != nil {
+		return err
+	}
+	prefs, err = prefs.WithSavedFeeds(items)
+	if err != nil {
+		return err
+	}
+	return c.PutPreferences(prefs)
+}
+
+// GetLists loads a page of the lists actor has made.
+func (c *Client) GetLists(actor string, limit int, cursor string) (messages.ListsMessage, error) {
+	var out messages.ListsMessage
+	params := pageParams(limit, cursor)
+	params.Set("actor", actor)
+	err := c.Query("app.bsky.graph.getLists", params, &out)
+	return out, err
+}
+
+// GetList loads the list at uri with a page of its members.
+func (c *Client) GetList(uri string, limit int, cursor string) (messages.ListMessage, error) {
+	var out messages.ListMessage
+	params := pageParams(limit, cursor)
+	params.Set("list", uri)
+	err := c.Query("app.bsky.graph.getList", params, &out)
+	return out, err
+}
+
+// GetListFeed loads a page of the posts of the members of the list at uri.
+func (c *Client) GetListFeed(uri string, limit int, cursor string) (messages.FeedMessage, error) {
+	var out messages.FeedMessage
+	params := pageParams(limit, cursor)
+	params.Set("list", uri)
+	err := c.Query("app.bsky.graph.getListFeed", params, &out)
+	return out, err
+}
+
+// CreateList makes a new curation list called name.
+func (c *Client) CreateList(name string) (messages.StrongRef, error) {
+	return c.CreateRecord(messages.LIST_COLLECTION, messages.ListRecord{
+		Type:      messages.LIST_COLLECTION,
+		Purpose:   messages.LIST_PURPOSE_CURATE,
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// RenameList renames the list at uri, leaving the rest of the record as it is.
+func (c *Client) RenameList(uri, name string) (messages.StrongRef, error) {
+	var record map[string]any
+	cid, err := c.GetRecord(uri, &record)
+	if err != nil {
+		return messages.StrongRef{}, err
+	}
+	record["name"] = name
+	return c.PutRecord(uri, record, cid)
+}
+
+// DeleteList takes everyone off the list at uri, then deletes it.
+func (c *Client) DeleteList(uri string) error {
+	cursor := ""
+	for {
+		page, err := c.GetList(uri, 100, cursor)
+		if err != nil {
+			return err
+		}
+		for _, item := range page.Items {
+			if err := c.DeleteRecord(item.URI); err != nil {
+				return err
+			}
+		}
+		if page.Cursor == "" || len(page.Items) == 0 {
+			break
+		}
+		cursor = page.Cursor
+	}
+	return c.DeleteRecord(uri)
+}
+
+// AddToList puts the account with did on the list at list.
+func (c *Client) AddToList(list, did string) (messages.StrongRef, error) {
+	return c.CreateRecord(messages.LISTITEM_COLLECTION, messages.ListItemRecord{
+		Type:      messages.LISTITEM_COLLECTION,
+		Subject:   did,
+		List:      list,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// ListMembership returns the listitem record that puts the account with did on the list at list,
+// or nothing if they are not on it.
+func (c *Client) ListMembership(list, did string) (string, error) {
+	cursor := ""
+	for {
+		page, err := c.GetList(list, 100, cursor)
+		if err != nil {
+			return "", err
+		}
+		for _, item := range page.Items {
+			if item.Subject.Did == did {
+				return item.URI, nil
+			}
+		}
+		if page.Cursor == "" || len(page.Items) == 0 {
+			return "", nil
+		}
+		cursor = page.Cursor
+	}
+}
+
+// GetPostThread loads the post at uri with depth levels of replies below it and parentHeight posts above it.
+func (c *Client) GetPostThread(uri string, depth, parentHeight int) (messages.ThreadMessage, error) {
+	params := url.Values{
+		"uri":          {uri},
+		"depth":        {strconv.Itoa(depth)},
+		"parentHeight": {strconv.Itoa(parentHeight)},
+	}
+	var out messages.ThreadMessage
+	err := c.Query("app.bsky.feed.getPostThread", params, &out)
+	return out, err
+}
+
+// GetPosts loads the posts at uris. Posts that no longer exist are left out.
+func (c *Client) GetPosts(uris ...string) ([]messages.PostView, error) {
+	var out struct {
+		Posts []messages.PostView `json:"posts"`
+	}
+	err := c.Query("app.bsky.feed.getPosts", url.Values{"uris": uris}, &out)
+	return out.Posts, err
+}
+
+// ListNotifications loads a page of notifications, pass the cursor of the previous page to continue.
+func (c *Client) ListNotifications(limit int, cursor string) (messages.NotificationsMessage, error) {
+	var out messages.NotificationsMessage
+	err := c.Query("app.bsky.notification.listNotifications", pageParams(limit, cursor), &out)
+	return out, err
+}
+
+// GetUnreadCount counts the notifications that arrived since they were last seen.
+func (c *Client) GetUnreadCount() (int, error) {
+	var out struct {
+		Count int `json:"count"`
+	}
+	err := c.Query("app.bsky.notification.getUnreadCount", nil, &out)
+	return out.Count, err
+}
+
+// UpdateSeen marks the notifications that arrived before seenAt as seen.
+func (c *Client) UpdateSeen(seenAt time.Time) error {
+	in := map[string]any{"seenAt": seenAt.UTC().Format(time.RFC3339Nano)}
+	return c.Procedure("app.bsky.notification.updateSeen", in, nil)
+}
+
+// CreateRecord writes a new record to a collection in the logged in account's repo.
+func (c *Client) CreateRecord(collection string, record any) (messages.StrongRef, error) {
+	in := map[string]any{
+		"repo":       c.Did(),
+		"collection": collection,
+		"record":     record,
+	}
+	var out messages.StrongRef
+	err := c.Procedure("com.atproto.repo.createRecord", in, &out)
+	return out, err
+}
+
+// GetRecord decodes the record at uri into out, and returns the CID of the version that was read.
+func (c *Client) GetRecord(uri string, out any) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(uri, "at://"), "/")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("not a record URI: %s", uri)
+	}
+	params := url.Values{"repo": {parts[0]}, "collection": {parts[1]}, "rkey": {parts[2]}}
+	var record struct {
+		CID   string          `json:"cid"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := c.Query("com.atproto.repo.getRecord", params, &record); err != nil {
+		return "", err
+	}
+	return record.CID, json.Unmarshal(record.Value, out)
+}
+
+// PutRecord replaces the record at uri, which must be in the logged in account's repo. If swap is set the
+// record is only replaced if it is still at that version.
+func (c *Client) PutRecord(uri string, record any, swap string) (messages.StrongRef, error) {
+	parts := strings.Split(strings.TrimPrefix(uri, "at://"), "/")
+	if len(parts) != 3 {
+		return messages.StrongRef{}, fmt.Errorf("not a record URI: %s", uri)
+	}
+	in := map[string]any{
+		"repo":       c.Did(),
+		"collection": parts[1],
+		"rkey":       parts[2],
+		"record":     record,
+	}
+	if swap != "" {
+		in["swapRecord"] = swap
+	}
+	var out messages.StrongRef
+	err := c.Procedure("com.atproto.repo.putRecord", in, &out)
+	return out, err
+}
+
+// DeleteRecord deletes the record at uri, which must be in the logged in account's repo.
+func (c *Client) DeleteRecord(uri string) error {
+	parts := strings.Split(strings.TrimPrefix(uri, "at://"), "/")
+	if len(parts) != 3 {
+		return fmt.Errorf("not a record URI: %s", uri)
+	}
+	in := map[string]any{
+		"repo":       c.Did(),
+		"collection": parts[1],
+		"rkey":       parts[2],
+	}
+	return c.Procedure("com.atproto.repo.deleteRecord", in, nil)
+}
+
+// Like likes the post subject points at.
+func (c *Client) Like(subject messages.StrongRef) (messages.StrongRef, error) {
+	return c.CreateRecord(messages.LIKE_COLLECTION, messages.SubjectRecord{
+		Type:      messages.LIKE_COLLECTION,
+		Subject:   subject,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// Repost reposts the post subject points at.
+func (c *Client) Repost(subject messages.StrongRef) (messages.StrongRef, error) {
+	return c.CreateRecord(messages.REPOST_COLLECTION, messages.SubjectRecord{
+		Type:      messages.REPOST_COLLECTION,
+		Subject:   subject,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// Follow follows the account with did.
+func (c *Client) Follow(did string) (messages.StrongRef, error) {
+	return c.CreateRecord(messages.FOLLOW_COLLECTION, messages.FollowRecord{
+		Type:      messages.FOLLOW_COLLECTION,
+		Subject:   did,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// CreatePost publishes a post, filling in the record type and creation time if they are unset.
+func (c *Client) CreatePost(post messages.PostRecord) (messages.StrongRef, error) {
+	if post.Type == "" {
+		post.Type = messages.POST_COLLECTION
+	}
+	if post.CreatedAt.IsZero() {
+		post.CreatedAt = time.Now().UTC()
+	}
+	return c.CreateRecord(messages.POST_COLLECTION, post)
+}
+
+// UploadBlob uploads a file so it can be referenced from a record, e.g. an image in a post.
+func (c *Client) UploadBlob(mimeType string, data []byte) (messages.Blob, error) {
+	var out struct {
+		Blob messages.Blob `json:"blob"`
+	}
+	err := c.Upload("com.atproto.repo.uploadBlob", mimeType, data, &out)
+	return out.Blob, err
+}
+
+func pageParams(limit int, cursor string) url.Values {
+	params := url.Values{}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	return params
+}
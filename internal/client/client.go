@@ -1,13 +1,42 @@
 package client
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 
+	"github.com/haukened/tsky/internal/logging"
 	"github.com/haukened/tsky/internal/tokensvc"
 	"github.com/haukened/tsky/internal/utils"
 )
 
+const XRPC_URI_BASE = "https://%s/xrpc/%s"
+
+var logger = logging.For("client")
+
+var ErrHttpError = errors.New("http error in underlying client")
+
+// XRPCError is the error body an XRPC server returns with a non 2xx status.
+type XRPCError struct {
+	StatusCode int    `json:"-"`
+	Name       string `json:"error"`
+	Message    string `json:"message"`
+}
+
+func (e *XRPCError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s: %s", e.Name, e.Message)
+	}
+	if e.Name != "" {
+		return e.Name
+	}
+	return fmt.Sprintf("xrpc request failed with status %d", e.StatusCode)
+}
+
 type Client struct {
 	tokSvc     *tokensvc.Refresher
 	httpClient *http.Client
@@ -16,10 +45,20 @@ type Client struct {
 func New(tokSvc *tokensvc.Refresher) *Client {
 	return &Client{
 		tokSvc:     tokSvc,
-		httpClient: &http.Client{},
+		httpClient: &http.Client{Timeout: utils.HTTP_TIMEOUT},
 	}
 }
 
+// Did returns the DID of the logged in account.
+func (c *Client) Did() string {
+	return c.tokSvc.Did()
+}
+
+// Handle returns the handle of the logged in account.
+func (c *Client) Handle() string {
+	return c.tokSvc.Handle()
+}
+
 func (c *Client) NewRequest(method, url string) (*http.Request, error) {
 	req, err := http.NewRequest(method, url, nil)
 	if err != nil {
@@ -28,5 +67,75 @@ func (c *Client) NewRequest(method, url string) (*http.Request, error) {
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.tokSvc.AuthToken()))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", utils.UserAgent())
+	logger.Debug("new request", "method", method, "url", url)
 	return req, nil
 }
+
+// Query calls an XRPC query (GET) and decodes the response into out.
+func (c *Client) Query(nsid string, params url.Values, out any) error {
+	URL := fmt.Sprintf(XRPC_URI_BASE, c.tokSvc.Server(), nsid)
+	if len(params) > 0 {
+		URL += "?" + params.Encode()
+	}
+	req, err := c.NewRequest(http.MethodGet, URL)
+	if err != nil {
+		return err
+	}
+	return c.do(req, out)
+}
+
+// Procedure calls an XRPC procedure (POST) with in as the JSON body and decodes the response into out.
+// Either in or out can be nil.
+func (c *Client) Procedure(nsid string, in, out any) error {
+	URL := fmt.Sprintf(XRPC_URI_BASE, c.tokSvc.Server(), nsid)
+	req, err := c.NewRequest(http.MethodPost, URL)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		body, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+	return c.do(req, out)
+}
+
+// Upload calls an XRPC procedure with a raw body, like com.atproto.repo.uploadBlob.
+func (c *Client) Upload(nsid, mimeType string, data []byte, out any) error {
+	URL := fmt.Sprintf(XRPC_URI_BASE, c.tokSvc.Server(), nsid)
+	req, err := c.NewRequest(http.MethodPost, URL)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mimeType)
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	return c.do(req, out)
+}
+
+func (c *Client) do(req *http.Request, out any) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		logger.Warn("request failed", "url", req.URL.Path, "err", err)
+		return fmt.Errorf("%w: %w", ErrHttpError, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		xerr := &XRPCError{StatusCode: resp.StatusCode}
+		// the body is best effort, some proxies answer with html
+		json.Unmarshal(body, xerr)
+		logger.Warn("xrpc error", "url", req.URL.Path, "status", resp.StatusCode, "err", xerr)
+		return xerr
+	}
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
@@ -1,12 +1,19 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/user"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/haukened/tsky/internal/logging"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/providers/file"
@@ -14,17 +21,56 @@ import (
 	yaml2 "gopkg.in/yaml.v2"
 )
 
-var k = koanf.New(".")
+const (
+	DEFAULT_SERVER           = "bsky.social"
+	DEFAULT_THEME            = "default"
+	DEFAULT_REFRESH_INTERVAL = 5 * time.Minute
+	MIN_REFRESH_INTERVAL     = 10 * time.Second
+	// editors often write a file in several steps, wait for them to finish before reloading
+	WATCH_DEBOUNCE = 150 * time.Millisecond
+)
+
+// Orders replies in a thread can be sorted in, for the thread_sort key.
+const (
+	THREAD_SORT_OLDEST   = "oldest"
+	THREAD_SORT_NEWEST   = "newest"
+	THREAD_SORT_LIKES    = "likes"
+	THREAD_SORT_FOLLOWED = "followed"
+)
+
+// ThreadSorts are the valid values of thread_sort, the first is the default.
+var ThreadSorts = []string{THREAD_SORT_OLDEST, THREAD_SORT_NEWEST, THREAD_SORT_LIKES, THREAD_SORT_FOLLOWED}
+
+var (
+	ErrRefreshIntervalTooShort = fmt.Errorf("refresh_interval must be at least %s", MIN_REFRESH_INTERVAL)
+	ErrEmptyKeyBinding         = errors.New("key bindings cannot be empty")
+)
 
 type Config struct {
-	Did         string `koanf:"did" yaml:"did"`
-	Identifier  string `koanf:"identifier" yaml:"identifier"`
-	RefreshJwt  string `koanf:"refresh_jwt" yaml:"refresh_jwt"`
-	AccessJwt   string `koanf:"-" yaml:"-"` // do not marshal this field
-	AppPassword string `koanf:"-" yaml:"-"` // do not marshal this field
-	Path        string `koanf:"-" yaml:"-"` // do not marshal this field
-	Server      string `koanf:"server,omitempty" yaml:"server,omitempty"`
-	Debug       bool   `koanf:"debug,omitempty" yaml:"debug,omitempty"`
+	// mu guards the session fields and Save, the token service refreshes the session from its own goroutine
+	mu              sync.Mutex
+	Did             string              `koanf:"did" yaml:"did"`
+	Identifier      string              `koanf:"identifier" yaml:"identifier"`
+	RefreshJwt      string              `koanf:"refresh_jwt" yaml:"refresh_jwt"`
+	AccessJwt       string              `koanf:"-" yaml:"-"` // do not marshal this field
+	AppPassword     string              `koanf:"-" yaml:"-"` // do not marshal this field
+	Path            string              `koanf:"-" yaml:"-"` // do not marshal this field
+	Server          string              `koanf:"server,omitempty" yaml:"server,omitempty"`
+	Debug           bool                `koanf:"debug,omitempty" yaml:"debug,omitempty"`
+	Theme           string              `koanf:"theme,omitempty" yaml:"theme,omitempty"`
+	Keymap          string              `koanf:"keymap,omitempty" yaml:"keymap,omitempty"`
+	Keys            map[string][]string `koanf:"keys,omitempty" yaml:"keys,omitempty"`
+	RefreshInterval string              `koanf:"refresh_interval,omitempty" yaml:"refresh_interval,omitempty"`
+	LogLevel        string              `koanf:"log_level,omitempty" yaml:"log_level,omitempty"`
+	ThreadSort      string              `koanf:"thread_sort,omitempty" yaml:"thread_sort,omitempty"`
+	LevelOverride   string              `koanf:"-" yaml:"-"` // set from the command line, wins over the file
+}
+
+// Change is delivered by Watch every time the config file is modified.
+// Exactly one of Config or Err is set.
+type Change struct {
+	Config *Config
+	Err    error
 }
 
 func New(path string) (*Config, error) {
@@ -70,6 +116,9 @@ func (c *Config) Exists() bool {
 //   - *Config: A pointer to the loaded Config struct.
 //   - error: An error if any occurred during the loading process.
 func (c *Config) Load() error {
+	// use a fresh instance every time so a reload does not inherit stale keys
+	k := koanf.New(".")
+
 	if c.Exists() {
 		// check the file permissions
 		if err := c.checkFilePermissions(); err != nil {
@@ -99,19 +148,187 @@ func (c *Config) Load() error {
 
 	// set the default server if it is not set
 	if c.Server == "" {
-		c.Server = "bsky.social"
+		c.Server = DEFAULT_SERVER
+	}
+
+	// set the default theme if it is not set
+	if c.Theme == "" {
+		c.Theme = DEFAULT_THEME
 	}
 
 	return nil
 }
 
+// Validate checks the user editable settings for values that cannot be applied.
+func (c *Config) Validate() error {
+	if c.RefreshInterval != "" {
+		d, err := time.ParseDuration(c.RefreshInterval)
+		if err != nil {
+			return fmt.Errorf("invalid refresh_interval %q: %w", c.RefreshInterval, err)
+		}
+		if d < MIN_REFRESH_INTERVAL {
+			return ErrRefreshIntervalTooShort
+		}
+	}
+	if c.LogLevel != "" {
+		if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+			return err
+		}
+	}
+	if c.ThreadSort != "" && !slices.Contains(ThreadSorts, c.ThreadSort) {
+		return fmt.Errorf("invalid thread_sort %q, valid orders are %v", c.ThreadSort, ThreadSorts)
+	}
+	for action, keys := range c.Keys {
+		if len(keys) == 0 {
+			return fmt.Errorf("%w: %s", ErrEmptyKeyBinding, action)
+		}
+		for _, key := range keys {
+			if strings.TrimSpace(key) == "" {
+				return fmt.Errorf("%w: %s", ErrEmptyKeyBinding, action)
+			}
+		}
+	}
+	return nil
+}
+
+// RefreshEvery returns the parsed refresh interval, or the default if it is unset or invalid.
+func (c *Config) RefreshEvery() time.Duration {
+	d, err := time.ParseDuration(c.RefreshInterval)
+	if err != nil || d < MIN_REFRESH_INTERVAL {
+		return DEFAULT_REFRESH_INTERVAL
+	}
+	return d
+}
+
+// ThreadOrder returns the order replies in a thread are sorted in, or the default if it is unset or invalid.
+func (c *Config) ThreadOrder() string {
+	if slices.Contains(ThreadSorts, c.ThreadSort) {
+		return c.ThreadSort
+	}
+	return ThreadSorts[0]
+}
+
+// Level returns the configured log level.
+// A command line override wins, then the debug flag, then the log_level key.
+func (c *Config) Level() slog.Level {
+	if l, err := logging.ParseLevel(c.LevelOverride); err == nil && c.LevelOverride != "" {
+		return l
+	}
+	if c.Debug {
+		return slog.LevelDebug
+	}
+	l, err := logging.ParseLevel(c.LogLevel)
+	if err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// Reload reads the config file from disk into a new Config without touching the receiver.
+// The returned Config has already been validated.
+func (c *Config) Reload() (*Config, error) {
+	n := &Config{Path: c.Path}
+	if err := n.Load(); err != nil {
+		return nil, err
+	}
+	if err := n.Validate(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Apply copies the settings that can be changed while tsky is running from n into c,
+// and reports whether any of them changed. Session fields like the tokens and identifier are left alone.
+func (c *Config) Apply(n *Config) bool {
+	// the token service may be saving the config while it changes
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed := c.Theme != n.Theme ||
+		c.Keymap != n.Keymap ||
+		!reflect.DeepEqual(c.Keys, n.Keys) ||
+		c.RefreshInterval != n.RefreshInterval ||
+		c.Debug != n.Debug ||
+		c.LogLevel != n.LogLevel ||
+		c.ThreadSort != n.ThreadSort
+	c.Theme = n.Theme
+	c.Keymap = n.Keymap
+	c.Keys = n.Keys
+	c.RefreshInterval = n.RefreshInterval
+	c.Debug = n.Debug
+	c.LogLevel = n.LogLevel
+	c.ThreadSort = n.ThreadSort
+	return changed
+}
+
+// Watch watches the config file and sends a Change every time it is written.
+// Invalid edits are reported as a Change with Err set, and the receiver is never modified.
+// Only the latest Change is kept, one that has not been received yet is replaced by the next.
+func (c *Config) Watch() (<-chan Change, error) {
+	ch := make(chan Change, 1)
+	var mu sync.Mutex
+	// send never blocks the watcher, a pending change is stale once the file is written again
+	send := func(change Change) {
+		mu.Lock()
+		defer mu.Unlock()
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- change
+	}
+	reload := time.AfterFunc(WATCH_DEBOUNCE, func() {
+		n, err := c.Reload()
+		send(Change{Config: n, Err: err})
+	})
+	reload.Stop()
+	f := file.Provider(c.Path)
+	err := f.Watch(func(_ interface{}, err error) {
+		if err != nil {
+			send(Change{Err: err})
+			return
+		}
+		reload.Reset(WATCH_DEBOUNCE)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// SetSession stores the tokens and DID of a session, it is safe to call while the config is in use elsewhere.
+func (c *Config) SetSession(access, refresh, did string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.AccessJwt = access
+	c.RefreshJwt = refresh
+	c.Did = did
+}
+
+// Session returns the refresh token and DID of the stored session.
+func (c *Config) Session() (refresh, did string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.RefreshJwt, c.Did
+}
+
 func (c *Config) Save() error {
-	// marshal the data into yaml
-	data, err := yaml2.Marshal(&c)
+	data, err := c.Encode()
 	if err != nil {
 		return err
 	}
+	return c.Write(data)
+}
+
+// Encode returns the config as it is saved. It lets the config be changed on one goroutine
+// and written on another, without sharing it.
+func (c *Config) Encode() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return yaml2.Marshal(&c)
+}
 
+// Write saves data from Encode as the config file.
+func (c *Config) Write(data []byte) error {
 	// ensure the directory exists
 	if err := os.MkdirAll(filepath.Dir(c.Path), 0700); err != nil {
 		return err
@@ -125,6 +342,15 @@ func (c *Config) Save() error {
 	return nil
 }
 
+// StateDir returns the directory tsky keeps logs and other state in.
+// It is $XDG_STATE_HOME/tsky, or ~/.local/state/tsky if that is not set.
+func StateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "tsky"), nil
+	}
+	return expandHomeDir("~/.local/state/tsky")
+}
+
 func expandHomeDir(path string) (string, error) {
 	usr, err := user.Current()
 	if err != nil {
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// TABS_FILE is where the open tabs are kept between runs, inside StateDir.
+const TABS_FILE = "tabs.json"
+
+// TabState is the view a tab was showing, enough to open it again.
+type TabState struct {
+	Route  string            `json:"route"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// Tabs are the tabs that were open when tsky last exited, and which one was selected.
+// Did is the account they were open for, they are not reopened for any other.
+type Tabs struct {
+	Did     string     `json:"did,omitempty"`
+	Current int        `json:"current"`
+	Open    []TabState `json:"open"`
+}
+
+// LoadTabs reads the tabs saved for the account did. It returns no tabs and no error if none have been
+// saved yet, or they were saved for another account.
+func LoadTabs(did string) (Tabs, error) {
+	var t Tabs
+	path, err := tabsPath()
+	if err != nil {
+		return t, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return t, err
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Tabs{}, err
+	}
+	if t.Did != did {
+		return Tabs{}, nil
+	}
+	return t, nil
+}
+
+// SaveTabs replaces the saved tabs.
+func SaveTabs(t Tabs) error {
+	path, err := tabsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func tabsPath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, TABS_FILE), nil
+}
@@ -0,0 +1,238 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_MAX_SIZE    = 5 * 1024 * 1024
+	DEFAULT_MAX_BACKUPS = 3
+	RECENT_LINES        = 500
+	// ROTATE_RETRY is how long a log file that could not be rotated keeps growing before trying again
+	ROTATE_RETRY = time.Minute
+)
+
+var (
+	level  = new(slog.LevelVar)
+	out    = &switchWriter{w: io.Discard}
+	recent = newRing(RECENT_LINES)
+	root   = slog.NewTextHandler(out, &slog.HandlerOptions{Level: level})
+)
+
+type Options struct {
+	Level      slog.Level
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+}
+
+// For returns the logger for a subsystem, e.g. auth, tokensvc, client or tui.
+// Loggers can be created before Setup is called, they start writing once it is.
+func For(subsystem string) *slog.Logger {
+	return slog.New(root).With("subsystem", subsystem)
+}
+
+// Setup starts writing log records at or above opts.Level to a size rotated file at opts.Path.
+// The returned io.Closer closes the log file.
+func Setup(opts Options) (io.Closer, error) {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DEFAULT_MAX_SIZE
+	}
+	if opts.MaxBackups <= 0 {
+		opts.MaxBackups = DEFAULT_MAX_BACKUPS
+	}
+	f, err := openRotating(opts.Path, opts.MaxSize, opts.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	level.Set(opts.Level)
+	out.set(io.MultiWriter(f, recent))
+	// anything still using the standard library logger ends up in the same file
+	slog.SetDefault(For("tsky"))
+	return f, nil
+}
+
+// SetLevel changes the minimum level of every logger while tsky is running.
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// Level returns the current minimum level.
+func Level() slog.Level {
+	return level.Level()
+}
+
+// ParseLevel converts a level name like debug, info, warn or error into a slog.Level.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return l, fmt.Errorf("invalid log level %q, use debug, info, warn or error", s)
+	}
+	return l, nil
+}
+
+// Recent returns the most recent log lines, oldest first.
+func Recent() []string {
+	return recent.lines()
+}
+
+// switchWriter lets the handler be created at init time and pointed at the real file later.
+type switchWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *switchWriter) set(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w = w
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// ring keeps the last n lines written to it so they can be shown inside the TUI.
+type ring struct {
+	mu    sync.Mutex
+	buf   []string
+	next  int
+	count int
+}
+
+func newRing(n int) *ring {
+	return &ring{buf: make([]string, n)}
+}
+
+func (r *ring) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		r.buf[r.next] = line
+		r.next = (r.next + 1) % len(r.buf)
+		r.count = min(r.count+1, len(r.buf))
+	}
+	return len(p), nil
+}
+
+func (r *ring) lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lines := make([]string, 0, r.count)
+	start := (r.next - r.count + len(r.buf)) % len(r.buf)
+	for i := 0; i < r.count; i++ {
+		lines = append(lines, r.buf[(start+i)%len(r.buf)])
+	}
+	return lines
+}
+
+// rotatingFile is an append only file that is rotated to path.1, path.2... once it grows past maxSize.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	size       int64
+	f          *os.File
+	// failed is when rotating last failed, it is not tried again until ROTATE_RETRY has passed
+	failed time.Time
+}
+
+func openRotating(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	r := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f = f
+	r.size = info.Size()
+	return nil
+}
+
+// rotate moves the file to path.1 and starts a new one. If it can not be moved the same file is opened
+// again, so logging carries on past the size limit rather than stopping.
+func (r *rotatingFile) rotate() error {
+	r.f.Close()
+	r.f = nil
+	// shift the backups up into the first free slot, the oldest only falls off the end once they are
+	// all taken. A retry after a failed rename finds path.1 free and moves nothing.
+	free := r.maxBackups
+	for i := 1; i < r.maxBackups; i++ {
+		if _, err := os.Stat(r.backup(i)); errors.Is(err, fs.ErrNotExist) {
+			free = i
+			break
+		}
+	}
+	for i := free - 1; i > 0; i-- {
+		os.Rename(r.backup(i), r.backup(i+1))
+	}
+	renamed := os.Rename(r.path, r.backup(1))
+	if err := r.open(); err != nil {
+		return err
+	}
+	return renamed
+}
+
+func (r *rotatingFile) backup(i int) string {
+	return fmt.Sprintf("%s.%d", r.path, i)
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size+int64(len(p)) > r.maxSize && r.size > 0 && time.Since(r.failed) >= ROTATE_RETRY {
+		// a failed rotation is not worth losing the record over, it is noted in the file and tried later
+		if err := r.rotate(); err != nil {
+			r.failed = time.Now()
+			if r.f != nil {
+				// written straight to the file, logging it normally would come back into this Write
+				slog.New(slog.NewTextHandler(r.f, nil)).Warn("unable to rotate log file",
+					"subsystem", "logging", "err", err, "retry", ROTATE_RETRY)
+			}
+		}
+	}
+	if r.f == nil {
+		// the file could not be opened again after the last rotation, try once more
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Close()
+}
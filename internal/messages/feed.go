@@ -0,0 +1,347 @@
+package messages
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ProfileViewBasic is the short form of a profile that is embedded in posts and notifications.
+type ProfileViewBasic struct {
+	Did         string              `json:"did"`
+	Handle      string              `json:"handle"`
+	DisplayName string              `json:"displayName,omitempty"`
+	Avatar      string              `json:"avatar,omitempty"`
+	Viewer      *ProfileViewerState `json:"viewer,omitempty"`
+	Labels      []any               `json:"labels,omitempty"`
+	CreatedAt   time.Time           `json:"createdAt"`
+}
+
+// ProfileViewerState is the relationship between the logged in account and a profile.
+type ProfileViewerState struct {
+	Muted      bool   `json:"muted"`
+	BlockedBy  bool   `json:"blockedBy"`
+	Blocking   string `json:"blocking,omitempty"`
+	Following  string `json:"following,omitempty"`
+	FollowedBy string `json:"followedBy,omitempty"`
+}
+
+// StrongRef points at a specific version of a record.
+type StrongRef struct {
+	URI string `json:"uri"`
+	CID string `json:"cid"`
+}
+
+// ReplyRef is the reply field of a post record.
+type ReplyRef struct {
+	Root   StrongRef `json:"root"`
+	Parent StrongRef `json:"parent"`
+}
+
+// ByteSlice is a range of UTF-8 bytes in the text of a post, end exclusive.
+type ByteSlice struct {
+	ByteStart int `json:"byteStart"`
+	ByteEnd   int `json:"byteEnd"`
+}
+
+const (
+	FACET_MENTION = "app.bsky.richtext.facet#mention"
+	FACET_LINK    = "app.bsky.richtext.facet#link"
+	FACET_TAG     = "app.bsky.richtext.facet#tag"
+)
+
+// FacetFeature is one of a mention, link or tag, the $type says which field is set.
+type FacetFeature struct {
+	Type string `json:"$type"`
+	Did  string `json:"did,omitempty"`
+	URI  string `json:"uri,omitempty"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+// Facet annotates a range of the post text.
+type Facet struct {
+	Index    ByteSlice      `json:"index"`
+	Features []FacetFeature `json:"features"`
+}
+
+// BlobRef is the content link of an uploaded blob.
+type BlobRef struct {
+	Link string `json:"$link"`
+}
+
+// Blob is a file uploaded with com.atproto.repo.uploadBlob that a record refers to.
+type Blob struct {
+	Type     string  `json:"$type"`
+	Ref      BlobRef `json:"ref"`
+	MimeType string  `json:"mimeType"`
+	Size     int64   `json:"size"`
+}
+
+const (
+	EMBED_IMAGES            = "app.bsky.embed.images"
+	EMBED_EXTERNAL          = "app.bsky.embed.external"
+	EMBED_RECORD            = "app.bsky.embed.record"
+	EMBED_RECORD_WITH_MEDIA = "app.bsky.embed.recordWithMedia"
+)
+
+// AspectRatio is the width to height ratio of an image.
+type AspectRatio struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// EmbedImage is a single image attached to a post.
+type EmbedImage struct {
+	Alt         string       `json:"alt"`
+	Image       Blob         `json:"image"`
+	AspectRatio *AspectRatio `json:"aspectRatio,omitempty"`
+}
+
+// EmbedExternal is a link card attached to a post.
+type EmbedExternal struct {
+	URI         string `json:"uri"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Thumb       *Blob  `json:"thumb,omitempty"`
+}
+
+// EmbedRecordRef is the record field of a record embed. A plain record embed holds the strong ref
+// directly, while a record with media embed wraps it in another record embed, so both shapes decode here.
+type EmbedRecordRef struct {
+	Type   string     `json:"$type,omitempty"`
+	URI    string     `json:"uri,omitempty"`
+	CID    string     `json:"cid,omitempty"`
+	Record *StrongRef `json:"record,omitempty"`
+}
+
+// Ref returns the strong ref to the embedded record whichever shape it was written in.
+func (r EmbedRecordRef) Ref() StrongRef {
+	if r.Record != nil {
+		return *r.Record
+	}
+	return StrongRef{URI: r.URI, CID: r.CID}
+}
+
+// RecordEmbed is the embed field of a post record, $type says which fields are set.
+type RecordEmbed struct {
+	Type     string          `json:"$type"`
+	Images   []EmbedImage    `json:"images,omitempty"`
+	External *EmbedExternal  `json:"external,omitempty"`
+	Record   *EmbedRecordRef `json:"record,omitempty"`
+	Media    *RecordEmbed    `json:"media,omitempty"`
+}
+
+// Embed views are the hydrated embeds of a post view, the $type says which fields are set.
+const (
+	EMBED_IMAGES_VIEW            = "app.bsky.embed.images#view"
+	EMBED_VIDEO_VIEW             = "app.bsky.embed.video#view"
+	EMBED_EXTERNAL_VIEW          = "app.bsky.embed.external#view"
+	EMBED_RECORD_VIEW            = "app.bsky.embed.record#view"
+	EMBED_RECORD_WITH_MEDIA_VIEW = "app.bsky.embed.recordWithMedia#view"
+)
+
+// The kinds of record a record embed view can show.
+const (
+	EMBED_VIEW_RECORD    = "app.bsky.embed.record#viewRecord"
+	EMBED_VIEW_NOT_FOUND = "app.bsky.embed.record#viewNotFound"
+	EMBED_VIEW_BLOCKED   = "app.bsky.embed.record#viewBlocked"
+	EMBED_VIEW_DETACHED  = "app.bsky.embed.record#viewDetached"
+	GENERATOR_VIEW       = "app.bsky.feed.defs#generatorView"
+	LIST_VIEW            = "app.bsky.graph.defs#listView"
+)
+
+// EmbedImageView is an image of an images embed view.
+type EmbedImageView struct {
+	Thumb       string       `json:"thumb"`
+	Fullsize    string       `json:"fullsize"`
+	Alt         string       `json:"alt"`
+	AspectRatio *AspectRatio `json:"aspectRatio,omitempty"`
+}
+
+// EmbedExternalView is the link card of an external embed view.
+type EmbedExternalView struct {
+	URI         string `json:"uri"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Thumb       string `json:"thumb,omitempty"`
+}
+
+// EmbeddedRecord is the record of a record embed view, a union of a quoted post, a post that can not
+// be shown, a feed or a list. A record with media view wraps it in another record view, so that shape
+// decodes here too, with Record set.
+type EmbeddedRecord struct {
+	Type        string            `json:"$type"`
+	URI         string            `json:"uri"`
+	CID         string            `json:"cid,omitempty"`
+	Author      ProfileViewBasic  `json:"author"`
+	Value       PostRecord        `json:"value"`
+	Embeds      []EmbedView       `json:"embeds,omitempty"`
+	ReplyCount  int               `json:"replyCount"`
+	RepostCount int               `json:"repostCount"`
+	LikeCount   int               `json:"likeCount"`
+	QuoteCount  int               `json:"quoteCount"`
+	IndexedAt   time.Time         `json:"indexedAt"`
+	Creator     *ProfileViewBasic `json:"creator,omitempty"`
+	DisplayName string            `json:"displayName,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Record      *EmbeddedRecord   `json:"record,omitempty"`
+}
+
+// View returns the record being shown whichever shape it was sent in.
+func (r EmbeddedRecord) View() EmbeddedRecord {
+	if r.Record != nil {
+		return *r.Record
+	}
+	return r
+}
+
+// EmbedView is the embed field of a post view.
+type EmbedView struct {
+	Type     string             `json:"$type"`
+	Images   []EmbedImageView   `json:"images,omitempty"`
+	External *EmbedExternalView `json:"external,omitempty"`
+	Record   *EmbeddedRecord    `json:"record,omitempty"`
+	Media    *EmbedView         `json:"media,omitempty"`
+	Alt      string             `json:"alt,omitempty"`
+}
+
+const POST_COLLECTION = "app.bsky.feed.post"
+
+// PostRecord is an app.bsky.feed.post record.
+type PostRecord struct {
+	Type      string       `json:"$type"`
+	Text      string       `json:"text"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Facets    []Facet      `json:"facets,omitempty"`
+	Reply     *ReplyRef    `json:"reply,omitempty"`
+	Embed     *RecordEmbed `json:"embed,omitempty"`
+	Langs     []string     `json:"langs,omitempty"`
+}
+
+// PostViewerState is the relationship between the logged in account and a post.
+type PostViewerState struct {
+	Repost            string `json:"repost,omitempty"`
+	Like              string `json:"like,omitempty"`
+	ThreadMuted       bool   `json:"threadMuted,omitempty"`
+	ReplyDisabled     bool   `json:"replyDisabled,omitempty"`
+	EmbeddingDisabled bool   `json:"embeddingDisabled,omitempty"`
+}
+
+// PostView is a hydrated post. Where the lexicon allows a not found or blocked post
+// instead, the same struct is used with NotFound or Blocked set.
+type PostView struct {
+	Type        string           `json:"$type,omitempty"`
+	URI         string           `json:"uri"`
+	CID         string           `json:"cid"`
+	Author      ProfileViewBasic `json:"author"`
+	Record      PostRecord       `json:"record"`
+	Embed       *EmbedView       `json:"embed,omitempty"`
+	ReplyCount  int              `json:"replyCount"`
+	RepostCount int              `json:"repostCount"`
+	LikeCount   int              `json:"likeCount"`
+	QuoteCount  int              `json:"quoteCount"`
+	IndexedAt   time.Time        `json:"indexedAt"`
+	Viewer      *PostViewerState `json:"viewer,omitempty"`
+	Labels      []any            `json:"labels,omitempty"`
+	NotFound    bool             `json:"notFound,omitempty"`
+	Blocked     bool             `json:"blocked,omitempty"`
+}
+
+// FeedReason explains why a post is in a feed, e.g. it was reposted.
+type FeedReason struct {
+	Type      string           `json:"$type"`
+	By        ProfileViewBasic `json:"by"`
+	IndexedAt time.Time        `json:"indexedAt"`
+}
+
+const REASON_REPOST = "app.bsky.feed.defs#reasonRepost"
+
+// FeedReplyRef is the context of a post in a feed that is a reply.
+type FeedReplyRef struct {
+	Root              PostView          `json:"root"`
+	Parent            PostView          `json:"parent"`
+	GrandparentAuthor *ProfileViewBasic `json:"grandparentAuthor,omitempty"`
+}
+
+// FeedViewPost is a single item in a feed.
+type FeedViewPost struct {
+	Post        PostView      `json:"post"`
+	Reply       *FeedReplyRef `json:"reply,omitempty"`
+	Reason      *FeedReason   `json:"reason,omitempty"`
+	FeedContext string        `json:"feedContext,omitempty"`
+}
+
+// Filters of an author feed, which posts of the author are in it.
+const (
+	AUTHOR_FEED_POSTS   = "posts_and_author_threads"
+	AUTHOR_FEED_REPLIES = "posts_with_replies"
+	AUTHOR_FEED_MEDIA   = "posts_with_media"
+)
+
+// FeedMessage is a page of a feed, like the home timeline or an author feed.
+type FeedMessage struct {
+	LoadingError bool           `json:"-"` // Used to display error message
+	Error        error          `json:"-"` // Used to store error message
+	Cursor       string         `json:"cursor,omitempty"`
+	Feed         []FeedViewPost `json:"feed"`
+}
+
+const (
+	LIKE_COLLECTION   = "app.bsky.feed.like"
+	REPOST_COLLECTION = "app.bsky.feed.repost"
+)
+
+// SubjectRecord is an app.bsky.feed.like or app.bsky.feed.repost record, both point at the post they are about.
+type SubjectRecord struct {
+	Type      string    `json:"$type"`
+	Subject   StrongRef `json:"subject"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PENDING_RECORD stands in for the URI of a like or repost that is still being written.
+const PENDING_RECORD = "pending"
+
+// InteractionMsg is a change to whether the logged in account likes or reposts a post. It is sent as soon as
+// the change is made, again once it is saved, and to undo it if it could not be saved, so every view showing
+// the post stays up to date. Collection says whether it is a like or repost, Record is the URI of the like or
+// repost record, or empty if there is none, and Delta is how the count changes.
+type InteractionMsg struct {
+	URI        string
+	Collection string
+	Record     string
+	Delta      int
+}
+
+// Apply returns post with the change made, if it is the post the change is for.
+func (m InteractionMsg) Apply(post PostView) PostView {
+	if post.URI != m.URI {
+		return post
+	}
+	viewer := PostViewerState{}
+	if post.Viewer != nil {
+		viewer = *post.Viewer
+	}
+	switch m.Collection {
+	case LIKE_COLLECTION:
+		viewer.Like = m.Record
+		post.LikeCount = max(0, post.LikeCount+m.Delta)
+	case REPOST_COLLECTION:
+		viewer.Repost = m.Record
+		post.RepostCount = max(0, post.RepostCount+m.Delta)
+	}
+	post.Viewer = &viewer
+	return post
+}
+
+// PostedMsg is sent once a post has been published, so the views showing its thread can reload.
+type PostedMsg struct {
+	Ref    StrongRef
+	Record PostRecord
+}
+
+func SendFeedMsg(msg FeedMessage) tea.Cmd {
+	return func() tea.Msg {
+		return msg
+	}
+}
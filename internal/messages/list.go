@@ -0,0 +1,85 @@
+package messages
+
+import "time"
+
+const (
+	LIST_COLLECTION     = "app.bsky.graph.list"
+	LISTITEM_COLLECTION = "app.bsky.graph.listitem"
+)
+
+// The purposes a list can have.
+const (
+	LIST_PURPOSE_CURATE    = "app.bsky.graph.defs#curatelist"
+	LIST_PURPOSE_MOD       = "app.bsky.graph.defs#modlist"
+	LIST_PURPOSE_REFERENCE = "app.bsky.graph.defs#referencelist"
+)
+
+// ListViewerState is the relationship between the logged in account and a list.
+type ListViewerState struct {
+	Muted   bool   `json:"muted,omitempty"`
+	Blocked string `json:"blocked,omitempty"`
+}
+
+// ListView is a list of accounts, as it is described by app.bsky.graph.getLists and getList.
+type ListView struct {
+	URI               string           `json:"uri"`
+	CID               string           `json:"cid"`
+	Creator           ProfileView      `json:"creator"`
+	Name              string           `json:"name"`
+	Purpose           string           `json:"purpose"`
+	Description       string           `json:"description,omitempty"`
+	DescriptionFacets []Facet          `json:"descriptionFacets,omitempty"`
+	Avatar            string           `json:"avatar,omitempty"`
+	ListItemCount     int              `json:"listItemCount"`
+	Viewer            *ListViewerState `json:"viewer,omitempty"`
+	IndexedAt         time.Time        `json:"indexedAt"`
+}
+
+// ListItemView is a member of a list, URI is the listitem record that put them there.
+type ListItemView struct {
+	URI     string      `json:"uri"`
+	Subject ProfileView `json:"subject"`
+}
+
+// ListsMessage is a page of app.bsky.graph.getLists.
+type ListsMessage struct {
+	Cursor string     `json:"cursor,omitempty"`
+	Lists  []ListView `json:"lists"`
+}
+
+// ListMessage is a page of the members of a list, from app.bsky.graph.getList.
+type ListMessage struct {
+	Cursor string         `json:"cursor,omitempty"`
+	List   ListView       `json:"list"`
+	Items  []ListItemView `json:"items"`
+}
+
+// ListRecord is an app.bsky.graph.list record.
+type ListRecord struct {
+	Type        string    `json:"$type"`
+	Purpose     string    `json:"purpose"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// ListItemRecord is an app.bsky.graph.listitem record, it puts the account Subject on the list at List.
+type ListItemRecord struct {
+	Type      string    `json:"$type"`
+	Subject   string    `json:"subject"`
+	List      string    `json:"list"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ListChangedMsg is sent when a list is created, renamed or deleted. List is nil once it is deleted.
+type ListChangedMsg struct {
+	URI  string
+	List *ListView
+}
+
+// ListMemberMsg is sent when an account is added to a list, Delta 1, or taken off it, Delta -1.
+type ListMemberMsg struct {
+	List  string
+	Item  ListItemView
+	Delta int
+}
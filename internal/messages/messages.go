@@ -4,25 +4,44 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
+	"github.com/haukened/tsky/internal/config"
 )
 
-type NextMsg struct{}
+// Params are the parameters of a route, e.g. the actor of a profile.
+type Params map[string]string
 
-func Next() tea.Msg {
-	return NextMsg{}
+// NavigateMsg opens a view by route name. Replace swaps the current view instead of pushing onto the back stack,
+// and NewTab opens the view in a tab of its own.
+type NavigateMsg struct {
+	Route   string
+	Params  Params
+	Replace bool
+	NewTab  bool
 }
 
-type PrevMsg struct{}
+func Navigate(route string, params Params) tea.Cmd {
+	return func() tea.Msg {
+		return NavigateMsg{Route: route, Params: params}
+	}
+}
+
+func Replace(route string, params Params) tea.Cmd {
+	return func() tea.Msg {
+		return NavigateMsg{Route: route, Params: params, Replace: true}
+	}
+}
 
-func Prev() tea.Msg {
-	return PrevMsg{}
+func OpenTab(route string, params Params) tea.Cmd {
+	return func() tea.Msg {
+		return NavigateMsg{Route: route, Params: params, NewTab: true}
+	}
 }
 
-type StartAuthMsg struct{}
+// BackMsg closes the current view and returns to the one it was opened from.
+type BackMsg struct{}
 
-func StartAuth() tea.Msg {
-	return StartAuthMsg{}
+func Back() tea.Msg {
+	return BackMsg{}
 }
 
 type TickMsg time.Time
@@ -33,11 +52,41 @@ func Tick() tea.Cmd {
 	})
 }
 
-type HelpMsg string
+// RefreshMsg is sent every configured refresh interval so views can reload their data.
+type RefreshMsg struct {
+	Gen int
+}
+
+func Refresh(every time.Duration, gen int) tea.Cmd {
+	return tea.Tick(every, func(time.Time) tea.Msg {
+		return RefreshMsg{Gen: gen}
+	})
+}
+
+// ConfigChangedMsg carries a validated config that was edited while tsky is running.
+type ConfigChangedMsg struct {
+	Config *config.Config
+}
+
+// ConfigErrorMsg is sent when an edit to the config file could not be applied.
+type ConfigErrorMsg struct {
+	Err error
+}
 
-func SendHelpText(msg string) tea.Cmd {
+// WaitForConfig blocks until the next config change arrives on ch.
+func WaitForConfig(ch <-chan config.Change) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
 	return func() tea.Msg {
-		return HelpMsg(msg)
+		change, ok := <-ch
+		if !ok {
+			return nil
+		}
+		if change.Err != nil {
+			return ConfigErrorMsg{Err: change.Err}
+		}
+		return ConfigChangedMsg{Config: change.Config}
 	}
 }
 
@@ -55,8 +104,11 @@ type ProfileMessage struct {
 		Labeler      bool `json:"labeler"`
 	} `json:"associated"`
 	Viewer struct {
-		Muted          bool `json:"muted"`
-		BlockedBy      bool `json:"blockedBy"`
+		Muted          bool   `json:"muted"`
+		BlockedBy      bool   `json:"blockedBy"`
+		Blocking       string `json:"blocking,omitempty"`
+		Following      string `json:"following,omitempty"`
+		FollowedBy     string `json:"followedBy,omitempty"`
 		KnownFollowers struct {
 			Count     int `json:"count"`
 			Followers []struct {
@@ -90,28 +142,104 @@ type ProfileMessage struct {
 	PostsCount     int       `json:"postsCount"`
 }
 
+const FOLLOW_COLLECTION = "app.bsky.graph.follow"
+
+// FollowRecord is an app.bsky.graph.follow record, the subject is the DID of the account that is followed.
+type FollowRecord struct {
+	Type      string    `json:"$type"`
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// FollowMsg is a change to whether the logged in account follows another. Like an InteractionMsg it is sent
+// as soon as the change is made, again once it is saved, and to undo it if it could not be saved. Record is
+// the URI of the follow record, or empty if there is none, and Delta is how the follower count changes.
+type FollowMsg struct {
+	Did    string
+	Record string
+	Delta  int
+}
+
+// Apply returns profile with the change made, if it is the profile the change is for.
+func (m FollowMsg) Apply(profile ProfileMessage) ProfileMessage {
+	if profile.Did != m.Did {
+		return profile
+	}
+	profile.Viewer.Following = m.Record
+	profile.FollowersCount = max(0, profile.FollowersCount+m.Delta)
+	return profile
+}
+
 func SendProfileMsg(msg ProfileMessage) tea.Cmd {
 	return func() tea.Msg {
 		return msg
 	}
 }
 
-type StatusMsg string
+// SessionMessage describes the logged in account.
+type SessionMessage struct {
+	Did            string `json:"did"`
+	Handle         string `json:"handle"`
+	Email          string `json:"email,omitempty"`
+	EmailConfirmed bool   `json:"emailConfirmed,omitempty"`
+	Active         bool   `json:"active"`
+	Status         string `json:"status,omitempty"`
+}
+
+// Severity is how important a toast is, it decides how the toast is styled and how long it stays up.
+type Severity int
 
-func SendStatusMsg(msg string) tea.Cmd {
-	return func() tea.Msg {
-		return StatusMsg(msg)
+const (
+	SEVERITY_INFO Severity = iota
+	SEVERITY_SUCCESS
+	SEVERITY_WARNING
+	SEVERITY_ERROR
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SEVERITY_SUCCESS:
+		return "success"
+	case SEVERITY_WARNING:
+		return "warning"
+	case SEVERITY_ERROR:
+		return "error"
 	}
+	return "info"
 }
 
-func SendErrorMsg(msg string) tea.Cmd {
-	return func() tea.Msg {
-		return StatusMsg(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Render(msg))
-	}
+// ToastMsg shows a message in the status bar. Toasts queue up behind each other and expire on their own.
+// Text is plain, it is styled for its severity when it is shown.
+type ToastMsg struct {
+	Text     string
+	Severity Severity
 }
 
-func ClearStatusMsg() tea.Cmd {
+func Toast(severity Severity, text string) tea.Cmd {
 	return func() tea.Msg {
-		return StatusMsg("")
+		return ToastMsg{Text: text, Severity: severity}
 	}
 }
+
+func SendStatusMsg(msg string) tea.Cmd {
+	return Toast(SEVERITY_INFO, msg)
+}
+
+func SendSuccessMsg(msg string) tea.Cmd {
+	return Toast(SEVERITY_SUCCESS, msg)
+}
+
+func SendWarningMsg(msg string) tea.Cmd {
+	return Toast(SEVERITY_WARNING, msg)
+}
+
+func SendErrorMsg(msg string) tea.Cmd {
+	return Toast(SEVERITY_ERROR, msg)
+}
+
+// DismissMsg takes down the toast on screen before it expires.
+type DismissMsg struct{}
+
+func Dismiss() tea.Msg {
+	return DismissMsg{}
+}
@@ -0,0 +1,56 @@
+package messages
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Notification is a single app.bsky.notification.listNotifications item.
+type Notification struct {
+	URI           string           `json:"uri"`
+	CID           string           `json:"cid"`
+	Author        ProfileViewBasic `json:"author"`
+	Reason        string           `json:"reason"`
+	ReasonSubject string           `json:"reasonSubject,omitempty"`
+	Record        json.RawMessage  `json:"record"`
+	IsRead        bool             `json:"isRead"`
+	IndexedAt     time.Time        `json:"indexedAt"`
+	Labels        []any            `json:"labels,omitempty"`
+}
+
+// Text returns the text of the notification record if it is a post, e.g. a reply or mention.
+func (n Notification) Text() string {
+	record, _ := n.Post()
+	return record.Text
+}
+
+// Post returns the notification record if it is a post, e.g. a reply or mention.
+func (n Notification) Post() (PostRecord, bool) {
+	var record PostRecord
+	if err := json.Unmarshal(n.Record, &record); err != nil || record.Type != POST_COLLECTION {
+		return PostRecord{}, false
+	}
+	return record, true
+}
+
+// The reasons for a notification.
+const (
+	NOTIFY_LIKE              = "like"
+	NOTIFY_REPOST            = "repost"
+	NOTIFY_FOLLOW            = "follow"
+	NOTIFY_MENTION           = "mention"
+	NOTIFY_REPLY             = "reply"
+	NOTIFY_QUOTE             = "quote"
+	NOTIFY_STARTERPACK       = "starterpack-joined"
+	NOTIFY_LIKE_VIA_REPOST   = "like-via-repost"
+	NOTIFY_REPOST_VIA_REPOST = "repost-via-repost"
+)
+
+// NotificationsMessage is a page of notifications.
+type NotificationsMessage struct {
+	LoadingError  bool           `json:"-"` // Used to display error message
+	Error         error          `json:"-"` // Used to store error message
+	Cursor        string         `json:"cursor,omitempty"`
+	Notifications []Notification `json:"notifications"`
+	SeenAt        time.Time      `json:"seenAt,omitempty"`
+}
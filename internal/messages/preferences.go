@@ -0,0 +1,172 @@
+package messages
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SAVED_FEEDS_PREF is the $type of the preference that lists the saved and pinned feeds.
+const SAVED_FEEDS_PREF = "app.bsky.actor.defs#savedFeedsPrefV2"
+
+// SAVED_FEEDS_PREF_V1 is the $type of the older preference, which only lists the URIs of feeds and lists.
+// Accounts that have not been used with a newer app only have this one.
+const SAVED_FEEDS_PREF_V1 = "app.bsky.actor.defs#savedFeedsPref"
+
+// GENERATOR_COLLECTION is where custom feeds are published.
+const GENERATOR_COLLECTION = "app.bsky.feed.generator"
+
+// The kinds of saved feed.
+const (
+	SAVED_FEED_FEED     = "feed"
+	SAVED_FEED_LIST     = "list"
+	SAVED_FEED_TIMELINE = "timeline"
+)
+
+// SavedFeed is a feed the account has saved. Value is the at:// URI of the feed or list, or "following"
+// for the home timeline. Pinned feeds are the ones shown as tabs.
+type SavedFeed struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+	Pinned bool   `json:"pinned"`
+}
+
+// NewSavedFeed saves the feed or list at uri, with a new id.
+func NewSavedFeed(kind, uri string, pinned bool) SavedFeed {
+	return SavedFeed{ID: NewTID(), Type: kind, Value: uri, Pinned: pinned}
+}
+
+// SavedFeedsPref is the savedFeedsPrefV2 preference.
+type SavedFeedsPref struct {
+	Type  string      `json:"$type"`
+	Items []SavedFeed `json:"items"`
+}
+
+// SavedFeedsPrefV1 is the savedFeedsPref preference.
+type SavedFeedsPrefV1 struct {
+	Type   string   `json:"$type"`
+	Pinned []string `json:"pinned"`
+	Saved  []string `json:"saved"`
+}
+
+// Preferences are the account's preferences as they were loaded. Only the saved feeds are read, the rest
+// are kept as they are so they can be written back untouched.
+type Preferences []json.RawMessage
+
+// PreferencesMessage is the app.bsky.actor.getPreferences response.
+type PreferencesMessage struct {
+	Preferences Preferences `json:"preferences"`
+}
+
+// SavedFeeds returns the saved feeds, in order. If the account only has the older preference its feeds
+// are returned as the newer one would list them, after the home timeline.
+func (p Preferences) SavedFeeds() []SavedFeed {
+	var v1 *SavedFeedsPrefV1
+	for _, raw := range p {
+		switch prefType(raw) {
+		case SAVED_FEEDS_PREF:
+			var pref SavedFeedsPref
+			if err := json.Unmarshal(raw, &pref); err == nil {
+				return pref.Items
+			}
+		case SAVED_FEEDS_PREF_V1:
+			var pref SavedFeedsPrefV1
+			if err := json.Unmarshal(raw, &pref); err == nil {
+				v1 = &pref
+			}
+		}
+	}
+	if v1 == nil {
+		return nil
+	}
+	items := []SavedFeed{NewSavedFeed(SAVED_FEED_TIMELINE, "following", true)}
+	for _, uri := range v1.Saved {
+		kind := SAVED_FEED_FEED
+		if strings.Contains(uri, "/"+LIST_COLLECTION+"/") {
+			kind = SAVED_FEED_LIST
+		}
+		items = append(items, NewSavedFeed(kind, uri, slices.Contains(v1.Pinned, uri)))
+	}
+	return items
+}
+
+// WithSavedFeeds returns the preferences with the saved feeds replaced by items. The older preference is
+// kept in step if the account has it, for the apps that still read it.
+func (p Preferences) WithSavedFeeds(items []SavedFeed) (Preferences, error) {
+	raw, err := json.Marshal(SavedFeedsPref{Type: SAVED_FEEDS_PREF, Items: items})
+	if err != nil {
+		return nil, err
+	}
+	v1 := SavedFeedsPrefV1{Type: SAVED_FEEDS_PREF_V1, Pinned: []string{}, Saved: []string{}}
+	for _, item := range items {
+		if item.Type == SAVED_FEED_TIMELINE {
+			continue
+		}
+		v1.Saved = append(v1.Saved, item.Value)
+		if item.Pinned {
+			v1.Pinned = append(v1.Pinned, item.Value)
+		}
+	}
+	rawV1, err := json.Marshal(v1)
+	if err != nil {
+		return nil, err
+	}
+	p = slices.Clone(p)
+	replaced := false
+	for i, old := range p {
+		switch prefType(old) {
+		case SAVED_FEEDS_PREF:
+			p[i], replaced = raw, true
+		case SAVED_FEEDS_PREF_V1:
+			p[i] = rawV1
+		}
+	}
+	if !replaced {
+		p = append(p, raw)
+	}
+	return p, nil
+}
+
+// prefType returns the $type of a preference.
+func prefType(raw json.RawMessage) string {
+	var pref struct {
+		Type string `json:"$type"`
+	}
+	json.Unmarshal(raw, &pref)
+	return pref.Type
+}
+
+// SavedFeedsMsg is sent when the saved feeds are changed, so the pinned tabs can follow.
+// Save asks for them to be written to the preferences, it is false once they have been.
+type SavedFeedsMsg struct {
+	Items []SavedFeed
+	Save  bool
+}
+
+// Pinned returns the pinned feeds, in order.
+func (m SavedFeedsMsg) Pinned() []SavedFeed {
+	var pinned []SavedFeed
+	for _, item := range m.Items {
+		if item.Pinned {
+			pinned = append(pinned, item)
+		}
+	}
+	return pinned
+}
+
+// tidChars is the sortable base32 alphabet of record keys.
+const tidChars = "234567abcdefghijklmnopqrstuvwxyz"
+
+// NewTID returns a timestamp id, the kind of key records and saved feeds are given.
+func NewTID() string {
+	v := uint64(time.Now().UnixMicro())<<10 | uint64(rand.IntN(1024))
+	id := make([]byte, 13)
+	for i := len(id) - 1; i >= 0; i-- {
+		id[i] = tidChars[v&31]
+		v >>= 5
+	}
+	return string(id)
+}
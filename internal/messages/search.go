@@ -0,0 +1,68 @@
+package messages
+
+import "time"
+
+// The orders search results can be sorted in.
+const (
+	SEARCH_SORT_TOP    = "top"
+	SEARCH_SORT_LATEST = "latest"
+)
+
+// ProfileView is a profile as it is listed, in search results and the like. It has the description
+// of the account but not its counts.
+type ProfileView struct {
+	Did         string              `json:"did"`
+	Handle      string              `json:"handle"`
+	DisplayName string              `json:"displayName,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Avatar      string              `json:"avatar,omitempty"`
+	Viewer      *ProfileViewerState `json:"viewer,omitempty"`
+	Labels      []any               `json:"labels,omitempty"`
+	IndexedAt   time.Time           `json:"indexedAt"`
+	CreatedAt   time.Time           `json:"createdAt"`
+}
+
+// Basic returns the short form of the profile.
+func (p ProfileView) Basic() ProfileViewBasic {
+	return ProfileViewBasic{Did: p.Did, Handle: p.Handle, DisplayName: p.DisplayName, Avatar: p.Avatar,
+		Viewer: p.Viewer, Labels: p.Labels, CreatedAt: p.CreatedAt}
+}
+
+// ActorsMessage is a page of accounts, e.g. from app.bsky.actor.searchActors.
+type ActorsMessage struct {
+	Cursor string        `json:"cursor,omitempty"`
+	Actors []ProfileView `json:"actors"`
+}
+
+// SearchPostsMessage is a page of app.bsky.feed.searchPosts results.
+type SearchPostsMessage struct {
+	Cursor    string     `json:"cursor,omitempty"`
+	HitsTotal int        `json:"hitsTotal,omitempty"`
+	Posts     []PostView `json:"posts"`
+}
+
+// GeneratorViewerState is the relationship between the logged in account and a feed.
+type GeneratorViewerState struct {
+	Like string `json:"like,omitempty"`
+}
+
+// GeneratorView is a custom feed, a feed generator that someone has published.
+type GeneratorView struct {
+	URI               string                `json:"uri"`
+	CID               string                `json:"cid"`
+	Did               string                `json:"did"`
+	Creator           ProfileView           `json:"creator"`
+	DisplayName       string                `json:"displayName"`
+	Description       string                `json:"description,omitempty"`
+	DescriptionFacets []Facet               `json:"descriptionFacets,omitempty"`
+	Avatar            string                `json:"avatar,omitempty"`
+	LikeCount         int                   `json:"likeCount"`
+	Viewer            *GeneratorViewerState `json:"viewer,omitempty"`
+	IndexedAt         time.Time             `json:"indexedAt"`
+}
+
+// FeedGeneratorsMessage is a page of custom feeds.
+type FeedGeneratorsMessage struct {
+	Cursor string          `json:"cursor,omitempty"`
+	Feeds  []GeneratorView `json:"feeds"`
+}
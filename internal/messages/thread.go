@@ -0,0 +1,37 @@
+package messages
+
+// The kinds of post in a thread.
+const (
+	THREAD_VIEW_POST = "app.bsky.feed.defs#threadViewPost"
+	NOT_FOUND_POST   = "app.bsky.feed.defs#notFoundPost"
+	BLOCKED_POST     = "app.bsky.feed.defs#blockedPost"
+)
+
+// ThreadPost is a post in a thread along with its parent and replies, or a post that can not be shown.
+// The $type says which: a thread post has Post set, a not found or blocked post only has URI and the flag,
+// and a blocked post also has the DID of its author and how they are related to the logged in account.
+type ThreadPost struct {
+	Type     string            `json:"$type"`
+	Post     *PostView         `json:"post,omitempty"`
+	Parent   *ThreadPost       `json:"parent,omitempty"`
+	Replies  []ThreadPost      `json:"replies,omitempty"`
+	URI      string            `json:"uri,omitempty"`
+	NotFound bool              `json:"notFound,omitempty"`
+	Blocked  bool              `json:"blocked,omitempty"`
+	Author   *ProfileViewBasic `json:"author,omitempty"`
+}
+
+// Key returns the URI of the post, whether or not it can be shown.
+func (t ThreadPost) Key() string {
+	if t.Post != nil {
+		return t.Post.URI
+	}
+	return t.URI
+}
+
+// ThreadMessage is a post with as much of the thread around it as was asked for.
+type ThreadMessage struct {
+	LoadingError bool       `json:"-"` // Used to display error message
+	Error        error      `json:"-"` // Used to store error message
+	Thread       ThreadPost `json:"thread"`
+}
@@ -0,0 +1,161 @@
+package richtext
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/haukened/tsky/internal/logging"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/rivo/uniseg"
+)
+
+// MAX_GRAPHEMES is the longest post text the app view will accept.
+const MAX_GRAPHEMES = 300
+
+// MAX_TAG_GRAPHEMES is the longest hashtag, not counting the #.
+const MAX_TAG_GRAPHEMES = 64
+
+const (
+	ENTITY_MENTION = "mention"
+	ENTITY_LINK    = "link"
+	ENTITY_TAG     = "tag"
+)
+
+var logger = logging.For("richtext")
+
+// these follow the reference implementation in the bluesky social-app, minus the lookbehinds go does not support
+var (
+	mentionRe = regexp.MustCompile(`(?:^|[\s(])(@([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)`)
+	linkRe    = regexp.MustCompile(`(?:^|[\s(])(https?://[^\s]+)`)
+	tagRe     = regexp.MustCompile(`(?:^|\s)([#＃][^\s\x{00AD}\x{2060}\x{200A}\x{200B}\x{200C}\x{200D}\x{20e2}]+)`)
+)
+
+// Entity is a mention, link or tag found in text. Start and End are UTF-8 byte offsets, end exclusive.
+// Value is the handle without the @, the URL, or the tag without the #.
+type Entity struct {
+	Type  string
+	Start int
+	End   int
+	Value string
+}
+
+// Resolver turns a handle into a DID.
+type Resolver func(handle string) (string, error)
+
+// Length counts the graphemes in text, which is how post length is limited.
+func Length(text string) int {
+	return uniseg.GraphemeClusterCount(text)
+}
+
+// Detect finds every mention, link and tag in text, in the order they appear.
+func Detect(text string) []Entity {
+	var entities []Entity
+	for _, m := range mentionRe.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[2], m[3]
+		entities = append(entities, Entity{Type: ENTITY_MENTION, Start: start, End: end, Value: text[start+1 : end]})
+	}
+	for _, m := range linkRe.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[2], trimLink(text, m[2], m[3])
+		entities = append(entities, Entity{Type: ENTITY_LINK, Start: start, End: end, Value: text[start:end]})
+	}
+	for _, m := range tagRe.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[2], m[3]
+		end = start + len(strings.TrimRightFunc(text[start:end], unicode.IsPunct))
+		_, size := utf8.DecodeRuneInString(text[start:])
+		tag := text[start+size : end]
+		if tag == "" || isDigits(tag) || Length(tag) > MAX_TAG_GRAPHEMES {
+			continue
+		}
+		entities = append(entities, Entity{Type: ENTITY_TAG, Start: start, End: end, Value: tag})
+	}
+	sortEntities(entities)
+	return entities
+}
+
+// Facets detects the entities in text and turns them into post facets.
+// Mentions of handles that do not resolve are left as plain text, like the official app does.
+func Facets(text string, resolve Resolver) []messages.Facet {
+	var facets []messages.Facet
+	for _, e := range Detect(text) {
+		feature := messages.FacetFeature{}
+		switch e.Type {
+		case ENTITY_MENTION:
+			if resolve == nil {
+				continue
+			}
+			did, err := resolve(e.Value)
+			if err != nil {
+				logger.Debug("mention does not resolve", "handle", e.Value, "err", err)
+				continue
+			}
+			feature.Type = messages.FACET_MENTION
+			feature.Did = did
+		case ENTITY_LINK:
+			feature.Type = messages.FACET_LINK
+			feature.URI = e.Value
+		case ENTITY_TAG:
+			feature.Type = messages.FACET_TAG
+			feature.Tag = e.Value
+		}
+		facets = append(facets, messages.Facet{
+			Index:    messages.ByteSlice{ByteStart: e.Start, ByteEnd: e.End},
+			Features: []messages.FacetFeature{feature},
+		})
+	}
+	return facets
+}
+
+// CachedResolver wraps resolve so every handle is only looked up once.
+func CachedResolver(resolve Resolver) Resolver {
+	cache := map[string]string{}
+	return func(handle string) (string, error) {
+		handle = strings.ToLower(handle)
+		if did, ok := cache[handle]; ok {
+			return did, nil
+		}
+		did, err := resolve(handle)
+		if err != nil {
+			return "", err
+		}
+		cache[handle] = did
+		return did, nil
+	}
+}
+
+// trimLink drops trailing punctuation that is almost certainly not part of the URL,
+// and a closing paren unless the URL has a matching opening one.
+func trimLink(text string, start, end int) int {
+	for end > start {
+		r, size := utf8.DecodeLastRuneInString(text[start:end])
+		if strings.ContainsRune(".,;:!?\"'", r) {
+			end -= size
+			continue
+		}
+		if r == ')' && strings.Count(text[start:end], "(") < strings.Count(text[start:end], ")") {
+			end -= size
+			continue
+		}
+		break
+	}
+	return end
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortEntities(entities []Entity) {
+	// insertion sort, posts only ever have a handful of entities
+	for i := 1; i < len(entities); i++ {
+		for j := i; j > 0 && entities[j].Start < entities[j-1].Start; j-- {
+			entities[j], entities[j-1] = entities[j-1], entities[j]
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package richtext
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/haukened/tsky/internal/messages"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Entity
+	}{
+		{"mention after multi-byte text", "héllo @alice.test", []Entity{
+			{Type: ENTITY_MENTION, Start: 7, End: 18, Value: "alice.test"},
+		}},
+		{"tag after an emoji", "😀 #golang!", []Entity{
+			{Type: ENTITY_TAG, Start: 5, End: 12, Value: "golang"},
+		}},
+		{"link ending in punctuation", "see https://example.com/ü.", []Entity{
+			{Type: ENTITY_LINK, Start: 4, End: 26, Value: "https://example.com/ü"},
+		}},
+		{"link in parens", "(https://en.wikipedia.org/wiki/Go_(language))", []Entity{
+			{Type: ENTITY_LINK, Start: 1, End: 44, Value: "https://en.wikipedia.org/wiki/Go_(language)"},
+		}},
+		{"numeric tags are skipped", "#123 #日本語", []Entity{
+			{Type: ENTITY_TAG, Start: 5, End: 15, Value: "日本語"},
+		}},
+		{"full width hash", "＃タグ", []Entity{
+			{Type: ENTITY_TAG, Start: 0, End: 9, Value: "タグ"},
+		}},
+		{"email is not a mention", "email me@example.com", nil},
+		{"in order of appearance", "#go @bob.test https://go.dev", []Entity{
+			{Type: ENTITY_TAG, Start: 0, End: 3, Value: "go"},
+			{Type: ENTITY_MENTION, Start: 4, End: 13, Value: "bob.test"},
+			{Type: ENTITY_LINK, Start: 14, End: 28, Value: "https://go.dev"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFacets(t *testing.T) {
+	resolve := func(handle string) (string, error) {
+		if handle == "alice.test" {
+			return "did:plc:alice", nil
+		}
+		return "", errors.New("not found")
+	}
+	text := "😀 @alice.test @nobody.test https://x.com"
+	link := messages.Facet{
+		Index:    messages.ByteSlice{ByteStart: 30, ByteEnd: 43},
+		Features: []messages.FacetFeature{{Type: messages.FACET_LINK, URI: "https://x.com"}},
+	}
+	tests := []struct {
+		name    string
+		resolve Resolver
+		want    []messages.Facet
+	}{
+		{"unresolved mentions stay text", resolve, []messages.Facet{
+			{
+				Index:    messages.ByteSlice{ByteStart: 5, ByteEnd: 16},
+				Features: []messages.FacetFeature{{Type: messages.FACET_MENTION, Did: "did:plc:alice"}},
+			},
+			link,
+		}},
+		{"no resolver skips mentions", nil, []messages.Facet{link}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Facets(text, tt.resolve); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Facets(%q) = %+v, want %+v", text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package richtext
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+)
+
+// Split breaks text into posts of at most limit graphemes, so it can be published as a thread.
+// It prefers to break between paragraphs, then sentences, then words, and only cuts a word
+// in half when a single word is longer than a whole post.
+func Split(text string, limit int) []string {
+	text = strings.TrimSpace(text)
+	var posts []string
+	for Length(text) > limit {
+		cut := breakPoint(text, limit)
+		posts = append(posts, strings.TrimRightFunc(text[:cut], unicode.IsSpace))
+		text = strings.TrimLeftFunc(text[cut:], unicode.IsSpace)
+	}
+	if text != "" {
+		posts = append(posts, text)
+	}
+	return posts
+}
+
+// breakPoint returns the byte offset to cut text at, so the first part holds at most limit graphemes.
+func breakPoint(text string, limit int) int {
+	var (
+		count     int
+		hard      int // the end of the last grapheme that still fits
+		word      int
+		sentence  int
+		paragraph int
+	)
+	g := uniseg.NewGraphemes(text)
+	for g.Next() && count < limit {
+		count++
+		_, end := g.Positions()
+		hard = end
+		if strings.HasSuffix(text[:end], "\n\n") {
+			paragraph = end
+		}
+		if g.IsSentenceBoundary() {
+			sentence = end
+		}
+		if lb := g.LineBreak(); lb == uniseg.LineCanBreak || lb == uniseg.LineMustBreak {
+			word = end
+		}
+	}
+	// a break in the first half of the post would leave it mostly empty, so fall through to a smaller unit
+	half := hard / 2
+	for _, cut := range []int{paragraph, sentence, word} {
+		if cut > half {
+			return cut
+		}
+	}
+	if word > 0 {
+		return word
+	}
+	return hard
+}
@@ -0,0 +1,30 @@
+package richtext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"fits", "hello", 10, []string{"hello"}},
+		{"empty", "  ", 10, nil},
+		{"between paragraphs", "First para.\n\nSecond one here.", 20, []string{"First para.", "Second one here."}},
+		{"between sentences", "One two three. Four five six seven.", 20, []string{"One two three.", "Four five six seven."}},
+		{"between words", "aaaa bbbb cccc dddd", 12, []string{"aaaa bbbb", "cccc dddd"}},
+		{"long word is cut", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"counts graphemes, not bytes", "😀😀😀😀😀", 2, []string{"😀😀", "😀😀", "😀"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Split(tt.text, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
@@ -6,77 +6,133 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/haukened/tsky/internal/config"
+	"github.com/haukened/tsky/internal/logging"
 	"github.com/haukened/tsky/internal/utils"
 )
 
 const REFRESH_URI_BASE = "https://%s/xrpc/com.atproto.server.refreshSession"
 
+var logger = logging.For("tokensvc")
+
 var (
 	ErrHttpError            = errors.New("http error in underlying refresher client")
 	ErrUnableToRefreshToken = errors.New("unable to refresh token")
 )
 
+// Refresher keeps the access token of the session stored in the config valid.
+// The config is the source of truth for the refresh token, so a login or logout
+// elsewhere in tsky is picked up on the next refresh.
+// mu only guards the tokens, refreshing is held across a whole refresh so only one runs at a time,
+// as a refresh token can only be used once.
 type Refresher struct {
-	authToken    string
-	refreshToken string
-	server       string
+	mu         sync.Mutex
+	refreshing sync.Mutex
+	conf       *config.Config
+	authToken  string
+	handle     string
 }
 
 type RefreshOutput struct {
 	AccessJwt  string `json:"accessJwt"`
 	RefreshJwt string `json:"refreshJwt"`
+	Did        string `json:"did"`
+	Handle     string `json:"handle"`
 }
 
-func NewRefresher(c *config.Config) (*Refresher, error) {
-	r := &Refresher{
-		refreshToken: c.RefreshJwt,
-		server:       c.Server,
+// New creates a refresher without touching the network, the session is refreshed on first use.
+func New(c *config.Config) *Refresher {
+	return &Refresher{
+		conf: c,
 	}
+}
+
+// NewRefresher creates a refresher and refreshes the session immediately, so an invalid session is reported up front.
+func NewRefresher(c *config.Config) (*Refresher, error) {
+	r := New(c)
 	// refresh now
 	err := r.Refresh()
 	if err != nil {
 		return nil, err
 	}
 	// get the expiration time of the token
-	exp := utils.GetTokenExpiration(r.authToken)
+	exp := utils.GetTokenExpiration(r.AuthToken())
 	// refresh 5 minutes before expiration
 	early := exp.Add(-5 * time.Minute)
 	// set a timer to refresh at that time
+	logger.Debug("scheduled token refresh", "at", early)
 	time.AfterFunc(time.Until(early), func() {
-		r.Refresh()
+		if err := r.Refresh(); err != nil {
+			logger.Error("scheduled token refresh failed", "err", err)
+		}
 	})
 	// return the refresher
 	return r, nil
 }
 
 func (r *Refresher) AuthToken() string {
-	if utils.IsJwtExpired(r.authToken) {
-		r.Refresh()
+	if r.expired() {
+		r.refreshing.Lock()
+		// another caller may have refreshed it while this one waited
+		if r.expired() {
+			logger.Debug("access token expired, refreshing")
+			if err := r.refresh(); err != nil {
+				logger.Error("token refresh failed", "err", err)
+			}
+		}
+		r.refreshing.Unlock()
 	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	return r.authToken
 }
 
+func (r *Refresher) expired() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return utils.IsJwtExpired(r.authToken)
+}
+
 func (r *Refresher) Refresh() error {
-	URL := fmt.Sprintf(REFRESH_URI_BASE, r.server)
+	r.refreshing.Lock()
+	defer r.refreshing.Unlock()
+	return r.refresh()
+}
+
+// refresh trades the stored refresh token for new tokens, the caller holds refreshing.
+func (r *Refresher) refresh() error {
+	refreshJwt, did := r.conf.Session()
+	URL := fmt.Sprintf(REFRESH_URI_BASE, r.conf.Server)
 	req, err := http.NewRequest("POST", URL, nil)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.refreshToken))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", refreshJwt))
 	req.Header.Set("User-Agent", utils.UserAgent())
 	req.Header.Set("Content-Type", "application/json")
-	client := http.Client{}
+	client := http.Client{Timeout: utils.HTTP_TIMEOUT}
 	resp, err := client.Do(req)
 	if err != nil {
+		logger.Warn("refresh request failed", "err", err)
 		return ErrHttpError
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
+		// the server will not take this refresh token again, so drop it rather than retry it on every start
+		logger.Warn("refresh request rejected, clearing session", "status", resp.Status)
+		r.mu.Lock()
+		r.authToken = ""
+		r.handle = ""
+		r.mu.Unlock()
+		r.conf.SetSession("", "", "")
+		if err := r.conf.Save(); err != nil {
+			logger.Error("unable to save cleared session", "err", err)
+		}
 		return ErrUnableToRefreshToken
 	}
-	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
@@ -86,11 +142,51 @@ func (r *Refresher) Refresh() error {
 	if err != nil {
 		return err
 	}
+	r.mu.Lock()
 	r.authToken = output.AccessJwt
-	r.refreshToken = output.RefreshJwt
+	r.handle = output.Handle
+	r.mu.Unlock()
+	logger.Info("refreshed session tokens", "server", r.conf.Server)
+	// refresh tokens are single use, so the new one has to be saved for the next run
+	if output.Did != "" {
+		did = output.Did
+	}
+	r.conf.SetSession(output.AccessJwt, output.RefreshJwt, did)
+	if err := r.conf.Save(); err != nil {
+		logger.Error("unable to save refreshed session", "err", err)
+	}
 	return nil
 }
 
+// Reset forgets the access token, so the next request refreshes the session stored in the config.
+// It is used after logging out, so nothing from the old session is sent for a new one.
+func (r *Refresher) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authToken = ""
+	r.handle = ""
+}
+
 func (r *Refresher) RefreshToken() string {
-	return r.refreshToken
+	refresh, _ := r.conf.Session()
+	return refresh
+}
+
+// Server returns the host the session belongs to.
+func (r *Refresher) Server() string {
+	return r.conf.Server
+}
+
+// Did returns the DID of the logged in account, as it is stored with the session.
+func (r *Refresher) Did() string {
+	_, did := r.conf.Session()
+	return did
+}
+
+// Handle returns the handle of the logged in account, as of the last refresh.
+// Like Did it never refreshes, so it is safe to call while drawing.
+func (r *Refresher) Handle() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.handle
 }
@@ -0,0 +1,591 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/loader"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+const (
+	// FEED_PAGE_SIZE is how many posts are fetched at a time.
+	FEED_PAGE_SIZE = 30
+	// FEED_LOAD_AHEAD is how close to the end of the loaded posts the list gets before it fetches the next page.
+	FEED_LOAD_AHEAD = 5
+	// WHEEL_LINES is how far one turn of the mouse wheel scrolls.
+	WHEEL_LINES = 3
+)
+
+// FeedPage fetches the page of a feed that starts at cursor, or the newest page if cursor is empty.
+type FeedPage func(cursor string) (messages.FeedMessage, error)
+
+// rendered is a post as it is drawn, with the links in it for hit-testing.
+type rendered struct {
+	lines []string
+	spans []Span
+}
+
+// renderCache holds posts already rendered at a width and theme. It is shared by every copy of a list,
+// so a post is only rendered once however many times the list is copied.
+// It keeps one rendering of each item, the one for its latest version.
+type renderCache struct {
+	width int
+	theme string
+	posts map[string]cachedPost
+}
+
+// cachedPost is an item as it was last rendered, version is the renderKey it was rendered from.
+type cachedPost struct {
+	version string
+	rendered
+}
+
+func (c *renderCache) get(item messages.FeedViewPost, width int) rendered {
+	if c.posts == nil || c.width != width || c.theme != styles.Current() {
+		c.width, c.theme = width, styles.Current()
+		c.posts = map[string]cachedPost{}
+	}
+	key, version := itemKey(item), renderKey(item)
+	p, ok := c.posts[key]
+	if !ok || p.version != version {
+		text := renderPost(item, width)
+		p = cachedPost{version: version, rendered: rendered{lines: strings.Split(text, "\n"), spans: findSpans(text)}}
+		// a blank line between posts
+		p.lines = append(p.lines, "")
+		c.posts[key] = p
+	}
+	return p.rendered
+}
+
+// keep drops the posts that are not among items, once a list no longer shows them.
+func (c *renderCache) keep(items []messages.FeedViewPost) {
+	shown := make(map[string]bool, len(items))
+	for _, item := range items {
+		shown[itemKey(item)] = true
+	}
+	for key := range c.posts {
+		if !shown[key] {
+			delete(c.posts, key)
+		}
+	}
+}
+
+// feedRow is what is on one row of the list, a line of a post, or the header or footer when item is -1.
+type feedRow struct {
+	item int
+	line int
+}
+
+// FeedList is a scrolling list of posts. It loads older pages by cursor as it nears the end, and newer posts
+// when it is refreshed, which wait above the posts on screen behind a banner. Only the posts on screen are rendered.
+type FeedList struct {
+	name   string
+	client *client.Client
+	fetch  FeedPage
+	latest loader.Resource[messages.FeedMessage]
+	merged time.Time
+	older  loader.Resource[messages.FeedMessage]
+	more   bool
+	items  []messages.FeedViewPost
+	cursor string
+	// the focused post, and the scroll position as the first post on screen and how many of its lines are above the top
+	focus int
+	top   int
+	skip  int
+	// how many of the posts at the top have arrived since the list was last scrolled to the top
+	unseen int
+	// the post and link under the mouse
+	over  int
+	hover int
+	query string
+	cache *renderCache
+	w     int
+	h     int
+}
+
+// NewFeedList shows the feed fetch pages through, name is what it is called while loading.
+// Posts are liked and reposted with c.
+func NewFeedList(name string, c *client.Client, fetch FeedPage) FeedList {
+	l := FeedList{
+		name:   name,
+		client: c,
+		fetch:  fetch,
+		over:   -1,
+		hover:  -1,
+		cache:  &renderCache{},
+	}
+	l.latest = loader.New(name, func() (messages.FeedMessage, error) {
+		return fetch("")
+	})
+	return l
+}
+
+func (l FeedList) Init() tea.Cmd {
+	return l.latest.Init()
+}
+
+// Focused returns the post that is focused, if there are any posts.
+func (l FeedList) Focused() (messages.FeedViewPost, bool) {
+	if l.focus >= len(l.items) {
+		return messages.FeedViewPost{}, false
+	}
+	return l.items[l.focus], true
+}
+
+// Resize sets the size of the list, keeping the focused post on screen.
+func (l FeedList) Resize(w, h int) FeedList {
+	l.w, l.h = w, h
+	if l.top < len(l.items) {
+		l.skip = min(l.skip, len(l.post(l.top).lines)-1)
+	}
+	return l.show(l.focus)
+}
+
+// Update scrolls and moves the focus for scrolling actions and the mouse, and refreshes for refresh messages.
+func (l FeedList) Update(msg tea.Msg) (FeedList, tea.Cmd) {
+	var cmd, more tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		return l.Resize(msg.Width, msg.Height), nil
+	case messages.RefreshMsg:
+		return l.refresh()
+	case keymap.ActionMsg:
+		l, cmd = l.action(msg)
+	case SearchMsg:
+		l.query = strings.ToLower(msg.Query)
+		l, cmd = l.step(0)
+	case tea.MouseMsg:
+		l, cmd = l.mouse(msg)
+	case messages.InteractionMsg:
+		items := make([]messages.FeedViewPost, len(l.items))
+		for i, item := range l.items {
+			item.Post = msg.Apply(item.Post)
+			items[i] = item
+		}
+		l.items = items
+	default:
+		l.latest, cmd = l.latest.Update(msg)
+		if l.latest.HasData() && l.latest.LoadedAt() != l.merged {
+			l.merged = l.latest.LoadedAt()
+			l = l.mergeLatest()
+		}
+		var older tea.Cmd
+		l.older, older = l.older.Update(msg)
+		if l.more && l.older.HasData() {
+			l.more = false
+			l = l.mergeOlder()
+		}
+		cmd = tea.Batch(cmd, older)
+	}
+	// the posts scrolled past are no longer new
+	l.unseen = min(l.unseen, l.top)
+	l, more = l.loadOlder()
+	return l, tea.Batch(cmd, more)
+}
+
+func (l FeedList) action(msg keymap.ActionMsg) (FeedList, tea.Cmd) {
+	n := msg.Times()
+	switch msg.Action {
+	case keymap.RELOAD:
+		if l.older.State() == loader.Failed {
+			var cmd tea.Cmd
+			l.older, cmd = l.older.Reload()
+			return l, cmd
+		}
+		return l.refresh()
+	case keymap.UP:
+		if l.atTop() {
+			// pulling down past the top refreshes
+			return l.refresh()
+		}
+		return l.show(l.focus - n), nil
+	case keymap.DOWN:
+		return l.show(l.focus + n), nil
+	case keymap.PAGE_UP:
+		return l.scroll(-n * l.bodyHeight()), nil
+	case keymap.PAGE_DOWN:
+		return l.scroll(n * l.bodyHeight()), nil
+	case keymap.HALF_PAGE_UP:
+		return l.scroll(-n * l.bodyHeight() / 2), nil
+	case keymap.HALF_PAGE_DOWN:
+		return l.scroll(n * l.bodyHeight() / 2), nil
+	case keymap.TOP:
+		l.top, l.skip = 0, 0
+		return l.show(0), nil
+	case keymap.BOTTOM:
+		if msg.Count > 0 {
+			// like going to a line, a count goes to that post
+			return l.show(msg.Count - 1), nil
+		}
+		return l.show(len(l.items) - 1), nil
+	case keymap.NEXT_MATCH:
+		return l.step(n)
+	case keymap.PREV_MATCH:
+		return l.step(-n)
+	case keymap.SELECT:
+		if item, ok := l.Focused(); ok {
+			return l, messages.Navigate(ROUTE_THREAD, messages.Params{"uri": item.Post.URI})
+		}
+	case keymap.REPLY:
+		if item, ok := l.Focused(); ok {
+			return l, messages.Navigate(ROUTE_COMPOSE, messages.Params{"reply": item.Post.URI})
+		}
+	case keymap.QUOTE:
+		if item, ok := l.Focused(); ok {
+			return l, messages.Navigate(ROUTE_COMPOSE, messages.Params{"quote": item.Post.URI})
+		}
+	case keymap.LIKE:
+		if item, ok := l.Focused(); ok {
+			return l, toggleLike(l.client, item.Post)
+		}
+	case keymap.REPOST:
+		if item, ok := l.Focused(); ok {
+			return l, toggleRepost(l.client, item.Post)
+		}
+	}
+	return l, nil
+}
+
+// mouse scrolls for the wheel, highlights what is under the pointer, and follows or focuses what is clicked.
+func (l FeedList) mouse(msg tea.MouseMsg) (FeedList, tea.Cmd) {
+	if msg.Action == tea.MouseActionPress {
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			if l.atTop() {
+				return l.refresh()
+			}
+			return l.scroll(-WHEEL_LINES), nil
+		case tea.MouseButtonWheelDown:
+			return l.scroll(WHEEL_LINES), nil
+		}
+	}
+	l.over, l.hover = -1, -1
+	rows := l.rows()
+	if msg.X < 0 || msg.Y < 0 || msg.X >= l.w || msg.Y >= len(rows) {
+		return l, nil
+	}
+	row := rows[msg.Y]
+	if row.item >= 0 {
+		l.over = row.item
+		l.hover = spanAt(l.post(row.item).spans, row.line, msg.X-ITEM_GUTTER)
+	}
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return l, nil
+	}
+	switch {
+	case row.item < 0 && msg.Y == 0 && l.unseen > 0:
+		// the new posts banner
+		return l.action(keymap.ActionMsg{Action: keymap.TOP})
+	case l.hover >= 0:
+		return l, l.post(row.item).spans[l.hover].Open()
+	case row.item >= 0:
+		l.focus = row.item
+	}
+	return l, nil
+}
+
+// refresh fetches the newest posts, unless they are already being fetched.
+func (l FeedList) refresh() (FeedList, tea.Cmd) {
+	var cmd tea.Cmd
+	l.latest, cmd = l.latest.Reload()
+	return l, cmd
+}
+
+// loadOlder fetches the next page once the end of the loaded posts is close to the screen.
+func (l FeedList) loadOlder() (FeedList, tea.Cmd) {
+	if l.cursor == "" || l.older.IsLoading() || l.older.State() == loader.Failed || len(l.items) == 0 {
+		return l, nil
+	}
+	rows := l.rows()
+	last := l.top
+	for _, row := range rows {
+		last = max(last, row.item)
+	}
+	if last < len(l.items)-FEED_LOAD_AHEAD {
+		return l, nil
+	}
+	cursor, fetch := l.cursor, l.fetch
+	l.older = loader.New("older "+l.name, func() (messages.FeedMessage, error) {
+		return fetch(cursor)
+	})
+	l.more = true
+	return l, l.older.Init()
+}
+
+// mergeLatest puts the newest posts above those already loaded. The posts on screen stay where they are,
+// and the new ones wait above them.
+func (l FeedList) mergeLatest() FeedList {
+	page := l.latest.Data()
+	if len(l.items) == 0 {
+		l.items, l.cursor = page.Feed, page.Cursor
+		l.cache.keep(l.items)
+		return l
+	}
+	first := itemKey(l.items[0])
+	n := -1
+	for i, item := range page.Feed {
+		if itemKey(item) == first {
+			n = i
+			break
+		}
+	}
+	if n < 0 {
+		// nothing in common, the gap is too big to fill so start again from the newest posts
+		l.items, l.cursor = page.Feed, page.Cursor
+		l.focus, l.top, l.skip, l.unseen = 0, 0, 0, 0
+		l.cache.keep(l.items)
+		return l
+	}
+	// the posts that were already loaded may have new counts
+	fresh := make(map[string]messages.FeedViewPost, len(page.Feed))
+	for _, item := range page.Feed[n:] {
+		fresh[itemKey(item)] = item
+	}
+	items := append([]messages.FeedViewPost{}, page.Feed[:n]...)
+	for _, item := range l.items {
+		if f, ok := fresh[itemKey(item)]; ok {
+			item = f
+		}
+		items = append(items, item)
+	}
+	l.items = items
+	l.focus += n
+	l.top += n
+	l.unseen += n
+	return l
+}
+
+// mergeOlder adds the next page to the end, skipping posts that are already loaded.
+func (l FeedList) mergeOlder() FeedList {
+	page := l.older.Data()
+	loaded := make(map[string]bool, len(l.items))
+	for _, item := range l.items {
+		loaded[itemKey(item)] = true
+	}
+	items := append([]messages.FeedViewPost{}, l.items...)
+	for _, item := range page.Feed {
+		if !loaded[itemKey(item)] {
+			items = append(items, item)
+		}
+	}
+	l.items = items
+	l.cursor = page.Cursor
+	if len(page.Feed) == 0 {
+		// some feeds keep handing out a cursor after the last page
+		l.cursor = ""
+	}
+	return l
+}
+
+// step moves the focus n posts on from the current one to the next that matches the search, wrapping around.
+func (l FeedList) step(n int) (FeedList, tea.Cmd) {
+	if l.query == "" {
+		return l, nil
+	}
+	var matches []int
+	for i, item := range l.items {
+		post := item.Post
+		text := strings.ToLower(post.Record.Text + " " + post.Author.Handle + " " + post.Author.DisplayName)
+		if strings.Contains(text, l.query) {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return l, messages.SendWarningMsg(fmt.Sprintf("Pattern not found: %s", l.query))
+	}
+	// start from the first match at or after the focused post
+	match := len(matches)
+	for i, m := range matches {
+		if m >= l.focus {
+			match = i
+			break
+		}
+	}
+	if n > 0 && matches[match] == l.focus {
+		match += n
+	} else if n > 0 {
+		match += n - 1
+	} else {
+		match += n
+	}
+	match = ((match % len(matches)) + len(matches)) % len(matches)
+	return l.show(matches[match]), messages.SendStatusMsg(fmt.Sprintf("/%s %d of %d", l.query, match+1, len(matches)))
+}
+
+// show focuses post i and scrolls as little as possible to bring it on screen.
+func (l FeedList) show(i int) FeedList {
+	if len(l.items) == 0 {
+		return l
+	}
+	focus := min(max(i, 0), len(l.items)-1)
+	l.focus = focus
+	if l.focus < l.top || (l.focus == l.top && l.skip > 0) {
+		l.top, l.skip = l.focus, 0
+		return l
+	}
+	// lines from the top of the screen to the end of the focused post
+	lines := len(l.post(l.top).lines) - l.skip
+	for i := l.top + 1; i <= l.focus; i++ {
+		lines += len(l.post(i).lines)
+	}
+	if lines > l.bodyHeight() {
+		if len(l.post(l.focus).lines) >= l.bodyHeight() {
+			l.top, l.skip = l.focus, 0
+			return l
+		}
+		l = l.scroll(lines - l.bodyHeight())
+		l.focus = focus
+	}
+	return l
+}
+
+// scroll moves the list n lines, down if n is positive, and keeps the focus on a post that is on screen.
+func (l FeedList) scroll(n int) FeedList {
+	if len(l.items) == 0 {
+		return l
+	}
+	for ; n > 0 && l.linesBelow() > l.bodyHeight(); n-- {
+		l.skip++
+		if l.skip >= len(l.post(l.top).lines) && l.top < len(l.items)-1 {
+			l.top, l.skip = l.top+1, 0
+		}
+	}
+	for ; n < 0 && (l.top > 0 || l.skip > 0); n++ {
+		l.skip--
+		if l.skip < 0 {
+			l.top--
+			l.skip = len(l.post(l.top).lines) - 1
+		}
+	}
+	// the focus stays put while it is on screen, otherwise it goes to the first post that starts on screen
+	first := l.top
+	if l.skip > 0 && l.top < len(l.items)-1 {
+		first++
+	}
+	last := l.top
+	for _, row := range l.rows() {
+		last = max(last, row.item)
+	}
+	if l.focus < first || l.focus > last {
+		l.focus = first
+	}
+	return l
+}
+
+// linesBelow counts the lines from the top of the screen to the end of the list, stopping once they overflow it.
+func (l FeedList) linesBelow() int {
+	lines := len(l.post(l.top).lines) - l.skip + 1
+	for i := l.top + 1; i < len(l.items) && lines <= l.bodyHeight(); i++ {
+		lines += len(l.post(i).lines)
+	}
+	return lines
+}
+
+// atTop reports whether the list is scrolled all the way up with the first post focused.
+func (l FeedList) atTop() bool {
+	return l.top == 0 && l.skip == 0 && l.focus == 0
+}
+
+// post is post i as it is drawn.
+func (l FeedList) post(i int) rendered {
+	return l.cache.get(l.items[i], max(1, l.w-ITEM_GUTTER))
+}
+
+// header is the line above the posts, the new posts banner or how a refresh is going.
+func (l FeedList) header() string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	switch {
+	case l.unseen > 0:
+		return styles.Focus.Bold(true).Render(fmt.Sprintf("↑ %d new posts", l.unseen))
+	case l.latest.IsLoading():
+		return muted.Render(fmt.Sprintf("%s refreshing…", loader.Spinner()))
+	case l.latest.Err() != nil:
+		return lipgloss.NewStyle().Foreground(styles.Error).Render(fmt.Sprintf("Refresh failed: %s", l.latest.Err())) +
+			muted.Render(" (press r to retry)")
+	}
+	return ""
+}
+
+// footer is the line after the last post, how loading the next page is going.
+func (l FeedList) footer() string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	switch {
+	case l.older.IsLoading():
+		return muted.Render(fmt.Sprintf("%s Loading older posts…", loader.Spinner()))
+	case l.older.State() == loader.Failed:
+		return lipgloss.NewStyle().Foreground(styles.Error).Render(fmt.Sprintf("Unable to load older posts: %s", l.older.Err())) +
+			muted.Render(" (press r to retry)")
+	case l.cursor == "":
+		return muted.Render("That's everything")
+	}
+	return ""
+}
+
+// bodyHeight is the rows left for posts under the header.
+func (l FeedList) bodyHeight() int {
+	if l.header() != "" {
+		return max(1, l.h-1)
+	}
+	return max(1, l.h)
+}
+
+// rows lays out the screen, it is what View draws and what the mouse is hit-tested against.
+func (l FeedList) rows() []feedRow {
+	var rows []feedRow
+	if l.header() != "" {
+		rows = append(rows, feedRow{item: -1})
+	}
+	skip := l.skip
+	for i := l.top; i < len(l.items) && len(rows) < l.h; i++ {
+		for line := skip; line < len(l.post(i).lines) && len(rows) < l.h; line++ {
+			rows = append(rows, feedRow{item: i, line: line})
+		}
+		skip = 0
+	}
+	if len(rows) < l.h && l.top < len(l.items) {
+		rows = append(rows, feedRow{item: -1, line: 1})
+	}
+	return rows
+}
+
+func (l FeedList) View() string {
+	if !l.latest.HasData() {
+		// the loader shows the spinner, or why the first page could not be loaded
+		return l.latest.View(func(messages.FeedMessage) string { return "" })
+	}
+	if len(l.items) == 0 {
+		return lipgloss.NewStyle().Foreground(styles.Muted).Render("Nothing here yet")
+	}
+	lines := make([]string, 0, l.h)
+	for _, row := range l.rows() {
+		if row.item < 0 {
+			if row.line == 0 {
+				lines = append(lines, l.header())
+			} else {
+				lines = append(lines, l.footer())
+			}
+			continue
+		}
+		post := l.post(row.item)
+		line := post.lines[row.line]
+		if row.item == l.over && l.hover >= 0 && post.spans[l.hover].Line == row.line {
+			line = highlight(line, post.spans[l.hover], styles.Hover)
+		}
+		gutter := strings.Repeat(" ", ITEM_GUTTER)
+		switch row.item {
+		case l.focus:
+			gutter = styles.Focus.Render("▌") + " "
+		case l.over:
+			gutter = styles.HoverItem.Render("▌") + " "
+		}
+		lines = append(lines, gutter+line)
+	}
+	return strings.Join(lines, "\n")
+}
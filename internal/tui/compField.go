@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Field is a line in a view that something it asks for is typed into, like the name of a new list.
+// What says what it was opened for, so the view knows what to do with the text.
+type Field struct {
+	input textinput.Model
+	what  string
+	width int
+}
+
+func NewField() Field {
+	return Field{input: textinput.New()}
+}
+
+// Open asks for what, with value already typed in.
+func (f Field) Open(prompt, value, what string) (Field, tea.Cmd) {
+	f.what = what
+	f.input.Prompt = prompt
+	f.input.SetValue(value)
+	f.input.CursorEnd()
+	f = f.Resize(f.width)
+	cmd := f.input.Focus()
+	return f, cmd
+}
+
+// Active reports whether something is being typed.
+func (f Field) Active() bool {
+	return f.input.Focused()
+}
+
+// What returns what the field was opened for.
+func (f Field) What() string {
+	return f.what
+}
+
+// Update edits the line. Once enter is pressed it closes and returns what was typed and true, esc
+// closes it without.
+func (f Field) Update(msg tea.KeyMsg) (Field, string, bool, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.Type {
+	case tea.KeyEsc:
+		f.input.Blur()
+		return f, "", false, nil
+	case tea.KeyEnter:
+		f.input.Blur()
+		value := strings.TrimSpace(f.input.Value())
+		return f, value, value != "", nil
+	}
+	f.input, cmd = f.input.Update(msg)
+	return f, "", false, cmd
+}
+
+// Resize sets how wide the line is.
+func (f Field) Resize(w int) Field {
+	f.width = w
+	f.input.Width = max(1, w-lipgloss.Width(f.input.Prompt)-1)
+	return f
+}
+
+// View is the line while something is typed, and nothing otherwise.
+func (f Field) View() string {
+	if !f.input.Focused() {
+		return ""
+	}
+	return f.input.View()
+}
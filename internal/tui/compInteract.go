@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/messages"
+)
+
+// toggleLike likes post, or takes the like back if it is already liked.
+func toggleLike(c *client.Client, post messages.PostView) tea.Cmd {
+	current := ""
+	if post.Viewer != nil {
+		current = post.Viewer.Like
+	}
+	return toggle(c, post, messages.LIKE_COLLECTION, current, c.Like, "like")
+}
+
+// toggleRepost reposts post, or undoes the repost if it is already reposted.
+func toggleRepost(c *client.Client, post messages.PostView) tea.Cmd {
+	current := ""
+	if post.Viewer != nil {
+		current = post.Viewer.Repost
+	}
+	return toggle(c, post, messages.REPOST_COLLECTION, current, c.Repost, "repost")
+}
+
+// toggle creates a like or repost record for post, or deletes current if there already is one. Every view
+// is told about the change straight away, then again once it is saved, or to undo it if it could not be.
+func toggle(c *client.Client, post messages.PostView, collection, current string,
+	create func(messages.StrongRef) (messages.StrongRef, error), noun string) tea.Cmd {
+	if current == messages.PENDING_RECORD {
+		return messages.SendStatusMsg(fmt.Sprintf("Still saving the %s…", noun))
+	}
+	uri := post.URI
+	changed := func(record string, delta int) tea.Cmd {
+		return func() tea.Msg {
+			return messages.InteractionMsg{URI: uri, Collection: collection, Record: record, Delta: delta}
+		}
+	}
+	if current == "" {
+		subject := messages.StrongRef{URI: post.URI, CID: post.CID}
+		return tea.Sequence(changed(messages.PENDING_RECORD, 1), func() tea.Msg {
+			ref, err := create(subject)
+			if err != nil {
+				logger.Warn("unable to save "+noun, "post", uri, "err", err)
+				return tea.BatchMsg{changed("", -1), messages.SendErrorMsg(fmt.Sprintf("Unable to %s the post: %s", noun, err))}
+			}
+			return messages.InteractionMsg{URI: uri, Collection: collection, Record: ref.URI}
+		})
+	}
+	return tea.Sequence(changed("", -1), func() tea.Msg {
+		if err := c.DeleteRecord(current); err != nil {
+			logger.Warn("unable to undo "+noun, "post", uri, "err", err)
+			return tea.BatchMsg{changed(current, 1), messages.SendErrorMsg(fmt.Sprintf("Unable to undo the %s: %s", noun, err))}
+		}
+		return nil
+	})
+}
+
+// toggleFollow follows the account of profile, or unfollows it if it is already followed. Like a like,
+// every view is told straight away, then again once it is saved, or to undo it if it could not be.
+func toggleFollow(c *client.Client, profile messages.ProfileMessage) tea.Cmd {
+	current := profile.Viewer.Following
+	if current == messages.PENDING_RECORD {
+		return messages.SendStatusMsg("Still saving the follow…")
+	}
+	did, handle := profile.Did, profile.Handle
+	changed := func(record string, delta int) tea.Cmd {
+		return func() tea.Msg {
+			return messages.FollowMsg{Did: did, Record: record, Delta: delta}
+		}
+	}
+	if current == "" {
+		return tea.Sequence(changed(messages.PENDING_RECORD, 1), func() tea.Msg {
+			ref, err := c.Follow(did)
+			if err != nil {
+				logger.Warn("unable to follow", "did", did, "err", err)
+				return tea.BatchMsg{changed("", -1), messages.SendErrorMsg(fmt.Sprintf("Unable to follow @%s: %s", handle, err))}
+			}
+			return messages.FollowMsg{Did: did, Record: ref.URI}
+		})
+	}
+	return tea.Sequence(changed("", -1), func() tea.Msg {
+		if err := c.DeleteRecord(current); err != nil {
+			logger.Warn("unable to unfollow", "did", did, "err", err)
+			return tea.BatchMsg{changed(current, 1), messages.SendErrorMsg(fmt.Sprintf("Unable to unfollow @%s: %s", handle, err))}
+		}
+		return nil
+	})
+}
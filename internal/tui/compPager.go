@@ -0,0 +1,256 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+// ITEM_GUTTER is the columns a pager with items keeps on the left to mark the focused and hovered ones.
+const ITEM_GUTTER = 2
+
+// Pager is a scrollable block of text, it handles the scrolling and search actions for the view that owns it.
+// Links, mentions and hashtags in the text can be clicked, and the text can be split into items, like posts,
+// that are focused by clicking them.
+type Pager struct {
+	vp      viewport.Model
+	content string
+	query   string
+	matches []int
+	match   int
+	spans   []Span
+	hover   int
+	items   []int
+	focus   int
+	over    int
+}
+
+func NewPager() Pager {
+	return Pager{vp: viewport.New(0, 0), hover: -1, focus: -1, over: -1}
+}
+
+// Resize sets the size of the pager.
+func (p Pager) Resize(w, h int) Pager {
+	p.vp.Width = w
+	p.vp.Height = h
+	return p
+}
+
+// TextWidth is how wide the content should be wrapped, leaving room for the gutter if there are items.
+func (p Pager) TextWidth() int {
+	if len(p.items) > 0 {
+		return max(0, p.vp.Width-ITEM_GUTTER)
+	}
+	return p.vp.Width
+}
+
+// SetContent replaces the text being shown, keeping the scroll position and search.
+func (p Pager) SetContent(s string) Pager {
+	p.content = s
+	p.spans = findSpans(s)
+	p.hover = -1
+	p.matches = findMatches(s, p.query)
+	p.match = min(p.match, max(0, len(p.matches)-1))
+	return p.render()
+}
+
+// SetItems splits the content into items starting at each of the given lines, in order.
+// The content should be wrapped to TextWidth, which is narrower once there are items.
+func (p Pager) SetItems(starts []int) Pager {
+	p.items = starts
+	if p.focus >= len(starts) {
+		p.focus = -1
+	}
+	p.over = -1
+	return p.render()
+}
+
+// Focused returns the index of the item that was last clicked, or -1.
+func (p Pager) Focused() int {
+	return p.focus
+}
+
+// Focus focuses item i and scrolls as little as possible to bring it on screen.
+func (p Pager) Focus(i int) Pager {
+	if i < 0 || i >= len(p.items) {
+		return p
+	}
+	p.focus = i
+	start, end := p.items[i], p.itemEnd(i)
+	switch {
+	case start < p.vp.YOffset || end-start > p.vp.Height:
+		p.vp.SetYOffset(start)
+	case end > p.vp.YOffset+p.vp.Height:
+		p.vp.SetYOffset(end - p.vp.Height)
+	}
+	return p.render()
+}
+
+// Hovered returns the index of the item under the mouse, or -1.
+func (p Pager) Hovered() int {
+	return p.over
+}
+
+// ScrollTo scrolls item i to the top of the screen.
+func (p Pager) ScrollTo(i int) Pager {
+	if i >= 0 && i < len(p.items) {
+		p.vp.SetYOffset(p.items[i])
+	}
+	return p
+}
+
+// Update scrolls for scrolling actions and mouse wheel events, and searches for search messages.
+func (p Pager) Update(msg tea.Msg) (Pager, tea.Cmd) {
+	switch msg := msg.(type) {
+	case keymap.ActionMsg:
+		switch msg.Action {
+		case keymap.NEXT_MATCH:
+			return p.step(msg.Times())
+		case keymap.PREV_MATCH:
+			return p.step(-msg.Times())
+		}
+		p.vp, _ = keymap.Scroll(p.vp, msg)
+		return p, nil
+	case SearchMsg:
+		p.query = msg.Query
+		p.matches = findMatches(p.content, p.query)
+		// start from the first match below the top of the screen
+		p.match = 0
+		for i, line := range p.matches {
+			if line >= p.vp.YOffset {
+				p.match = i
+				break
+			}
+		}
+		return p.step(0)
+	case tea.MouseMsg:
+		return p.mouse(msg)
+	}
+	return p, nil
+}
+
+// mouse scrolls for the wheel, highlights what is under the pointer, and follows or focuses what is clicked.
+func (p Pager) mouse(msg tea.MouseMsg) (Pager, tea.Cmd) {
+	var cmd tea.Cmd
+	if msg.Button == tea.MouseButtonWheelUp || msg.Button == tea.MouseButtonWheelDown {
+		p.vp, cmd = p.vp.Update(msg)
+	}
+	hover, over := -1, -1
+	line, col := p.vp.YOffset+msg.Y, msg.X
+	inside := msg.X >= 0 && msg.Y >= 0 && msg.X < p.vp.Width && msg.Y < p.vp.Height && line < p.vp.TotalLineCount()
+	if len(p.items) > 0 {
+		col -= ITEM_GUTTER
+	}
+	if inside {
+		hover = spanAt(p.spans, line, col)
+		over = p.itemAt(line)
+	}
+	if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+		if hover >= 0 {
+			return p, p.spans[hover].Open()
+		}
+		if over >= 0 {
+			p.focus = over
+		}
+	}
+	if hover != p.hover || over != p.over || msg.Action == tea.MouseActionPress {
+		p.hover, p.over = hover, over
+		p = p.render()
+	}
+	return p, cmd
+}
+
+// itemAt returns the index of the item that line is part of, or -1.
+func (p Pager) itemAt(line int) int {
+	item := -1
+	for i, start := range p.items {
+		if start > line {
+			break
+		}
+		item = i
+	}
+	return item
+}
+
+// itemEnd is the line after the last line of item i.
+func (p Pager) itemEnd(i int) int {
+	if i+1 < len(p.items) {
+		return p.items[i+1]
+	}
+	return p.vp.TotalLineCount()
+}
+
+// render puts the content in the viewport with the hovered link highlighted and the items marked in the gutter.
+func (p Pager) render() Pager {
+	if p.hover < 0 && len(p.items) == 0 {
+		p.vp.SetContent(p.content)
+		return p
+	}
+	lines := strings.Split(p.content, "\n")
+	if p.hover >= 0 {
+		span := p.spans[p.hover]
+		lines[span.Line] = highlight(lines[span.Line], span, styles.Hover)
+	}
+	if len(p.items) > 0 {
+		for i, line := range lines {
+			gutter := strings.Repeat(" ", ITEM_GUTTER)
+			switch p.itemAt(i) {
+			case -1:
+			case p.focus:
+				gutter = styles.Focus.Render("▌") + " "
+			case p.over:
+				gutter = styles.HoverItem.Render("▌") + " "
+			}
+			lines[i] = gutter + line
+		}
+	}
+	p.vp.SetContent(strings.Join(lines, "\n"))
+	return p
+}
+
+// step moves n matches on from the current one, wrapping around, and scrolls to it.
+func (p Pager) step(n int) (Pager, tea.Cmd) {
+	if p.query == "" {
+		return p, nil
+	}
+	if len(p.matches) == 0 {
+		return p, messages.SendWarningMsg(fmt.Sprintf("Pattern not found: %s", p.query))
+	}
+	p.match = ((p.match+n)%len(p.matches) + len(p.matches)) % len(p.matches)
+	p.vp.SetYOffset(p.matches[p.match])
+	return p, messages.SendStatusMsg(fmt.Sprintf("/%s %d of %d", p.query, p.match+1, len(p.matches)))
+}
+
+func (p Pager) AtBottom() bool {
+	return p.vp.AtBottom()
+}
+
+func (p Pager) GotoBottom() Pager {
+	p.vp.GotoBottom()
+	return p
+}
+
+func (p Pager) View() string {
+	return p.vp.View()
+}
+
+// findMatches returns the lines of s that contain query, ignoring case and styling.
+func findMatches(s, query string) []int {
+	if query == "" {
+		return nil
+	}
+	query = strings.ToLower(query)
+	var lines []int
+	for i, line := range strings.Split(s, "\n") {
+		if strings.Contains(strings.ToLower(ansi.Strip(line)), query) {
+			lines = append(lines, i)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,292 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/styles"
+	"github.com/haukened/tsky/internal/utils"
+)
+
+// EMBED_DEPTH is how many quotes deep embeds are shown, a quote of a quote is only linked to.
+const EMBED_DEPTH = 1
+
+// displayName is the display name of a profile, or its handle if it has none.
+func displayName(p messages.ProfileViewBasic) string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+	return "@" + p.Handle
+}
+
+// renderPost renders a feed item wrapped to width, with who reposted it or what it replies to above it.
+func renderPost(item messages.FeedViewPost, width int) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	var lines []string
+	if item.Reason != nil && item.Reason.Type == messages.REASON_REPOST {
+		lines = append(lines, muted.Render("⟲ reposted by "+displayName(item.Reason.By)))
+	}
+	if item.Reply != nil {
+		if context := replyContext(item.Reply.Parent); context != "" {
+			lines = append(lines, muted.Render(context))
+		}
+	}
+	if len(lines) > 0 {
+		lines = []string{closeLinks(Wrap(strings.Join(lines, "\n"), width))}
+	}
+	lines = append(lines, renderPostView(item.Post, width))
+	return strings.Join(lines, "\n")
+}
+
+// replyContext says what parent is, above a reply to it.
+func replyContext(parent messages.PostView) string {
+	switch {
+	case parent.NotFound:
+		return "↩ reply to a deleted post"
+	case parent.Blocked:
+		return "↩ reply to a blocked post"
+	case parent.Author.Handle != "":
+		return "↩ reply to @" + parent.Author.Handle
+	}
+	return ""
+}
+
+// renderPostView renders a post wrapped to width: who wrote it and when, its text, its embed, and its counts.
+func renderPostView(post messages.PostView, width int) string {
+	lines := []string{postHeader(post.Author, post.Record.CreatedAt)}
+	if post.Record.Text != "" {
+		lines = append(lines, renderText(post.Record.Text, post.Record.Facets))
+	}
+	text := closeLinks(Wrap(strings.Join(lines, "\n"), width))
+	if post.Embed != nil {
+		text += "\n" + renderEmbed(*post.Embed, width, 0)
+	}
+	return text + "\n" + renderCounts(post)
+}
+
+// postHeader is the bold name and the muted handle and age of the author of a post.
+func postHeader(author messages.ProfileViewBasic, at time.Time) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	name := lipgloss.NewStyle().Foreground(styles.Normal).Bold(true)
+	return name.Render(displayName(author)) + " " + muted.Render(fmt.Sprintf("@%s · %s", author.Handle, utils.RelativeTime(at)))
+}
+
+// renderCounts is the reply, repost, like and quote counts of a post. What the viewer has liked or reposted is highlighted.
+func renderCounts(post messages.PostView) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	repost, like := muted.Render(fmt.Sprintf("⟲ %d", post.RepostCount)), muted.Render(fmt.Sprintf("♡ %d", post.LikeCount))
+	if post.Viewer != nil && post.Viewer.Repost != "" {
+		repost = lipgloss.NewStyle().Foreground(styles.Special).Render(fmt.Sprintf("⟲ %d", post.RepostCount))
+	}
+	if post.Viewer != nil && post.Viewer.Like != "" {
+		like = lipgloss.NewStyle().Foreground(styles.Error).Render(fmt.Sprintf("♥ %d", post.LikeCount))
+	}
+	sep := muted.Render("  ")
+	return muted.Render(fmt.Sprintf("↩ %d", post.ReplyCount)) + sep + repost + sep + like + sep +
+		muted.Render(fmt.Sprintf("❝ %d", post.QuoteCount))
+}
+
+// renderText styles the facets of a post's text. Facets index the text by UTF-8 bytes, and ones that
+// overlap, run past the end or split a character are left as plain text rather than trusted.
+// Each facet is rendered as a hyperlink, so it can be clicked wherever the text wraps.
+func renderText(text string, facets []messages.Facet) string {
+	facets = slices.Clone(facets)
+	slices.SortFunc(facets, func(a, b messages.Facet) int {
+		return a.Index.ByteStart - b.Index.ByteStart
+	})
+	var sb strings.Builder
+	pos := 0
+	for _, facet := range facets {
+		start, end := facet.Index.ByteStart, facet.Index.ByteEnd
+		if start < pos || end > len(text) || start >= end || !utf8.RuneStart(text[start]) ||
+			(end < len(text) && !utf8.RuneStart(text[end])) {
+			logger.Debug("skipping invalid facet", "start", start, "end", end, "length", len(text))
+			continue
+		}
+		uri, style, ok := facetLink(facet)
+		if !ok {
+			continue
+		}
+		sb.WriteString(text[pos:start])
+		sb.WriteString(ansi.SetHyperlink(uri) + style.Render(text[start:end]) + ansi.ResetHyperlink())
+		pos = end
+	}
+	sb.WriteString(text[pos:])
+	return sb.String()
+}
+
+// facetLink is where a facet links to and how it is styled, from the first of its features that is understood.
+func facetLink(facet messages.Facet) (string, lipgloss.Style, bool) {
+	for _, feature := range facet.Features {
+		switch feature.Type {
+		case messages.FACET_MENTION:
+			if feature.Did != "" {
+				return profileURL(feature.Did), lipgloss.NewStyle().Foreground(styles.Primary), true
+			}
+		case messages.FACET_TAG:
+			if feature.Tag != "" {
+				return hashtagURL(feature.Tag), lipgloss.NewStyle().Foreground(styles.Primary), true
+			}
+		case messages.FACET_LINK:
+			if feature.URI != "" {
+				return feature.URI, lipgloss.NewStyle().Foreground(styles.Special), true
+			}
+		}
+	}
+	return "", lipgloss.Style{}, false
+}
+
+// renderEmbed renders an embed in a box with a border on the left, so the whole thing is width wide.
+// depth is how many quotes deep the embed is.
+func renderEmbed(embed messages.EmbedView, width, depth int) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	inner := max(1, width-2)
+	var body string
+	switch embed.Type {
+	case messages.EMBED_IMAGES_VIEW:
+		var lines []string
+		for _, image := range embed.Images {
+			lines = append(lines, muted.Render("[image] ")+altText(image.Alt))
+		}
+		body = closeLinks(Wrap(strings.Join(lines, "\n"), inner))
+	case messages.EMBED_VIDEO_VIEW:
+		body = closeLinks(Wrap(muted.Render("[video] ")+altText(embed.Alt), inner))
+	case messages.EMBED_EXTERNAL_VIEW:
+		if embed.External == nil {
+			return ""
+		}
+		body = renderExternal(*embed.External, inner)
+	case messages.EMBED_RECORD_VIEW:
+		if embed.Record == nil {
+			return ""
+		}
+		body = renderRecord(embed.Record.View(), inner, depth)
+	case messages.EMBED_RECORD_WITH_MEDIA_VIEW:
+		var parts []string
+		if embed.Media != nil {
+			parts = append(parts, renderEmbed(*embed.Media, width, depth))
+		}
+		if embed.Record != nil {
+			parts = append(parts, renderEmbed(messages.EmbedView{Type: messages.EMBED_RECORD_VIEW, Record: embed.Record}, width, depth))
+		}
+		return strings.Join(parts, "\n")
+	default:
+		body = muted.Render("[unsupported embed]")
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(styles.Muted).
+		PaddingLeft(1).
+		Render(body)
+}
+
+// altText is the alt text of an image or video, or a note that there is none.
+func altText(alt string) string {
+	if alt == "" {
+		return lipgloss.NewStyle().Foreground(styles.Muted).Italic(true).Render("no alt text")
+	}
+	return alt
+}
+
+// renderExternal renders a link card: its title, description, and the link itself.
+func renderExternal(card messages.EmbedExternalView, width int) string {
+	var lines []string
+	if card.Title != "" {
+		lines = append(lines, lipgloss.NewStyle().Bold(true).Render(card.Title))
+	}
+	if card.Description != "" {
+		lines = append(lines, lipgloss.NewStyle().Foreground(styles.Muted).Render(card.Description))
+	}
+	lines = append(lines, ansi.SetHyperlink(card.URI)+styles.URL(card.URI)+ansi.ResetHyperlink())
+	return closeLinks(Wrap(strings.Join(lines, "\n"), width))
+}
+
+// renderRecord renders the record of a record embed, usually a quoted post.
+func renderRecord(record messages.EmbeddedRecord, width, depth int) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	var text string
+	switch record.Type {
+	case messages.EMBED_VIEW_RECORD:
+		if depth >= EMBED_DEPTH {
+			link := postURL(record.URI, record.Author.Handle)
+			text = muted.Render("[quote] ") + ansi.SetHyperlink(link) + styles.URL(link) + ansi.ResetHyperlink()
+			break
+		}
+		lines := []string{postHeader(record.Author, record.Value.CreatedAt)}
+		if record.Value.Text != "" {
+			lines = append(lines, renderText(record.Value.Text, record.Value.Facets))
+		}
+		text = closeLinks(Wrap(strings.Join(lines, "\n"), width))
+		for _, embed := range record.Embeds {
+			if embed := renderEmbed(embed, width, depth+1); embed != "" {
+				text += "\n" + embed
+			}
+		}
+		return text
+	case messages.EMBED_VIEW_NOT_FOUND:
+		text = muted.Render("Quoted post not found, it may have been deleted")
+	case messages.EMBED_VIEW_BLOCKED:
+		text = muted.Render("Quoted post is blocked")
+	case messages.EMBED_VIEW_DETACHED:
+		text = muted.Render("Quoted post was removed by its author")
+	case messages.GENERATOR_VIEW:
+		text = renderCreated("Feed", record.DisplayName, record.Creator)
+	case messages.LIST_VIEW:
+		text = renderCreated("List", record.Name, record.Creator)
+	default:
+		text = muted.Render("[unsupported record]")
+	}
+	return closeLinks(Wrap(text, width))
+}
+
+// renderCreated names a feed or list and who made it.
+func renderCreated(kind, name string, creator *messages.ProfileViewBasic) string {
+	text := lipgloss.NewStyle().Foreground(styles.Muted).Render(kind+": ") + lipgloss.NewStyle().Bold(true).Render(name)
+	if creator != nil {
+		text += lipgloss.NewStyle().Foreground(styles.Muted).Render(" by @" + creator.Handle)
+	}
+	return text
+}
+
+// closeLinks ends each line of s with any hyperlink that is open still closed, and opens it again
+// on the next line, so that text wrapped inside a link does not link everything up to the end of the next line.
+func closeLinks(s string) string {
+	lines := strings.Split(s, "\n")
+	open := ""
+	for i, line := range lines {
+		if open != "" {
+			line = ansi.SetHyperlink(open) + line
+		}
+		_, open = hyperlinks(line, i, "")
+		if open != "" {
+			line += ansi.ResetHyperlink()
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// itemKey identifies a feed item. The same post can be in a feed more than once, once for each repost.
+func itemKey(item messages.FeedViewPost) string {
+	if item.Reason != nil {
+		return item.Post.URI + " " + item.Reason.By.Did
+	}
+	return item.Post.URI
+}
+
+// renderKey identifies how a feed item renders, it changes whenever the rendering would.
+func renderKey(item messages.FeedViewPost) string {
+	post := item.Post
+	var viewer messages.PostViewerState
+	if post.Viewer != nil {
+		viewer = *post.Viewer
+	}
+	return fmt.Sprintf("%s %s %d %d %d %d %+v", itemKey(item), utils.RelativeTime(post.Record.CreatedAt),
+		post.ReplyCount, post.RepostCount, post.LikeCount, post.QuoteCount, viewer)
+}
@@ -0,0 +1,241 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/loader"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+// ResultPage is a page of a list that is fetched by cursor, like accounts or feeds.
+type ResultPage[T any] struct {
+	Items  []T
+	Cursor string
+}
+
+// ResultList is a scrolling list of items that are not posts, like accounts, feeds or lists. It loads the
+// next page as the focus nears the end, and hands the focused item to its owner to open.
+type ResultList[T any] struct {
+	name   string
+	empty  string
+	fetch  func(cursor string) (ResultPage[T], error)
+	render func(item T, width int) string
+	first  loader.Resource[ResultPage[T]]
+	loaded time.Time
+	next   loader.Resource[ResultPage[T]]
+	more   bool
+	items  []T
+	cursor string
+	body   Pager
+	theme  string
+	width  int
+}
+
+// NewResultList lists the items fetch pages through, drawn with render. name is what they are called
+// while loading, and empty is shown when there are none.
+func NewResultList[T any](name, empty string, fetch func(cursor string) (ResultPage[T], error),
+	render func(item T, width int) string) ResultList[T] {
+	l := ResultList[T]{
+		name:   name,
+		empty:  empty,
+		fetch:  fetch,
+		render: render,
+		body:   NewPager(),
+	}
+	l.first = loader.New(name, func() (ResultPage[T], error) {
+		return fetch("")
+	})
+	return l
+}
+
+func (l ResultList[T]) Init() tea.Cmd {
+	return l.first.Init()
+}
+
+// Resize sets the size of the list.
+func (l ResultList[T]) Resize(w, h int) ResultList[T] {
+	l.width = w
+	l.body = l.body.Resize(w, h)
+	return l.sync()
+}
+
+// Focused returns the item that is focused, if any is.
+func (l ResultList[T]) Focused() (T, bool) {
+	i := l.body.Focused()
+	if i < 0 || i >= len(l.items) {
+		var none T
+		return none, false
+	}
+	return l.items[i], true
+}
+
+// Loaded reports whether the first page has been loaded.
+func (l ResultList[T]) Loaded() bool {
+	return l.first.HasData()
+}
+
+// Items returns the items loaded so far.
+func (l ResultList[T]) Items() []T {
+	return l.items
+}
+
+// Set replaces the items, for changes made while the list is shown that add, remove or reorder them.
+func (l ResultList[T]) Set(items []T) ResultList[T] {
+	l.items = items
+	return l.sync()
+}
+
+// Focus focuses item i.
+func (l ResultList[T]) Focus(i int) ResultList[T] {
+	l.body = l.body.Focus(i)
+	return l
+}
+
+// Map replaces every item with what change returns for it, for changes made while the list is shown.
+func (l ResultList[T]) Map(change func(T) T) ResultList[T] {
+	items := make([]T, len(l.items))
+	for i, item := range l.items {
+		items[i] = change(item)
+	}
+	l.items = items
+	return l.sync()
+}
+
+// Remove takes the items that match out of the list.
+func (l ResultList[T]) Remove(match func(T) bool) ResultList[T] {
+	l.items = slices.DeleteFunc(slices.Clone(l.items), match)
+	return l.sync()
+}
+
+// Reload fetches the list again from the first page.
+func (l ResultList[T]) Reload() (ResultList[T], tea.Cmd) {
+	var cmd tea.Cmd
+	l.first, cmd = l.first.Reload()
+	return l, cmd
+}
+
+// Update moves the focus and scrolls for actions and the mouse, and takes in the pages as they load.
+func (l ResultList[T]) Update(msg tea.Msg) (ResultList[T], tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case messages.RefreshMsg:
+		return l.Reload()
+	case keymap.ActionMsg:
+		switch msg.Action {
+		case keymap.RELOAD:
+			if l.next.State() == loader.Failed {
+				l.next, cmd = l.next.Reload()
+				return l, cmd
+			}
+			return l.Reload()
+		case keymap.UP:
+			return l.move(-msg.Times()).loadNext(nil)
+		case keymap.DOWN:
+			return l.move(msg.Times()).loadNext(nil)
+		}
+		l.body, cmd = l.body.Update(msg)
+		return l.loadNext(cmd)
+	case SearchMsg, tea.MouseMsg:
+		l.body, cmd = l.body.Update(msg)
+		return l.loadNext(cmd)
+	}
+	var next tea.Cmd
+	l.first, cmd = l.first.Update(msg)
+	l.next, next = l.next.Update(msg)
+	cmd = tea.Batch(cmd, next)
+	switch {
+	case l.first.HasData() && l.first.LoadedAt() != l.loaded:
+		// a reload starts the list again, the pages after the first are fetched again as they are needed
+		l.loaded = l.first.LoadedAt()
+		page := l.first.Data()
+		l.items, l.cursor, l.more = page.Items, page.Cursor, false
+		l = l.sync()
+	case l.more && l.next.HasData():
+		l.more = false
+		page := l.next.Data()
+		l.items = append(slices.Clone(l.items), page.Items...)
+		l.cursor = page.Cursor
+		if len(page.Items) == 0 {
+			l.cursor = ""
+		}
+		l = l.sync()
+	case l.theme != styles.Current() || l.next.IsLoading():
+		// the loading spinner turns with every message
+		l = l.sync()
+	}
+	return l.loadNext(cmd)
+}
+
+// move focuses the item n on from the focused one, or the first item if none is focused.
+func (l ResultList[T]) move(n int) ResultList[T] {
+	if len(l.items) == 0 {
+		return l
+	}
+	i := l.body.Focused()
+	if i < 0 {
+		l.body = l.body.Focus(0)
+		return l
+	}
+	l.body = l.body.Focus(min(max(i+n, 0), len(l.items)-1))
+	return l
+}
+
+// loadNext fetches the next page once the focus nears the end of the list, or the end is on screen.
+func (l ResultList[T]) loadNext(cmd tea.Cmd) (ResultList[T], tea.Cmd) {
+	if l.cursor == "" || l.next.IsLoading() || l.next.State() == loader.Failed || !l.first.HasData() {
+		return l, cmd
+	}
+	if l.body.Focused() < len(l.items)-FEED_LOAD_AHEAD && !l.body.AtBottom() {
+		return l, cmd
+	}
+	fetch, cursor := l.fetch, l.cursor
+	l.next = loader.New("more "+l.name, func() (ResultPage[T], error) {
+		return fetch(cursor)
+	})
+	l.more = true
+	return l.sync(), tea.Batch(cmd, l.next.Init())
+}
+
+// sync draws the items again and puts them in the body, keeping the focus where it was.
+func (l ResultList[T]) sync() ResultList[T] {
+	l.theme = styles.Current()
+	if !l.first.HasData() {
+		return l
+	}
+	width := max(1, l.width-ITEM_GUTTER)
+	var lines []string
+	starts := make([]int, len(l.items))
+	for i, item := range l.items {
+		starts[i] = len(lines)
+		lines = append(lines, strings.Split(l.render(item, width), "\n")...)
+		// a blank line between items
+		lines = append(lines, "")
+	}
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	switch {
+	case len(l.items) == 0:
+		lines = append(lines, muted.Render(l.empty))
+	case l.next.IsLoading():
+		lines = append(lines, muted.Render(fmt.Sprintf("%s Loading more %s…", loader.Spinner(), l.name)))
+	case l.next.State() == loader.Failed:
+		lines = append(lines, lipgloss.NewStyle().Foreground(styles.Error).Render(fmt.Sprintf("Unable to load more %s: %s", l.name, l.next.Err()))+
+			muted.Render(" (press r to retry)"))
+	}
+	l.body = l.body.SetContent(strings.Join(lines, "\n")).SetItems(starts)
+	return l
+}
+
+func (l ResultList[T]) View() string {
+	if !l.first.HasData() {
+		// the loader animates its own spinner and error states
+		return l.first.View(func(ResultPage[T]) string { return "" })
+	}
+	return l.body.View()
+}
@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+// TOAST_HISTORY is how many toasts are kept for the message history.
+const TOAST_HISTORY = 100
+
+// toastDurations is how long a toast of each severity stays up, errors stay longest so they can be read.
+var toastDurations = map[messages.Severity]time.Duration{
+	messages.SEVERITY_INFO:    3 * time.Second,
+	messages.SEVERITY_SUCCESS: 3 * time.Second,
+	messages.SEVERITY_WARNING: 5 * time.Second,
+	messages.SEVERITY_ERROR:   8 * time.Second,
+}
+
+// toast is a toast that has been sent, and when.
+type toast struct {
+	messages.ToastMsg
+	id int
+	at time.Time
+}
+
+// toastExpiredMsg takes down the toast with id when its time is up.
+type toastExpiredMsg struct {
+	id int
+}
+
+// Toasts shows the toasts sent by views one at a time, in the order they were sent, and remembers them
+// for the message history. Only the toast on screen is timed, the rest wait their turn.
+type Toasts struct {
+	queue   []toast
+	history []toast
+	next    int
+}
+
+// Push queues a toast. A toast that repeats the one last queued is dropped, and an info toast on screen
+// gives way to anything newer, since it is only ever about what just happened.
+func (t Toasts) Push(msg messages.ToastMsg) (Toasts, tea.Cmd) {
+	if n := len(t.queue); n > 0 && t.queue[n-1].ToastMsg == msg {
+		return t, nil
+	}
+	t.next++
+	sent := toast{ToastMsg: msg, id: t.next, at: time.Now()}
+	t.history = append(t.history, sent)
+	if len(t.history) > TOAST_HISTORY {
+		t.history = append([]toast{}, t.history[len(t.history)-TOAST_HISTORY:]...)
+	}
+	t.queue = append(append([]toast{}, t.queue...), sent)
+	switch {
+	case len(t.queue) == 1:
+		return t, t.expire()
+	case t.queue[0].Severity == messages.SEVERITY_INFO:
+		return t.pop()
+	}
+	return t, nil
+}
+
+// Expire takes down the toast on screen if its time is up, and starts the timer for the next one.
+func (t Toasts) Expire(msg toastExpiredMsg) (Toasts, tea.Cmd) {
+	if len(t.queue) == 0 || t.queue[0].id != msg.id {
+		// it was already dismissed or replaced
+		return t, nil
+	}
+	return t.pop()
+}
+
+// Dismiss takes down the toast on screen early.
+func (t Toasts) Dismiss() (Toasts, tea.Cmd) {
+	if len(t.queue) == 0 {
+		return t, nil
+	}
+	return t.pop()
+}
+
+// pop moves on to the next toast in the queue.
+func (t Toasts) pop() (Toasts, tea.Cmd) {
+	t.queue = append([]toast{}, t.queue[1:]...)
+	return t, t.expire()
+}
+
+// expire starts the timer for the toast on screen.
+func (t Toasts) expire() tea.Cmd {
+	if len(t.queue) == 0 {
+		return nil
+	}
+	id := t.queue[0].id
+	return tea.Tick(toastDurations[t.queue[0].Severity], func(time.Time) tea.Msg {
+		return toastExpiredMsg{id: id}
+	})
+}
+
+// History returns every toast still remembered, oldest first.
+func (t Toasts) History() []toast {
+	return t.history
+}
+
+// View is the toast on screen styled for its severity, cut to width, with a count of those waiting.
+func (t Toasts) View(width int) string {
+	if len(t.queue) == 0 || width <= 0 {
+		return ""
+	}
+	more := ""
+	if waiting := len(t.queue) - 1; waiting > 0 {
+		more = lipgloss.NewStyle().Foreground(styles.Muted).Render(fmt.Sprintf(" +%d", waiting))
+	}
+	text := ansi.Truncate(t.queue[0].Text, max(1, width-lipgloss.Width(more)), "…")
+	return toastStyle(t.queue[0].Severity).Render(text) + more
+}
+
+// toastStyle is the theme style for a severity.
+func toastStyle(s messages.Severity) lipgloss.Style {
+	switch s {
+	case messages.SEVERITY_SUCCESS:
+		return styles.ToastSuccess
+	case messages.SEVERITY_WARNING:
+		return styles.ToastWarning
+	case messages.SEVERITY_ERROR:
+		return styles.ToastError
+	}
+	return styles.ToastInfo
+}
@@ -0,0 +1,381 @@
+package keymap
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Actions, the names are the ones used in the keys section of the config.
+const (
+	QUIT           = "quit"
+	HELP           = "help"
+	LOGS           = "logs"
+	BACK           = "back"
+	NEXT_TAB       = "next_tab"
+	PREV_TAB       = "prev_tab"
+	NEW_TAB        = "new_tab"
+	CLOSE_TAB      = "close_tab"
+	SELECT_TAB     = "select_tab"
+	RELOAD         = "reload"
+	UP             = "up"
+	DOWN           = "down"
+	PAGE_UP        = "page_up"
+	PAGE_DOWN      = "page_down"
+	HALF_PAGE_UP   = "half_page_up"
+	HALF_PAGE_DOWN = "half_page_down"
+	TOP            = "top"
+	BOTTOM         = "bottom"
+	SEARCH         = "search"
+	NEXT_MATCH     = "next_match"
+	PREV_MATCH     = "prev_match"
+	COMMAND        = "command"
+	OPEN           = "open"
+	COPY_LINK      = "copy_link"
+	TOGGLE_THEME   = "toggle_theme"
+	SWITCH_ACCOUNT = "switch_account"
+	LOGOUT         = "logout"
+	MESSAGES       = "messages"
+	SELECT         = "select"
+	COLLAPSE       = "collapse"
+	SORT           = "sort"
+	COMPOSE        = "compose"
+	REPLY          = "reply"
+	QUOTE          = "quote"
+	LIKE           = "like"
+	REPOST         = "repost"
+	FILTER         = "filter"
+	NOTIFICATIONS  = "notifications"
+	FOLLOW         = "follow"
+	SEARCH_TAB     = "search_tab"
+	FEEDS          = "feeds"
+	SAVE           = "save"
+	PIN            = "pin"
+	MOVE_UP        = "move_up"
+	MOVE_DOWN      = "move_down"
+	LISTS          = "lists"
+	CREATE         = "create"
+	RENAME         = "rename"
+	DELETE         = "delete"
+	ADD            = "add"
+	REMOVE         = "remove"
+)
+
+// Groups actions are listed under in the help overlay.
+const (
+	GROUP_GLOBAL = "Global"
+	GROUP_TABS   = "Tabs"
+	GROUP_SCROLL = "Scrolling"
+	GROUP_SEARCH = "Search"
+	GROUP_VIEW   = "View"
+)
+
+// Action is something a key can be bound to.
+type Action struct {
+	Name  string
+	Group string
+	Keys  []string
+	Help  string
+}
+
+// actions is the registry of everything that can be bound, with the default keys.
+var actions = []Action{
+	{QUIT, GROUP_GLOBAL, []string{"ctrl+c"}, "quit"},
+	{HELP, GROUP_GLOBAL, []string{"?"}, "help"},
+	{LOGS, GROUP_GLOBAL, []string{"ctrl+l"}, "logs"},
+	{BACK, GROUP_GLOBAL, []string{"esc", "backspace"}, "back"},
+	{NEXT_TAB, GROUP_TABS, []string{"tab"}, "next tab"},
+	{PREV_TAB, GROUP_TABS, []string{"shift+tab"}, "previous tab"},
+	{NEW_TAB, GROUP_TABS, []string{"ctrl+t"}, "new tab"},
+	{CLOSE_TAB, GROUP_TABS, []string{"ctrl+w"}, "close tab"},
+	{SELECT_TAB, GROUP_TABS, []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}, "go to tab"},
+	{RELOAD, GROUP_VIEW, []string{"r"}, "reload"},
+	{UP, GROUP_SCROLL, []string{"up"}, "up"},
+	{DOWN, GROUP_SCROLL, []string{"down"}, "down"},
+	{PAGE_UP, GROUP_SCROLL, []string{"pgup"}, "page up"},
+	{PAGE_DOWN, GROUP_SCROLL, []string{"pgdown", " "}, "page down"},
+	{HALF_PAGE_UP, GROUP_SCROLL, []string{"ctrl+u"}, "½ page up"},
+	{HALF_PAGE_DOWN, GROUP_SCROLL, []string{"ctrl+d"}, "½ page down"},
+	{TOP, GROUP_SCROLL, []string{"home"}, "top"},
+	{BOTTOM, GROUP_SCROLL, []string{"end"}, "bottom"},
+	{SEARCH, GROUP_SEARCH, []string{"/"}, "search"},
+	{NEXT_MATCH, GROUP_SEARCH, []string{"n"}, "next match"},
+	{PREV_MATCH, GROUP_SEARCH, []string{"N"}, "previous match"},
+	{COMMAND, GROUP_GLOBAL, []string{":", "ctrl+p"}, "command palette"},
+	{OPEN, GROUP_GLOBAL, nil, "open a link or handle"},
+	{COPY_LINK, GROUP_VIEW, nil, "copy link"},
+	{TOGGLE_THEME, GROUP_GLOBAL, nil, "next theme"},
+	{SWITCH_ACCOUNT, GROUP_GLOBAL, nil, "switch account"},
+	{LOGOUT, GROUP_GLOBAL, nil, "log out"},
+	{MESSAGES, GROUP_GLOBAL, nil, "message history"},
+	{SELECT, GROUP_VIEW, []string{"enter"}, "open"},
+	{COLLAPSE, GROUP_VIEW, []string{"c"}, "collapse replies"},
+	{SORT, GROUP_VIEW, []string{"s"}, "change order"},
+	{COMPOSE, GROUP_GLOBAL, []string{"p"}, "new post"},
+	{REPLY, GROUP_VIEW, []string{"R"}, "reply"},
+	{QUOTE, GROUP_VIEW, []string{"Q"}, "quote"},
+	{LIKE, GROUP_VIEW, []string{"l"}, "like / unlike"},
+	{REPOST, GROUP_VIEW, []string{"t"}, "repost / undo"},
+	{FILTER, GROUP_VIEW, []string{"f"}, "filter"},
+	{NOTIFICATIONS, GROUP_TABS, []string{"i"}, "notifications"},
+	{FOLLOW, GROUP_VIEW, []string{"F"}, "follow / unfollow"},
+	{SEARCH_TAB, GROUP_TABS, []string{"S"}, "search bluesky"},
+	{FEEDS, GROUP_TABS, []string{"m"}, "my feeds"},
+	{SAVE, GROUP_VIEW, []string{"a"}, "save / remove"},
+	{PIN, GROUP_VIEW, []string{"P"}, "pin / unpin"},
+	{MOVE_UP, GROUP_VIEW, []string{"shift+up"}, "move up"},
+	{MOVE_DOWN, GROUP_VIEW, []string{"shift+down"}, "move down"},
+	{LISTS, GROUP_TABS, []string{"L"}, "lists"},
+	{CREATE, GROUP_VIEW, []string{"C"}, "new list"},
+	{RENAME, GROUP_VIEW, []string{"e"}, "rename"},
+	{DELETE, GROUP_VIEW, []string{"D"}, "delete"},
+	{ADD, GROUP_VIEW, []string{"+"}, "add member"},
+	{REMOVE, GROUP_VIEW, []string{"-"}, "remove member"},
+}
+
+// DEFAULT_PRESET is the arrow key preset used when the keymap config key is not set.
+const DEFAULT_PRESET = "default"
+
+// Preset is a set of keys to start from before the keys section of the config is applied.
+// Keys replaces the default keys of the actions it lists, a nil list leaves the action unbound.
+// Counts lets a number typed before a key repeat it, e.g. 5j.
+type Preset struct {
+	Counts bool
+	Keys   map[string][]string
+}
+
+// Presets can be selected with the keymap config key.
+var Presets = map[string]Preset{
+	DEFAULT_PRESET: {},
+	"vim": {
+		Counts: true,
+		Keys: map[string][]string{
+			UP:             {"k", "up"},
+			DOWN:           {"j", "down"},
+			PAGE_UP:        {"ctrl+b", "pgup"},
+			PAGE_DOWN:      {"ctrl+f", "pgdown"},
+			HALF_PAGE_UP:   {"ctrl+u"},
+			HALF_PAGE_DOWN: {"ctrl+d"},
+			TOP:            {"g g", "home"},
+			BOTTOM:         {"G", "end"},
+			NEXT_TAB:       {"g t"},
+			PREV_TAB:       {"g T"},
+			COPY_LINK:      {"y y"},
+			MOVE_UP:        {"K", "shift+up"},
+			MOVE_DOWN:      {"J", "shift+down"},
+			// the digits are counts, 3gt goes to the third tab
+			SELECT_TAB: nil,
+		},
+	},
+	"emacs": {
+		Keys: map[string][]string{
+			UP:         {"ctrl+p", "up"},
+			DOWN:       {"ctrl+n", "down"},
+			PAGE_UP:    {"alt+v", "pgup"},
+			PAGE_DOWN:  {"ctrl+v", "pgdown"},
+			TOP:        {"alt+<", "home"},
+			BOTTOM:     {"alt+>", "end"},
+			BACK:       {"ctrl+g", "esc"},
+			SEARCH:     {"ctrl+s"},
+			NEXT_MATCH: {"alt+n"},
+			PREV_MATCH: {"alt+p"},
+			COMMAND:    {"alt+x"},
+			NEXT_TAB:   {"ctrl+x o"},
+			PREV_TAB:   {"ctrl+x O"},
+			NEW_TAB:    {"ctrl+x t"},
+			CLOSE_TAB:  {"ctrl+x k"},
+		},
+	},
+}
+
+// bindings are rebuilt from the registry and the config by Apply, so never cache them in a model.
+var (
+	bindings map[string]key.Binding
+	counts   bool
+	preset   string
+)
+
+func init() {
+	Apply(DEFAULT_PRESET, nil)
+}
+
+// Check reports whether Apply would accept the named preset and overrides, without applying them.
+func Check(name string, overrides map[string][]string) error {
+	if name == "" {
+		name = DEFAULT_PRESET
+	}
+	if _, ok := Presets[name]; !ok {
+		return fmt.Errorf("unknown keymap %q, available keymaps are %v", name, PresetNames())
+	}
+	for action := range overrides {
+		if !slices.ContainsFunc(actions, func(a Action) bool { return a.Name == action }) {
+			return fmt.Errorf("unknown key action %q, available actions are %v", action, Names())
+		}
+	}
+	return nil
+}
+
+// Apply rebuilds every binding from the named preset, replacing the keys of any action in overrides.
+// If the preset or an action in overrides does not exist the current bindings are left untouched.
+func Apply(name string, overrides map[string][]string) error {
+	if err := Check(name, overrides); err != nil {
+		return err
+	}
+	if name == "" {
+		name = DEFAULT_PRESET
+	}
+	p := Presets[name]
+	next := make(map[string]key.Binding, len(actions))
+	for _, a := range actions {
+		keys := a.Keys
+		if k, ok := p.Keys[a.Name]; ok {
+			keys = k
+		}
+		if o, ok := overrides[a.Name]; ok {
+			keys = o
+		}
+		if len(keys) == 0 {
+			next[a.Name] = key.NewBinding(key.WithDisabled())
+			continue
+		}
+		next[a.Name] = key.NewBinding(key.WithKeys(keys...), key.WithHelp(display(a.Name, keys), a.Help))
+	}
+	bindings = next
+	counts = p.Counts
+	preset = name
+	return nil
+}
+
+// Current returns the name of the active preset.
+func Current() string {
+	return preset
+}
+
+// PresetNames returns the sorted names of all presets.
+func PresetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// display is how keys are shown in help, a run of tab numbers is shown as a range
+// and the keys of a sequence are run together.
+func display(name string, keys []string) string {
+	if name == SELECT_TAB && len(keys) > 2 {
+		return keys[0] + "-" + keys[len(keys)-1]
+	}
+	shown := make([]string, len(keys))
+	for i, k := range keys {
+		switch k {
+		case " ":
+			k = "space"
+		case "up":
+			k = "↑"
+		case "down":
+			k = "↓"
+		default:
+			k = strings.ReplaceAll(k, " ", "")
+		}
+		shown[i] = k
+	}
+	return strings.Join(shown, "/")
+}
+
+// Has reports whether action is in the registry.
+func Has(action string) bool {
+	_, ok := bindings[action]
+	return ok
+}
+
+// Get returns the binding for an action. Unknown actions get a disabled binding that never matches.
+func Get(action string) key.Binding {
+	b, ok := bindings[action]
+	if !ok {
+		return key.NewBinding(key.WithDisabled())
+	}
+	return b
+}
+
+// Matches reports whether msg is bound to action.
+func Matches(msg tea.KeyMsg, action string) bool {
+	return key.Matches(msg, Get(action))
+}
+
+// Bindings returns the bindings for actions, in order.
+func Bindings(actions ...string) []key.Binding {
+	b := make([]key.Binding, len(actions))
+	for i, a := range actions {
+		b[i] = Get(a)
+	}
+	return b
+}
+
+// Group returns the bindings of every action in group, in registry order.
+func Group(group string) []key.Binding {
+	var b []key.Binding
+	for _, a := range actions {
+		if a.Group == group {
+			b = append(b, Get(a.Name))
+		}
+	}
+	return b
+}
+
+// Actions returns every action in the registry, in order, with the keys they are bound to now.
+func Actions() []Action {
+	all := make([]Action, len(actions))
+	for i, a := range actions {
+		a.Keys = Get(a.Name).Keys()
+		all[i] = a
+	}
+	return all
+}
+
+// Names returns the sorted names of all actions.
+func Names() []string {
+	names := make([]string, 0, len(actions))
+	for _, a := range actions {
+		names = append(names, a.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Scroll moves a viewport for the scrolling actions, it reports false if msg is not one of them.
+func Scroll(vp viewport.Model, msg ActionMsg) (viewport.Model, bool) {
+	n := msg.Times()
+	switch msg.Action {
+	case UP:
+		vp.LineUp(n)
+	case DOWN:
+		vp.LineDown(n)
+	case PAGE_UP:
+		vp.LineUp(n * vp.Height)
+	case PAGE_DOWN:
+		vp.LineDown(n * vp.Height)
+	case HALF_PAGE_UP:
+		vp.LineUp(n * vp.Height / 2)
+	case HALF_PAGE_DOWN:
+		vp.LineDown(n * vp.Height / 2)
+	case TOP:
+		vp.GotoTop()
+	case BOTTOM:
+		if msg.Count > 0 {
+			// like vim, a count goes to that line
+			vp.SetYOffset(msg.Count - 1)
+			break
+		}
+		vp.GotoBottom()
+	default:
+		return vp, false
+	}
+	return vp, true
+}
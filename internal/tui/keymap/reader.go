@@ -0,0 +1,116 @@
+package keymap
+
+import (
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ActionMsg asks the view on screen to carry out an action. Count is how many times,
+// or 0 if no count was typed, and Arg is anything typed after the action in a command.
+type ActionMsg struct {
+	Action string
+	Count  int
+	Arg    string
+}
+
+// Times is how many times to repeat the action, at least once.
+func (a ActionMsg) Times() int {
+	return max(1, a.Count)
+}
+
+// Run returns a command that sends action with no count.
+func Run(action string) tea.Cmd {
+	return func() tea.Msg {
+		return ActionMsg{Action: action}
+	}
+}
+
+// Result is what a key press added up to.
+type Result int
+
+const (
+	// Unbound keys are not part of any binding and should be handled as plain input.
+	Unbound Result = iota
+	// Pending keys are the start of a count or sequence, and are held until it is complete.
+	Pending
+	// Complete keys finished a binding, the action is ready to run.
+	Complete
+)
+
+// Reader turns key presses into actions. It collects counts like the 5 in 5j, when the
+// preset allows them, and sequences like gg that take more than one key.
+type Reader struct {
+	count int
+	keys  []string
+}
+
+// Read feeds in one key press.
+func (r Reader) Read(msg tea.KeyMsg) (Reader, ActionMsg, Result) {
+	k := msg.String()
+	if counts && len(r.keys) == 0 && isDigit(k) && (k != "0" || r.count > 0) {
+		if action, prefix := lookup(k); action == "" && !prefix {
+			r.count = r.count*10 + int(k[0]-'0')
+			return r, ActionMsg{}, Pending
+		}
+	}
+	keys := append(append([]string{}, r.keys...), k)
+	action, prefix := lookup(strings.Join(keys, " "))
+	switch {
+	case action == SELECT_TAB:
+		// the count is which of the keys was pressed, so 3 goes to the third tab
+		return Reader{}, ActionMsg{Action: action, Count: index(action, k) + 1}, Complete
+	case action != "":
+		return Reader{}, ActionMsg{Action: action, Count: r.count}, Complete
+	case prefix:
+		r.keys = keys
+		return r, ActionMsg{}, Pending
+	}
+	if r.count > 0 || len(r.keys) > 0 {
+		// a count or sequence that went nowhere swallows the key that ended it, like vim
+		return Reader{}, ActionMsg{}, Pending
+	}
+	return Reader{}, ActionMsg{}, Unbound
+}
+
+// Pending is what has been typed of a count or sequence so far, e.g. 5g.
+func (r Reader) Pending() string {
+	var sb strings.Builder
+	if r.count > 0 {
+		sb.WriteString(strconv.Itoa(r.count))
+	}
+	for _, k := range r.keys {
+		sb.WriteString(k)
+	}
+	return sb.String()
+}
+
+// lookup returns the action bound to seq, and whether seq is the start of a longer sequence.
+func lookup(seq string) (action string, prefix bool) {
+	for _, a := range actions {
+		for _, k := range bindings[a.Name].Keys() {
+			switch {
+			case k == seq && action == "":
+				action = a.Name
+			case strings.HasPrefix(k, seq+" "):
+				prefix = true
+			}
+		}
+	}
+	return action, prefix
+}
+
+// index returns which of the keys bound to action k is.
+func index(action, k string) int {
+	for i, bound := range Get(action).Keys() {
+		if bound == k {
+			return i
+		}
+	}
+	return 0
+}
+
+func isDigit(k string) bool {
+	return len(k) == 1 && k[0] >= '0' && k[0] <= '9'
+}
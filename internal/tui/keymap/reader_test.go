@@ -0,0 +1,58 @@
+package keymap
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyMsg builds the key press that bubbletea reports as k.
+func keyMsg(k string) tea.KeyMsg {
+	if k == "ctrl+x" {
+		return tea.KeyMsg{Type: tea.KeyCtrlX}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+func TestReader(t *testing.T) {
+	t.Cleanup(func() { Apply(DEFAULT_PRESET, nil) })
+	tests := []struct {
+		preset  string
+		keys    string
+		want    ActionMsg
+		result  Result
+		pending string
+	}{
+		{"vim", "3 g t", ActionMsg{Action: NEXT_TAB, Count: 3}, Complete, ""},
+		{"vim", "g g", ActionMsg{Action: TOP}, Complete, ""},
+		{"vim", "1 2 j", ActionMsg{Action: DOWN, Count: 12}, Complete, ""},
+		{"vim", "5 g", ActionMsg{}, Pending, "5g"},
+		{"vim", "g z", ActionMsg{}, Pending, ""},
+		{"vim", "0", ActionMsg{}, Unbound, ""},
+		{"emacs", "ctrl+x o", ActionMsg{Action: NEXT_TAB}, Complete, ""},
+		{"emacs", "ctrl+x O", ActionMsg{Action: PREV_TAB}, Complete, ""},
+		{"emacs", "ctrl+x", ActionMsg{}, Pending, "ctrl+x"},
+		// without counts a digit is a key of its own
+		{"emacs", "3", ActionMsg{Action: SELECT_TAB, Count: 3}, Complete, ""},
+		{DEFAULT_PRESET, "3", ActionMsg{Action: SELECT_TAB, Count: 3}, Complete, ""},
+		{DEFAULT_PRESET, "z", ActionMsg{}, Unbound, ""},
+	}
+	for _, tt := range tests {
+		if err := Apply(tt.preset, nil); err != nil {
+			t.Fatal(err)
+		}
+		var (
+			r      Reader
+			got    ActionMsg
+			result Result
+		)
+		for _, k := range strings.Fields(tt.keys) {
+			r, got, result = r.Read(keyMsg(k))
+		}
+		if got != tt.want || result != tt.result || r.Pending() != tt.pending {
+			t.Errorf("%s %q = %+v, %d, pending %q, want %+v, %d, pending %q",
+				tt.preset, tt.keys, got, result, r.Pending(), tt.want, tt.result, tt.pending)
+		}
+	}
+}
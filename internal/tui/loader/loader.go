@@ -0,0 +1,234 @@
+package loader
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haukened/tsky/internal/logging"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+// State is where a Resource is in its lifecycle.
+type State int
+
+const (
+	// Idle resources have never been asked to load.
+	Idle State = iota
+	// Loading resources are fetching and have nothing to show yet.
+	Loading
+	// Loaded resources hold fresh data.
+	Loaded
+	// Failed resources could not be fetched, even after retrying.
+	Failed
+	// Stale resources hold data that is being reloaded, is older than its max age, or failed to reload.
+	Stale
+)
+
+const (
+	MAX_ATTEMPTS  = 3
+	FIRST_BACKOFF = time.Second
+)
+
+func (s State) String() string {
+	return [...]string{"idle", "loading", "loaded", "failed", "stale"}[s]
+}
+
+var logger = logging.For("loader")
+
+var nextID atomic.Int64
+
+// Fetch loads the data for a resource. It always runs off the UI goroutine, so it is free to block.
+type Fetch[T any] func() (T, error)
+
+// resultMsg carries the outcome of a fetch back to the resource that started it.
+type resultMsg struct {
+	id   int64
+	gen  int
+	data any
+	err  error
+}
+
+// retryMsg asks a resource to try a failed fetch again once its backoff has passed.
+type retryMsg struct {
+	id  int64
+	gen int
+}
+
+// Resource is one piece of remote data a view depends on. Views declare their resources when
+// they are created and never do I/O themselves, the fetch runs in a tea.Cmd and the result
+// is delivered back through Update.
+type Resource[T any] struct {
+	id       int64
+	name     string
+	gen      int
+	attempt  int
+	fetch    Fetch[T]
+	state    State
+	loading  bool
+	data     T
+	err      error
+	loadedAt time.Time
+	maxAge   time.Duration
+}
+
+// New declares a resource. It starts out loading, so Init only has to start the fetch.
+func New[T any](name string, fetch Fetch[T]) Resource[T] {
+	return Resource[T]{
+		id:      nextID.Add(1),
+		name:    name,
+		fetch:   fetch,
+		state:   Loading,
+		loading: true,
+	}
+}
+
+// WithMaxAge marks the data as stale once it is older than d.
+func (r Resource[T]) WithMaxAge(d time.Duration) Resource[T] {
+	r.maxAge = d
+	return r
+}
+
+// Init starts the first fetch of a resource created with New.
+func (r Resource[T]) Init() tea.Cmd {
+	return r.start()
+}
+
+// Reload fetches the data again. Data that was already loaded stays on screen, marked stale, until the new data arrives.
+func (r Resource[T]) Reload() (Resource[T], tea.Cmd) {
+	if r.loading {
+		return r, nil
+	}
+	r.gen++
+	r.attempt = 0
+	r.loading = true
+	if r.HasData() {
+		r.state = Stale
+	} else {
+		r.state = Loading
+	}
+	return r, r.start()
+}
+
+// Update handles the results of this resource's fetches, other messages are ignored.
+func (r Resource[T]) Update(msg tea.Msg) (Resource[T], tea.Cmd) {
+	switch msg := msg.(type) {
+	case resultMsg:
+		if msg.id != r.id || msg.gen != r.gen {
+			return r, nil
+		}
+		if msg.err != nil {
+			return r.failed(msg.err)
+		}
+		r.data = msg.data.(T)
+		r.err = nil
+		r.state = Loaded
+		r.loading = false
+		r.attempt = 0
+		r.loadedAt = time.Now()
+		logger.Debug("loaded", "resource", r.name)
+	case retryMsg:
+		if msg.id != r.id || msg.gen != r.gen {
+			return r, nil
+		}
+		return r, r.start()
+	}
+	return r, nil
+}
+
+// failed schedules a retry with exponential backoff, or gives up after MAX_ATTEMPTS.
+func (r Resource[T]) failed(err error) (Resource[T], tea.Cmd) {
+	r.err = err
+	r.attempt++
+	if r.attempt < MAX_ATTEMPTS {
+		backoff := FIRST_BACKOFF << (r.attempt - 1)
+		logger.Warn("fetch failed, retrying", "resource", r.name, "attempt", r.attempt, "in", backoff, "err", err)
+		id, gen := r.id, r.gen
+		return r, tea.Tick(backoff, func(time.Time) tea.Msg {
+			return retryMsg{id: id, gen: gen}
+		})
+	}
+	logger.Error("fetch failed", "resource", r.name, "err", err)
+	r.loading = false
+	if r.HasData() {
+		r.state = Stale
+	} else {
+		r.state = Failed
+	}
+	return r, nil
+}
+
+// start returns the command that runs the fetch off the UI goroutine.
+func (r Resource[T]) start() tea.Cmd {
+	id, gen, fetch := r.id, r.gen, r.fetch
+	return tea.Batch(func() tea.Msg {
+		active.Add(1)
+		defer active.Add(-1)
+		data, err := fetch()
+		return resultMsg{id: id, gen: gen, data: data, err: err}
+	}, startSpinner())
+}
+
+// State returns the current state, taking the max age into account.
+func (r Resource[T]) State() State {
+	if r.state == Loaded && r.maxAge > 0 && time.Since(r.loadedAt) > r.maxAge {
+		return Stale
+	}
+	return r.state
+}
+
+// Data returns the last data that was loaded, or the zero value.
+func (r Resource[T]) Data() T {
+	return r.data
+}
+
+// Err returns the error from the last fetch, if it failed.
+func (r Resource[T]) Err() error {
+	return r.err
+}
+
+// HasData reports whether the resource has loaded data at least once.
+func (r Resource[T]) HasData() bool {
+	return !r.loadedAt.IsZero()
+}
+
+// IsLoading reports whether a fetch is in flight, including a reload of stale data.
+func (r Resource[T]) IsLoading() bool {
+	return r.loading
+}
+
+// LoadedAt returns when the data was last loaded.
+func (r Resource[T]) LoadedAt() time.Time {
+	return r.loadedAt
+}
+
+// View renders the resource with render once it has data, and the shared loading,
+// error and stale states around it otherwise.
+func (r Resource[T]) View(render func(T) string) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	switch r.State() {
+	case Idle, Loading:
+		return fmt.Sprintf("%s Loading %s…", Spinner(), r.name)
+	case Failed:
+		return lipgloss.NewStyle().Foreground(styles.Error).Render(fmt.Sprintf("Unable to load %s: %s", r.name, r.err)) +
+			"\n" + muted.Render("press r to retry")
+	case Stale:
+		var note string
+		switch {
+		case r.loading:
+			note = fmt.Sprintf("%s refreshing…", Spinner())
+		case r.err != nil:
+			note = fmt.Sprintf("showing data from %s ago, refresh failed: %s (press r to retry)", since(r.loadedAt), r.err)
+		default:
+			note = fmt.Sprintf("showing data from %s ago", since(r.loadedAt))
+		}
+		return lipgloss.JoinVertical(lipgloss.Left, muted.Render(note), render(r.data))
+	}
+	return render(r.data)
+}
+
+func since(t time.Time) string {
+	return time.Since(t).Truncate(time.Second).String()
+}
@@ -0,0 +1,55 @@
+package loader
+
+import (
+	"sync/atomic"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+// every loading resource shares one spinner, which only ticks while a fetch is in flight.
+// ticking is only touched by UpdateSpinner, on the UI goroutine.
+var (
+	shared  = spinner.New(spinner.WithSpinner(spinner.Dot))
+	active  atomic.Int64
+	ticking bool
+)
+
+// wakeMsg asks the spinner to start ticking if it is not already.
+type wakeMsg struct{}
+
+func startSpinner() tea.Cmd {
+	return func() tea.Msg {
+		return wakeMsg{}
+	}
+}
+
+// UpdateSpinner advances the shared spinner. It reports false if msg was not meant for it,
+// so the caller can pass the message on.
+func UpdateSpinner(msg tea.Msg) (tea.Cmd, bool) {
+	if _, ok := msg.(wakeMsg); ok {
+		if ticking {
+			return nil, true
+		}
+		ticking = true
+		return shared.Tick, true
+	}
+	tick, ok := msg.(spinner.TickMsg)
+	if !ok || tick.ID != shared.ID() {
+		return nil, false
+	}
+	if active.Load() == 0 {
+		ticking = false
+		return nil, true
+	}
+	var cmd tea.Cmd
+	shared, cmd = shared.Update(tick)
+	return cmd, true
+}
+
+// Spinner renders the current frame of the shared spinner.
+func Spinner() string {
+	return lipgloss.NewStyle().Foreground(styles.Primary).Render(shared.View())
+}
@@ -1,38 +1,133 @@
 package tui
 
 import (
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/haukened/tsky/internal/client"
 	"github.com/haukened/tsky/internal/config"
+	"github.com/haukened/tsky/internal/messages"
 	"github.com/haukened/tsky/internal/tokensvc"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/loader"
 	"github.com/haukened/tsky/internal/tui/styles"
 )
 
+// defaultTab is what a new tab shows.
+var defaultTab = config.TabState{Route: ROUTE_HOME}
+
+// defaultTabs are the tabs that are opened when nothing was saved.
+var defaultTabs = []config.TabState{defaultTab, {Route: ROUTE_NOTIFICATIONS}}
+
+// AppView holds the tabs, in order. Each tab has its own back stack of views.
+// It also keeps count of the unread notifications, which are shown in the tab bar,
+// and keeps a tab open for each pinned feed. Changes to the saved feeds are written from here,
+// one at a time, so a slow write never lands after a later one.
 type AppView struct {
-	jwt        *tokensvc.Refresher
-	tabs       map[int]NamedModel
+	conf       *config.Config
+	client     *client.Client
+	did        string
+	tabs       []Router
 	currentTab int
+	hoverTab   int
+	unread     loader.Resource[int]
+	counted    time.Time
+	count      int
+	prefs      loader.Resource[messages.Preferences]
+	synced     time.Time
+	pinned     []string
+	saved      []messages.SavedFeed
+	feeds      []messages.SavedFeed
+	writing    bool
+	queued     []messages.SavedFeed
+	pending    bool
+	opened     []tea.Cmd
 	w          int
 	h          int
 }
 
-func NewAppView(c *config.Config) AppView {
-	// create a new token svc
-	jwt, err := tokensvc.NewRefresher(c)
+// NewAppView reopens the tabs that were open when tsky last exited.
+func NewAppView(c *config.Config, sess *tokensvc.Refresher) AppView {
+	client := client.New(sess)
+	a := AppView{
+		conf:     c,
+		client:   client,
+		did:      client.Did(),
+		hoverTab: -1,
+		unread:   loader.New("unread notifications", client.GetUnreadCount),
+		prefs:    loader.New("preferences", client.GetPreferences),
+	}
+	saved, err := config.LoadTabs(a.did)
 	if err != nil {
-		jwt = nil
+		logger.Warn("unable to restore tabs", "err", err)
 	}
-	return AppView{
-		jwt: jwt,
-		tabs: map[int]NamedModel{
-			0: NewProfileTab(c.Did, c.Server, jwt),
-		},
-		currentTab: 0,
-		w:          0,
-		h:          0,
+	// the commands that start each tab are kept for Init
+	var cmd tea.Cmd
+	for _, tab := range saved.Open {
+		a, cmd = a.openTab(messages.NavigateMsg{Route: tab.Route, Params: tab.Params})
+		a.opened = append(a.opened, cmd)
 	}
+	if len(a.tabs) == 0 {
+		for _, tab := range defaultTabs {
+			a, cmd = a.openTab(messages.NavigateMsg{Route: tab.Route, Params: tab.Params})
+			a.opened = append(a.opened, cmd)
+		}
+	}
+	a.currentTab = min(max(saved.Current, 0), len(a.tabs)-1)
+	return a
+}
+
+// newTabRouter returns a router for the views that can be opened inside a tab.
+func newTabRouter(client *client.Client, conf *config.Config) Router {
+	return NewRouter(map[string]RouteFactory{
+		ROUTE_HOME: func(messages.Params) (NamedModel, error) {
+			return NewHomeTab(client), nil
+		},
+		ROUTE_PROFILE: func(params messages.Params) (NamedModel, error) {
+			return NewProfileTab(params["actor"], client), nil
+		},
+		ROUTE_THREAD: func(params messages.Params) (NamedModel, error) {
+			if params["uri"] == "" {
+				//lint:ignore ST1005 shown in the status bar
+				return nil, errors.New("No post to show a thread for")
+			}
+			return NewThreadTab(params["uri"], client, conf), nil
+		},
+		ROUTE_COMPOSE: func(params messages.Params) (NamedModel, error) {
+			return NewComposeView(params["reply"], params["quote"], client), nil
+		},
+		ROUTE_NOTIFICATIONS: func(messages.Params) (NamedModel, error) {
+			return NewNotificationsTab(client), nil
+		},
+		ROUTE_SEARCH: func(params messages.Params) (NamedModel, error) {
+			return NewSearchTab(params["q"], client), nil
+		},
+		ROUTE_FEED: func(params messages.Params) (NamedModel, error) {
+			if params["uri"] == "" {
+				//lint:ignore ST1005 shown in the status bar
+				return nil, errors.New("No feed to show")
+			}
+			return NewFeedTab(params["uri"], client), nil
+		},
+		ROUTE_FEEDS: func(messages.Params) (NamedModel, error) {
+			return NewFeedsTab(client), nil
+		},
+		ROUTE_LIST: func(params messages.Params) (NamedModel, error) {
+			if params["uri"] == "" {
+				//lint:ignore ST1005 shown in the status bar
+				return nil, errors.New("No list to show")
+			}
+			return NewListTab(params["uri"], client), nil
+		},
+		ROUTE_LISTS: func(params messages.Params) (NamedModel, error) {
+			return NewListsTab(params["actor"], client), nil
+		},
+	})
 }
 
 func (a AppView) Name() string {
@@ -40,49 +135,498 @@ func (a AppView) Name() string {
 }
 
 func (a AppView) Init() tea.Cmd {
+	return tea.Batch(append([]tea.Cmd{a.unread.Init(), a.prefs.Init()}, a.opened...)...)
+}
+
+func (a AppView) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	model, cmd := a.update(msg)
+	if a, ok := model.(AppView); ok {
+		a, replaced := a.replaceClosed()
+		a, seen := a.markSeen()
+		return a, tea.Batch(cmd, replaced, seen)
+	}
+	return model, cmd
+}
+
+// replaceClosed shows the default view in a tab whose only view has closed, like a composer that was
+// all the tab had. The router keeps it, as a tab can not be left empty.
+func (a AppView) replaceClosed() (AppView, tea.Cmd) {
 	var cmds []tea.Cmd
-	for _, model := range a.tabs {
-		cmds = append(cmds, model.Init())
+	for i, tab := range a.tabs {
+		if tab.Depth() != 1 || !closed(tab.Current()) {
+			continue
+		}
+		tab, cmd := tab.Navigate(messages.NavigateMsg{Route: defaultTab.Route, Params: defaultTab.Params, Replace: true})
+		a.tabs = slices.Clone(a.tabs)
+		a.tabs[i] = tab
+		cmds = append(cmds, cmd)
 	}
-	return tea.Batch(cmds...)
+	if len(cmds) == 0 {
+		return a, nil
+	}
+	return a, tea.Batch(append(cmds, a.saveTabs())...)
 }
 
-func (a AppView) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+func (a AppView) update(msg tea.Msg) (NamedModel, tea.Cmd) {
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		// nothing yet
+	case messages.NavigateMsg:
+		if msg.NewTab {
+			a, cmd := a.openTab(msg)
+			a.currentTab = len(a.tabs) - 1
+			return a, tea.Batch(cmd, a.saveTabs())
+		}
+		// links open on top of the current tab, so back returns to where they were followed from
+		tab, cmd := a.tabs[a.currentTab].Navigate(msg)
+		a.tabs = slices.Clone(a.tabs)
+		a.tabs[a.currentTab] = tab
+		return a, tea.Batch(cmd, a.saveTabs())
+	case messages.BackMsg:
+		tab, _ := a.tabs[a.currentTab].Back()
+		a.tabs = slices.Clone(a.tabs)
+		a.tabs[a.currentTab] = tab
+		return a, a.saveTabs()
+	case ParamsChangedMsg:
+		return a, a.saveTabs()
+	case keymap.ActionMsg:
+		switch msg.Action {
+		case keymap.NEXT_TAB:
+			if msg.Count > 0 {
+				// like vim, 3gt goes to the third tab
+				return a.selectTab(msg.Count - 1)
+			}
+			return a.selectTab((a.currentTab + 1) % len(a.tabs))
+		case keymap.PREV_TAB:
+			n := len(a.tabs)
+			return a.selectTab(((a.currentTab-msg.Times())%n + n) % n)
+		case keymap.NEW_TAB:
+			open := messages.NavigateMsg{Route: defaultTab.Route, Params: defaultTab.Params, NewTab: true}
+			if msg.Arg != "" {
+				link, err := ParseLink(msg.Arg)
+				if err != nil {
+					return a, messages.SendErrorMsg(err.Error())
+				}
+				open.Route, open.Params = link.Route, link.Params
+			}
+			return a.Update(open)
+		case keymap.CLOSE_TAB:
+			return a.closeTab(a.currentTab)
+		case keymap.COMPOSE:
+			return a.Update(messages.NavigateMsg{Route: ROUTE_COMPOSE})
+		case keymap.NOTIFICATIONS:
+			return a.showTab(ROUTE_NOTIFICATIONS)
+		case keymap.SEARCH_TAB:
+			if route, _ := a.tabs[a.currentTab].Route(); route == ROUTE_SEARCH {
+				// already searching, start typing a new search
+				return a.updateCurrent(msg)
+			}
+			return a.showTab(ROUTE_SEARCH)
+		case keymap.FEEDS:
+			return a.showTab(ROUTE_FEEDS)
+		case keymap.LISTS:
+			if route, params := a.tabs[a.currentTab].Route(); route == ROUTE_PROFILE && params["actor"] != "" {
+				// on a profile, the lists are the ones that account made
+				return a.Update(messages.NavigateMsg{Route: ROUTE_LISTS, Params: messages.Params{"actor": params["actor"]}})
+			}
+			return a.showTab(ROUTE_LISTS)
+		case keymap.SELECT_TAB:
+			return a.selectTab(msg.Count - 1)
+		case keymap.BACK:
+			if tab, ok := a.tabs[a.currentTab].Back(); ok {
+				a.tabs = slices.Clone(a.tabs)
+				a.tabs[a.currentTab] = tab
+				return a, a.saveTabs()
+			}
+			return a, nil
+		}
+		return a.updateCurrent(msg)
+	case tea.KeyMsg, SearchMsg:
+		// only the tab on screen takes input
+		return a.updateCurrent(msg)
+	case tea.MouseMsg:
+		a.hoverTab = -1
+		if msg.Y >= 0 && msg.Y < tabBarHeight() {
+			return a.mouseTabs(msg)
+		}
+		// the tab sees the mouse relative to its own top left corner
+		msg.Y -= tabBarHeight()
+		return a.updateCurrent(msg)
+	case messages.RefreshMsg:
+		// only the tab on screen reloads, hidden tabs keep what they have until they are shown and refreshed,
+		// but the unread count in the tab bar is always on screen
+		var cmd tea.Cmd
+		a.unread, cmd = a.unread.Reload()
+		model, current := a.updateCurrent(msg)
+		return model, tea.Batch(cmd, current)
 	case tea.WindowSizeMsg:
+		// tabs get whatever is left under the tab bar
 		a.w = msg.Width
 		a.h = msg.Height
+		body := a.tabSize()
+		a.tabs = slices.Clone(a.tabs)
+		for i, tab := range a.tabs {
+			tab, cmd := tab.Update(body)
+			cmds = append(cmds, cmd)
+			a.tabs[i] = tab
+		}
+		return a, tea.Batch(cmds...)
+	case messages.SavedFeedsMsg:
+		// the feed manager changed the saved feeds, the tabs follow and then every tab is told
+		var cmd tea.Cmd
+		a, cmd = a.pinTabs(msg.Pinned())
+		cmds = append(cmds, cmd)
+		a.feeds = msg.Items
+		if msg.Save {
+			a, cmd = a.saveFeeds(msg.Items)
+			cmds = append(cmds, cmd)
+		}
+	case savedFeedsMsg:
+		return a.savedFeeds(msg)
+	case messages.ListChangedMsg:
+		// a deleted list is no longer a saved feed either, which closes its pinned tab
+		if msg.List == nil {
+			feeds := slices.DeleteFunc(slices.Clone(a.feeds), func(item messages.SavedFeed) bool { return item.Value == msg.URI })
+			if len(feeds) < len(a.feeds) {
+				cmds = append(cmds, func() tea.Msg { return messages.SavedFeedsMsg{Items: feeds, Save: true} })
+			}
+		}
+	}
+	var cmd tea.Cmd
+	a.unread, cmd = a.unread.Update(msg)
+	cmds = append(cmds, cmd)
+	if a.unread.HasData() && a.unread.LoadedAt() != a.counted {
+		a.counted = a.unread.LoadedAt()
+		a.count = a.unread.Data()
+	}
+	a.prefs, cmd = a.prefs.Update(msg)
+	cmds = append(cmds, cmd)
+	if a.prefs.HasData() && a.prefs.LoadedAt() != a.synced {
+		a.synced = a.prefs.LoadedAt()
+		a.saved = a.prefs.Data().SavedFeeds()
+		a.feeds = a.saved
+		a, cmd = a.pinTabs(messages.SavedFeedsMsg{Items: a.saved}.Pinned())
+		cmds = append(cmds, cmd)
 	}
 	// update all tabs
-	for i, model := range a.tabs {
-		model, cmd := model.Update(msg)
+	a.tabs = slices.Clone(a.tabs)
+	for i, tab := range a.tabs {
+		tab, cmd := tab.Update(msg)
 		cmds = append(cmds, cmd)
-		a.tabs[i] = model
+		a.tabs[i] = tab
 	}
 	// return the updated model and a batch of commands
 	return a, tea.Batch(cmds...)
 }
 
+// mouseTabs handles the mouse over the tab bar, a click selects a tab and a middle click closes it.
+func (a AppView) mouseTabs(msg tea.MouseMsg) (NamedModel, tea.Cmd) {
+	i, ok := a.tabAt(msg.X)
+	if !ok {
+		return a, nil
+	}
+	a.hoverTab = i
+	if msg.Action != tea.MouseActionPress {
+		return a, nil
+	}
+	switch msg.Button {
+	case tea.MouseButtonLeft:
+		return a.selectTab(i)
+	case tea.MouseButtonMiddle:
+		return a.closeTab(i)
+	}
+	return a, nil
+}
+
+// markSeen marks the notifications as seen once the tab showing them is on screen and has loaded them,
+// up to the newest one it has, so any that arrive meanwhile are still unread.
+func (a AppView) markSeen() (AppView, tea.Cmd) {
+	if route, _ := a.tabs[a.currentTab].Route(); route != ROUTE_NOTIFICATIONS || a.count == 0 {
+		return a, nil
+	}
+	tab, ok := a.tabs[a.currentTab].Current().(NotificationsTab)
+	if !ok || tab.Newest().IsZero() {
+		return a, nil
+	}
+	a.count = 0
+	c, seenAt := a.client, tab.Newest()
+	return a, func() tea.Msg {
+		if err := c.UpdateSeen(seenAt); err != nil {
+			logger.Warn("unable to mark notifications as seen", "err", err)
+		}
+		return nil
+	}
+}
+
+// pinTabs keeps a tab open for each pinned feed and list, in the order they are pinned in, and closes the
+// tabs of the ones that were unpinned since the last time. Tabs of feeds that were never pinned are left alone.
+func (a AppView) pinTabs(pinned []messages.SavedFeed) (AppView, tea.Cmd) {
+	var uris []string
+	routes := map[string]string{}
+	for _, item := range pinned {
+		switch item.Type {
+		case messages.SAVED_FEED_FEED:
+			routes[item.Value] = ROUTE_FEED
+		case messages.SAVED_FEED_LIST:
+			routes[item.Value] = ROUTE_LIST
+		default:
+			continue
+		}
+		uris = append(uris, item.Value)
+	}
+	// ids are where each tab was before, to find the current tab again afterwards
+	var tabs []Router
+	var ids []int
+	for i, tab := range a.tabs {
+		if uri := pinnedFeed(tab); slices.Contains(a.pinned, uri) && !slices.Contains(uris, uri) {
+			continue
+		}
+		tabs, ids = append(tabs, tab), append(ids, i)
+	}
+	if len(tabs) == 0 {
+		// the last tab can not be closed
+		tabs, ids = a.tabs[:1], []int{0}
+	}
+	a.tabs = tabs
+	var cmds []tea.Cmd
+	for _, uri := range uris {
+		if slices.ContainsFunc(a.tabs, func(tab Router) bool { return pinnedFeed(tab) == uri }) {
+			continue
+		}
+		var cmd tea.Cmd
+		a, cmd = a.openTab(messages.NavigateMsg{Route: routes[uri], Params: messages.Params{"uri": uri}})
+		cmds = append(cmds, cmd)
+		for len(ids) < len(a.tabs) {
+			ids = append(ids, -1)
+		}
+	}
+	// the tabs of pinned feeds swap places until they are in order
+	var slots []int
+	for i, tab := range a.tabs {
+		if slices.Contains(uris, pinnedFeed(tab)) {
+			slots = append(slots, i)
+		}
+	}
+	order := slices.Clone(slots)
+	slices.SortStableFunc(order, func(i, j int) int {
+		return slices.Index(uris, pinnedFeed(a.tabs[i])) - slices.Index(uris, pinnedFeed(a.tabs[j]))
+	})
+	tabs, moved := slices.Clone(a.tabs), slices.Clone(ids)
+	for k, slot := range slots {
+		tabs[slot], moved[slot] = a.tabs[order[k]], ids[order[k]]
+	}
+	current := slices.Index(moved, a.currentTab)
+	if current < 0 {
+		current = min(a.currentTab, len(tabs)-1)
+	}
+	a.tabs, a.currentTab, a.pinned = tabs, current, uris
+	return a, tea.Batch(append(cmds, a.saveTabs())...)
+}
+
+// savedFeedsMsg is the result of writing the saved feeds as items.
+type savedFeedsMsg struct {
+	items []messages.SavedFeed
+	err   error
+}
+
+// saveFeeds writes items as the saved feeds, or keeps them for when the write in flight is done.
+func (a AppView) saveFeeds(items []messages.SavedFeed) (AppView, tea.Cmd) {
+	if a.writing {
+		a.queued, a.pending = items, true
+		return a, nil
+	}
+	a.writing = true
+	c := a.client
+	return a, func() tea.Msg {
+		return savedFeedsMsg{items: items, err: c.PutSavedFeeds(items)}
+	}
+}
+
+// savedFeeds starts the write that was waiting, if there is one. Otherwise a failed write puts back what
+// the saved feeds were last saved as, in every view.
+func (a AppView) savedFeeds(msg savedFeedsMsg) (AppView, tea.Cmd) {
+	a.writing = false
+	var failed tea.Cmd
+	if msg.err != nil {
+		logger.Warn("unable to save feeds", "err", msg.err)
+		failed = messages.SendErrorMsg(fmt.Sprintf("Unable to save your feeds: %s", msg.err))
+	} else {
+		a.saved = msg.items
+	}
+	if a.pending {
+		a.pending = false
+		a, cmd := a.saveFeeds(a.queued)
+		return a, tea.Batch(failed, cmd)
+	}
+	if msg.err == nil || !a.prefs.HasData() {
+		return a, failed
+	}
+	saved := a.saved
+	return a, tea.Batch(failed, func() tea.Msg { return messages.SavedFeedsMsg{Items: saved} })
+}
+
+// pinnedFeed returns the custom feed or list tab was opened with, or nothing if it was opened with something else.
+func pinnedFeed(tab Router) string {
+	if route, params := tab.Root(); route == ROUTE_FEED || route == ROUTE_LIST {
+		return params["uri"]
+	}
+	return ""
+}
+
+// showTab switches to the first tab showing route, or opens a new one if none is.
+func (a AppView) showTab(route string) (NamedModel, tea.Cmd) {
+	for i, tab := range a.tabs {
+		if r, _ := tab.Route(); r == route {
+			return a.selectTab(i)
+		}
+	}
+	return a.Update(messages.NavigateMsg{Route: route, NewTab: true})
+}
+
+// updateCurrent passes msg to the tab on screen.
+func (a AppView) updateCurrent(msg tea.Msg) (NamedModel, tea.Cmd) {
+	tab, cmd := a.tabs[a.currentTab].Update(msg)
+	a.tabs = slices.Clone(a.tabs)
+	a.tabs[a.currentTab] = tab
+	return a, cmd
+}
+
+// openTab adds a tab at the end showing the route in msg.
+func (a AppView) openTab(msg messages.NavigateMsg) (AppView, tea.Cmd) {
+	tab, _ := newTabRouter(a.client, a.conf).Update(a.tabSize())
+	tab, cmd := tab.Navigate(messages.NavigateMsg{Route: msg.Route, Params: msg.Params})
+	if tab.Depth() == 0 {
+		// the route could not be opened, Navigate has already said why
+		return a, cmd
+	}
+	a.tabs = append(append([]Router{}, a.tabs...), tab)
+	return a, cmd
+}
+
+// selectTab switches to tab i, if there is one.
+func (a AppView) selectTab(i int) (NamedModel, tea.Cmd) {
+	if i < 0 || i >= len(a.tabs) {
+		return a, nil
+	}
+	a.currentTab = i
+	return a, a.saveTabs()
+}
+
+// closeTab closes tab i, the last tab can not be closed.
+func (a AppView) closeTab(i int) (NamedModel, tea.Cmd) {
+	if len(a.tabs) == 1 {
+		return a, messages.SendWarningMsg("The last tab can not be closed")
+	}
+	a.tabs = append(append([]Router{}, a.tabs[:i]...), a.tabs[i+1:]...)
+	if a.currentTab >= len(a.tabs) || a.currentTab > i {
+		a.currentTab--
+	}
+	return a, a.saveTabs()
+}
+
+// saveTabs remembers the view on top of each tab so they can be reopened on the next run.
+// A composer is only open until its post is sent, so the view under it is remembered instead.
+func (a AppView) saveTabs() tea.Cmd {
+	state := config.Tabs{Did: a.did, Current: a.currentTab}
+	for _, tab := range a.tabs {
+		route, params := tab.Last(func(route string) bool { return route != ROUTE_COMPOSE })
+		if route == "" {
+			route, params = defaultTab.Route, defaultTab.Params
+		}
+		state.Open = append(state.Open, config.TabState{Route: route, Params: params})
+	}
+	return func() tea.Msg {
+		if err := config.SaveTabs(state); err != nil {
+			logger.Warn("unable to save tabs", "err", err)
+		}
+		return nil
+	}
+}
+
+// CapturesInput reports whether the view in the current tab is taking text input.
+func (a AppView) CapturesInput() bool {
+	return capturesInput(a.tabs[a.currentTab].Current())
+}
+
+// Link returns the link to what the current tab shows.
+func (a AppView) Link() string {
+	return linkOf(a.tabs[a.currentTab].Current())
+}
+
+// KeyHelp lists the bindings of the view in the current tab, then those that switch tabs.
+func (a AppView) KeyHelp() []HelpGroup {
+	tabs := HelpGroup{Title: keymap.GROUP_TABS, Bindings: keymap.Group(keymap.GROUP_TABS)}
+	return append(keyHelp(a.tabs[a.currentTab].Current()), tabs)
+}
+
+// tabSize is the exact size available to the views inside a tab.
+func (a AppView) tabSize() tea.WindowSizeMsg {
+	return tea.WindowSizeMsg{Width: a.w, Height: max(0, a.h-tabBarHeight())}
+}
+
+// tabTitle is the label of tab i in the tab bar, numbered for the keys that select it.
+func (a AppView) tabTitle(i int) string {
+	name := ""
+	if current := a.tabs[i].Current(); current != nil {
+		name = current.Name()
+	}
+	if route, _ := a.tabs[i].Route(); route == ROUTE_NOTIFICATIONS && a.count > 0 {
+		name += " " + styles.Badge.Render(fmt.Sprint(a.count))
+	}
+	if i < 9 {
+		return fmt.Sprintf("%d %s", i+1, name)
+	}
+	return name
+}
+
+// badge is the unread count at the end of the tab bar, when no tab is showing the notifications.
+func (a AppView) badge() string {
+	if a.count == 0 {
+		return ""
+	}
+	for _, tab := range a.tabs {
+		if route, _ := tab.Route(); route == ROUTE_NOTIFICATIONS {
+			return ""
+		}
+	}
+	return styles.Badge.Render(fmt.Sprintf("● %d unread", a.count))
+}
+
+// tabAt returns the tab whose label covers column x of the tab bar.
+func (a AppView) tabAt(x int) (int, bool) {
+	left := 0
+	for i := range a.tabs {
+		right := left + lipgloss.Width(styles.Tab.Render(a.tabTitle(i)))
+		if x >= left && x < right {
+			return i, true
+		}
+		left = right
+	}
+	return 0, false
+}
+
 func (a AppView) View() string {
 	tabRow := a.RenderTabs()
-	tabContent := a.tabs[a.currentTab].View()
-	return lipgloss.JoinVertical(0, tabRow, tabContent)
+	body := a.tabSize()
+	tabContent := Fit(a.tabs[a.currentTab].View(), body.Width, body.Height)
+	return lipgloss.JoinVertical(lipgloss.Left, tabRow, tabContent)
 }
 
 func (a AppView) RenderTabs() string {
 	var tabs []string
-	for i, model := range a.tabs {
-		if i == a.currentTab {
-			tabs = append(tabs, styles.ActiveTab.Render(model.Name()))
-		} else {
-			tabs = append(tabs, styles.Tab.Render(model.Name()))
+	for i := range a.tabs {
+		switch i {
+		case a.currentTab:
+			tabs = append(tabs, styles.ActiveTab.Render(a.tabTitle(i)))
+		case a.hoverTab:
+			tabs = append(tabs, styles.HoverTab.Render(a.tabTitle(i)))
+		default:
+			tabs = append(tabs, styles.Tab.Render(a.tabTitle(i)))
 		}
 	}
 	row := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
-	gap := styles.TabGap.Render(strings.Repeat("+", max(0, a.w-2)))
+	// the gap has its own padding, so leave room for it
+	badge := a.badge()
+	gap := styles.TabGap.Render(strings.Repeat(" ", max(0, a.w-lipgloss.Width(row)-lipgloss.Width(badge)-2)) + badge)
 	row = lipgloss.JoinHorizontal(lipgloss.Bottom, row, gap)
 	return row
 }
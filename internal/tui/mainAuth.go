@@ -1,43 +1,133 @@
 package tui
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/haukened/tsky/internal/auth"
 	"github.com/haukened/tsky/internal/config"
-	"github.com/haukened/tsky/internal/debug"
 	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tokensvc"
+	"github.com/haukened/tsky/internal/tui/styles"
 	"github.com/haukened/tsky/internal/utils"
 )
 
-type AuthModel struct {
-	c *config.Config
-	s spinner.Model
-	r chan authResult
-	m string
+// authState is a step of authentication. AuthModel only moves between them through nextAuthState.
+type authState int
+
+const (
+	authChecking authState = iota
+	authRefreshing
+	authPassword
+	authTwoFactor
+	authFailed
+	authDone
+)
+
+func (s authState) String() string {
+	return [...]string{"checking session", "refreshing", "password login", "2fa", "failed", "done"}[s]
 }
 
-type authResult struct {
-	Success bool
-	Message string
+// authEvent is something that happened while authenticating, usually the result of a command.
+type authEvent int
+
+const (
+	authStart authEvent = iota
+	authHasSession
+	authNoSession
+	authMissingCredentials
+	authRefreshed
+	authRefreshFailed
+	authLoggedIn
+	authNeedsCode
+	authLoginFailed
+	authCodeEntered
+)
+
+func (e authEvent) String() string {
+	return [...]string{
+		"start", "has session", "no session", "missing credentials", "refreshed",
+		"refresh failed", "logged in", "needs code", "login failed", "code entered",
+	}[e]
 }
 
-func NewAuthModel(c *config.Config) AuthModel {
+// nextAuthState is the whole auth state machine. hasPassword says whether a password login
+// is possible, which decides where a failed refresh goes.
+func nextAuthState(from authState, ev authEvent, hasPassword bool) (authState, bool) {
+	if ev == authStart {
+		return authChecking, true
+	}
+	switch from {
+	case authChecking:
+		switch ev {
+		case authHasSession:
+			return authRefreshing, true
+		case authNoSession:
+			return authPassword, true
+		case authMissingCredentials:
+			return authFailed, true
+		}
+	case authRefreshing:
+		switch ev {
+		case authRefreshed:
+			return authDone, true
+		case authRefreshFailed:
+			if hasPassword {
+				return authPassword, true
+			}
+			return authFailed, true
+		}
+	case authPassword:
+		switch ev {
+		case authLoggedIn:
+			return authDone, true
+		case authNeedsCode:
+			return authTwoFactor, true
+		case authLoginFailed:
+			return authFailed, true
+		}
+	case authTwoFactor:
+		if ev == authCodeEntered {
+			return authPassword, true
+		}
+	}
+	return from, false
+}
+
+// authEventMsg delivers an authEvent to the model, err explains a failure.
+type authEventMsg struct {
+	event authEvent
+	err   error
+}
+
+type AuthModel struct {
+	c     *config.Config
+	sess  *tokensvc.Refresher
+	s     spinner.Model
+	code  textinput.Model
+	state authState
+	err   error
+	w     int
+	h     int
+}
+
+func NewAuthModel(c *config.Config, sess *tokensvc.Refresher) AuthModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(skyBlue)
+	code := textinput.New()
+	code.Placeholder = "XXXXX-XXXXX"
+	code.CharLimit = 11
 	return AuthModel{
-		s: s,
-		c: c,
-		r: make(chan authResult),
-		m: "Initializing",
+		s:     s,
+		c:     c,
+		sess:  sess,
+		code:  code,
+		state: authChecking,
 	}
 }
 
@@ -45,178 +135,137 @@ func (a AuthModel) Name() string {
 	return "auth"
 }
 
+// Init starts the state machine from the beginning.
 func (a AuthModel) Init() tea.Cmd {
-	debug.Debugf("Initializing AuthModel")
-	return a.s.Tick
+	logger.Debug("initializing auth model")
+	return tea.Batch(a.s.Tick, sendAuthEvent(authStart, nil))
 }
 
 func (a AuthModel) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
-	debug.Debugf("Updating AuthModel")
 	var cmd tea.Cmd
-	var cmds []tea.Cmd
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		a.w = msg.Width
+		a.h = msg.Height
+		return a, nil
 	case spinner.TickMsg:
-		debug.Debugf("Auth Spinner Tick")
 		a.s, cmd = a.s.Update(msg)
-		cmds = append(cmds, cmd, a.s.Tick)
-	case messages.StartAuthMsg:
-		debug.Debugf("got start auth message")
-		a.m = "Authenticating"
-		go func(ch chan authResult) {
-			doAuth(a.c, ch)
-		}(a.r)
-	}
-	select {
-	case result := <-a.r:
-		if result.Success {
-			a.m = "Success"
-			cmds = append(cmds, messages.SendStatusMsg(result.Message))
-			cmds = append(cmds, messages.Next)
-		} else {
-			a.m = "Failed"
-			cmds = append(cmds, messages.Prev)
-			cmds = append(cmds, messages.SendErrorMsg(result.Message))
+		return a, cmd
+	case authEventMsg:
+		return a.handle(msg)
+	case tea.KeyMsg:
+		if a.state != authTwoFactor {
+			return a, nil
+		}
+		if msg.Type == tea.KeyEnter {
+			return a, sendAuthEvent(authCodeEntered, nil)
 		}
-	default:
-		// No Action
+		a.code, cmd = a.code.Update(msg)
+		return a, cmd
 	}
-	return a, tea.Batch(cmds...)
+	return a, nil
 }
 
-func (a AuthModel) View() string {
-	return fmt.Sprintf("%s Authenicating as %s...\nStatus: %s", a.s.View(), a.c.Identifier, a.m)
-}
-
-const BASE_AUTH_URI = "https://%s/xrpc/com.atproto.server.createSession"
-
-type RequestBody struct {
-	Identifier string `json:"identifier"`
-	Password   string `json:"password"`
-}
-
-type AuthResponse struct {
-	Did    string `json:"did"`
-	DidDoc struct {
-		Context            []string `json:"@context"`
-		ID                 string   `json:"id"`
-		AlsoKnownAs        []string `json:"alsoKnownAs"`
-		VerificationMethod []struct {
-			ID                 string `json:"id"`
-			Type               string `json:"type"`
-			Controller         string `json:"controller"`
-			PublicKeyMultibase string `json:"publicKeyMultibase"`
-		} `json:"verificationMethod"`
-		Service []struct {
-			ID              string `json:"id"`
-			Type            string `json:"type"`
-			ServiceEndpoint string `json:"serviceEndpoint"`
-		} `json:"service"`
-	} `json:"didDoc"`
-	Handle          string `json:"handle"`
-	Email           string `json:"email"`
-	EmailConfirmed  bool   `json:"emailConfirmed"`
-	EmailAuthFactor bool   `json:"emailAuthFactor"`
-	AccessJwt       string `json:"accessJwt"`
-	RefreshJwt      string `json:"refreshJwt"`
-	Active          bool   `json:"active"`
-}
-
-func doAuth(c *config.Config, ch chan authResult) {
-	debug.Debugf("starting auth")
-	if c.Identifier != "" && c.RefreshJwt != "" {
-		debug.Debugf("Checking refresh token")
-		if !utils.IsJwtExpired(c.RefreshJwt) {
-			debug.Debugf("Refresh token is still valid")
-			ch <- authResult{Success: true, Message: "Authenticated"}
-			return
-		} else {
-			debug.Debugf("Refresh token is expired")
-		}
-	} else if c.Identifier == "" {
-		debug.Debugf("No username provided")
-		ch <- authResult{Success: false, Message: "No username provided"}
-		return
-	} else if c.AppPassword == "" {
-		debug.Debugf("No password provided")
-		ch <- authResult{Success: false, Message: "No password provided"}
-		return
+// handle moves the state machine and runs whatever the new state needs.
+func (a AuthModel) handle(msg authEventMsg) (NamedModel, tea.Cmd) {
+	next, ok := nextAuthState(a.state, msg.event, a.c.AppPassword != "")
+	if !ok {
+		logger.Warn("ignoring auth event", "state", a.state, "event", msg.event)
+		return a, nil
 	}
-	err := loginWithPassword(c)
-	if err != nil {
-		ch <- authResult{Success: false, Message: err.Error()}
-		return
+	logger.Info("auth transition", "from", a.state, "event", msg.event, "to", next, "err", msg.err)
+	a.state = next
+	a.err = msg.err
+	if a.err == nil && next == authFailed {
+		a.err = ErrAuthFailed
 	}
-	c.Save()
-	ch <- authResult{Success: true, Message: "Authenticated"}
+	switch next {
+	case authChecking:
+		return a, a.checkSession
+	case authRefreshing:
+		return a, a.refresh
+	case authPassword:
+		code := strings.TrimSpace(a.code.Value())
+		return a, a.login(code)
+	case authTwoFactor:
+		a.code.Reset()
+		return a, tea.Batch(a.code.Focus(), messages.SendStatusMsg(msg.err.Error()))
+	case authFailed:
+		// the form flag keeps the login view from sending a stored session straight back here
+		return a, tea.Batch(messages.Replace(ROUTE_LOGIN, messages.Params{"form": "1"}), messages.SendErrorMsg(a.err.Error()))
+	case authDone:
+		return a, tea.Batch(messages.SendSuccessMsg("Authenticated"), messages.Replace(ROUTE_APP, nil))
+	}
+	return a, nil
 }
 
-func loginWithPassword(c *config.Config) (err error) {
-	debug.Debugf("Logging in with password")
-	// Create the URL
-	postURL := fmt.Sprintf(BASE_AUTH_URI, c.Server)
-
-	// Create the body
-	body := RequestBody{
-		Identifier: c.Identifier,
-		Password:   c.AppPassword,
-	}
+// CapturesInput reports whether the sign in code is being typed.
+func (a AuthModel) CapturesInput() bool {
+	return a.state == authTwoFactor
+}
 
-	// Marshal the body
-	jsonBody, err := json.Marshal(body)
-	if err != nil {
-		return
+func (a AuthModel) View() string {
+	// style the spinner at render time so theme changes apply immediately
+	s := a.s
+	s.Style = lipgloss.NewStyle().Foreground(styles.Primary)
+	if a.state == authTwoFactor {
+		return Center(fmt.Sprintf("Check your email for a sign in code for %s\n\n%s", a.c.Identifier, a.code.View()), a.w, a.h)
 	}
+	return Center(fmt.Sprintf("%s Authenicating as %s...\nStatus: %s", s.View(), a.c.Identifier, a.state), a.w, a.h)
+}
 
-	// Create the request
-	req, err := http.NewRequest("POST", postURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return
+func sendAuthEvent(ev authEvent, err error) tea.Cmd {
+	return func() tea.Msg {
+		return authEventMsg{event: ev, err: err}
 	}
+}
 
-	// Set the headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", utils.UserAgent())
-
-	// log the request
-	log.Printf("Request: %+v\n", req)
+var (
+	//lint:ignore ST1005 the capital is for Formatting
+	ErrNoUsername = errors.New("No username provided")
+	//lint:ignore ST1005 the capital is for Formatting
+	ErrNoPassword = errors.New("No password provided")
+	//lint:ignore ST1005 the capital is for Formatting
+	ErrAuthFailed = errors.New("Authentication failed")
+)
 
-	// Send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Response: %+v\n", resp)
-		//lint:ignore ST1005 the capital is for Formatting
-		err = fmt.Errorf("Login Failed: %s", resp.Status)
-		// blank out the password
-		c.AppPassword = ""
-		return
+// checkSession decides how to authenticate from what is in the config, it does no I/O.
+func (a AuthModel) checkSession() tea.Msg {
+	switch refreshJwt, _ := a.c.Session(); {
+	case a.c.Identifier == "":
+		return authEventMsg{event: authMissingCredentials, err: ErrNoUsername}
+	case refreshJwt != "" && !utils.IsJwtExpired(refreshJwt):
+		return authEventMsg{event: authHasSession}
+	case a.c.AppPassword == "":
+		return authEventMsg{event: authMissingCredentials, err: ErrNoPassword}
 	}
+	return authEventMsg{event: authNoSession}
+}
 
-	// read the response body
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return
+func (a AuthModel) refresh() tea.Msg {
+	if err := a.sess.Refresh(); err != nil {
+		return authEventMsg{event: authRefreshFailed, err: err}
 	}
+	return authEventMsg{event: authRefreshed}
+}
 
-	// Unmarshal the response
-	var authResponse AuthResponse
-	err = json.Unmarshal(bodyBytes, &authResponse)
-	if err != nil {
-		return
+func (a AuthModel) login(code string) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if code != "" {
+			err = auth.LoginWithCode(a.c, code)
+		} else {
+			err = auth.LoginWithPassword(a.c)
+		}
+		switch {
+		case errors.Is(err, auth.ErrAuthFactorRequired):
+			return authEventMsg{event: authNeedsCode, err: err}
+		case err != nil:
+			return authEventMsg{event: authLoginFailed, err: err}
+		}
+		if err := a.c.Save(); err != nil {
+			logger.Error("unable to save session", "err", err)
+		}
+		return authEventMsg{event: authLoggedIn}
 	}
-
-	// set the access and refresh tokens
-	c.AccessJwt = authResponse.AccessJwt
-	c.RefreshJwt = authResponse.RefreshJwt
-	c.Did = authResponse.Did
-
-	// blank out the password
-	c.AppPassword = ""
-
-	return
 }
@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/haukened/tsky/internal/config"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tokensvc"
+)
+
+func TestNextAuthState(t *testing.T) {
+	tests := []struct {
+		from        authState
+		ev          authEvent
+		hasPassword bool
+		want        authState
+		ok          bool
+	}{
+		{authDone, authStart, false, authChecking, true},
+		{authFailed, authStart, true, authChecking, true},
+		{authChecking, authHasSession, false, authRefreshing, true},
+		{authChecking, authNoSession, true, authPassword, true},
+		{authChecking, authMissingCredentials, false, authFailed, true},
+		{authChecking, authRefreshed, true, authChecking, false},
+		{authRefreshing, authRefreshed, false, authDone, true},
+		{authRefreshing, authRefreshFailed, true, authPassword, true},
+		{authRefreshing, authRefreshFailed, false, authFailed, true},
+		{authRefreshing, authLoggedIn, true, authRefreshing, false},
+		{authPassword, authLoggedIn, true, authDone, true},
+		{authPassword, authNeedsCode, true, authTwoFactor, true},
+		{authPassword, authLoginFailed, true, authFailed, true},
+		{authPassword, authRefreshed, true, authPassword, false},
+		{authTwoFactor, authCodeEntered, true, authPassword, true},
+		{authTwoFactor, authLoggedIn, true, authTwoFactor, false},
+		{authDone, authRefreshFailed, false, authDone, false},
+		{authFailed, authLoggedIn, true, authFailed, false},
+	}
+	for _, tt := range tests {
+		got, ok := nextAuthState(tt.from, tt.ev, tt.hasPassword)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("nextAuthState(%s, %s, %t) = %s, %t, want %s, %t",
+				tt.from, tt.ev, tt.hasPassword, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// navigations runs cmd and collects every NavigateMsg it sends, looking inside batches.
+func navigations(cmd tea.Cmd) []messages.NavigateMsg {
+	if cmd == nil {
+		return nil
+	}
+	switch msg := cmd().(type) {
+	case messages.NavigateMsg:
+		return []messages.NavigateMsg{msg}
+	case tea.BatchMsg:
+		var navs []messages.NavigateMsg
+		for _, c := range msg {
+			navs = append(navs, navigations(c)...)
+		}
+		return navs
+	}
+	return nil
+}
+
+// A stored session that cannot be refreshed must end on the login form, not go back to auth.
+func TestFailedRefreshShowsLoginForm(t *testing.T) {
+	refreshJwt, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config.Config{Identifier: "alice.test"}
+	c.SetSession("", refreshJwt, "did:plc:alice")
+
+	a := NewAuthModel(c, tokensvc.New(c))
+	a.state = authRefreshing
+	m, cmd := a.handle(authEventMsg{event: authRefreshFailed, err: tokensvc.ErrHttpError})
+	if got := m.(AuthModel).state; got != authFailed {
+		t.Fatalf("state after failed refresh = %s, want %s", got, authFailed)
+	}
+	navs := navigations(cmd)
+	if len(navs) != 1 || navs[0].Route != ROUTE_LOGIN {
+		t.Fatalf("failed refresh navigated to %v, want %s", navs, ROUTE_LOGIN)
+	}
+
+	login := NewLoginModel(c, navs[0].Params["form"] != "")
+	login, cmd = login.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	if navs := navigations(cmd); len(navs) != 0 {
+		t.Errorf("login form navigated to %v, want it shown", navs)
+	}
+	if !login.(LoginModel).show {
+		t.Error("login form after a failed refresh is not shown")
+	}
+}
@@ -12,7 +12,6 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/haukened/tsky/internal/config"
-	"github.com/haukened/tsky/internal/debug"
 	"github.com/haukened/tsky/internal/messages"
 	"github.com/haukened/tsky/internal/utils"
 )
@@ -46,10 +45,20 @@ var disallowedTLDs = []string{
 	".test",
 }
 
+// LOGIN_FORM_WIDTH is the widest the login form grows, it is centred in any space left over.
+const LOGIN_FORM_WIDTH = 60
+
 type LoginModel struct {
 	form *huh.Form
 	conf *config.Config
 	show bool
+	// failed is set when authentication just failed, the form is shown even for a stored session
+	// so a session that cannot be refreshed does not bounce straight back to auth
+	failed bool
+	w      int
+	h      int
+	// formErr is the form error shown last, so it is only sent once
+	formErr string
 }
 
 func initialForm(c *config.Config) *huh.Form {
@@ -68,12 +77,13 @@ func initialForm(c *config.Config) *huh.Form {
 	).WithShowHelp(false).WithShowErrors(false)
 }
 
-func NewLoginModel(c *config.Config) NamedModel {
+func NewLoginModel(c *config.Config, failed bool) NamedModel {
 	f := initialForm(c)
 	return LoginModel{
-		form: f,
-		conf: c,
-		show: false,
+		form:   f,
+		conf:   c,
+		show:   false,
+		failed: failed,
 	}
 }
 
@@ -87,14 +97,19 @@ func (m LoginModel) Init() tea.Cmd {
 
 func (m LoginModel) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
 	var cmds []tea.Cmd
-	if !authNeeded(m.conf) {
-		debug.Debugf("No auth needed, skipping login")
-		cmds = append(cmds, messages.Next)
-		cmds = append(cmds, messages.StartAuth)
+	if !m.failed && !authNeeded(m.conf) {
+		logger.Debug("no auth needed, skipping login")
+		cmds = append(cmds, messages.Replace(ROUTE_AUTH, nil))
 		return m, tea.Batch(cmds...)
 	} else {
 		m.show = true
 	}
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.w = size.Width
+		m.h = size.Height
+		m.form = m.form.WithWidth(min(m.w, LOGIN_FORM_WIDTH))
+		return m, nil
+	}
 	if m.form.State != huh.StateCompleted {
 		// pass the message to the form
 		form, cmd := m.form.Update(msg)
@@ -102,30 +117,41 @@ func (m LoginModel) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
 			m.form = f
 		}
 		cmds = append(cmds, cmd)
-		// get the form help message
-		cmd = messages.SendHelpText(m.form.Help().ShortHelpView(m.form.KeyBinds()))
-		cmds = append(cmds, cmd)
-		// get the form errors
+		// show a form error once when it appears, and take it down once it is fixed
+		formErr := ""
 		if len(m.form.Errors()) > 0 {
-			cmd = messages.SendErrorMsg(m.form.Errors()[0].Error())
-			cmds = append(cmds, cmd)
-		} else {
-			cmd = messages.ClearStatusMsg()
-			cmds = append(cmds, cmd)
+			formErr = m.form.Errors()[0].Error()
+		}
+		switch {
+		case formErr == m.formErr:
+		case formErr != "":
+			cmds = append(cmds, messages.SendErrorMsg(formErr))
+		default:
+			cmds = append(cmds, messages.Dismiss)
 		}
+		m.formErr = formErr
 	} else {
 		// form is completed
 		m.conf.Save()
-		cmds = append(cmds, messages.Next)
-		cmds = append(cmds, messages.StartAuth)
+		cmds = append(cmds, messages.Replace(ROUTE_AUTH, nil))
 	}
 	// return the updated model and the batched commands
 	return m, tea.Batch(cmds...)
 }
 
+// CapturesInput is always true, every key goes to the form.
+func (m LoginModel) CapturesInput() bool {
+	return true
+}
+
+// KeyHelp lists the keys of the form.
+func (m LoginModel) KeyHelp() []HelpGroup {
+	return []HelpGroup{{Title: "Login", Bindings: m.form.KeyBinds()}}
+}
+
 func (m LoginModel) View() string {
 	if m.show {
-		return m.form.View()
+		return Center(m.form.View(), m.w, m.h)
 	}
 	return ""
 }
@@ -135,12 +161,13 @@ func authNeeded(c *config.Config) bool {
 		// if we don't have a username we need to auth
 		return true
 	}
-	if c.RefreshJwt == "" {
+	refreshJwt, _ := c.Session()
+	if refreshJwt == "" {
 		// if we don't have a refresh token we need to auth
 		return true
 	} else {
 		// if the refresh token is expired we need to auth
-		if utils.IsJwtExpired(c.RefreshJwt) {
+		if utils.IsJwtExpired(refreshJwt) {
 			return true
 		}
 	}
@@ -193,6 +220,22 @@ func validateEmail(s string) error {
 }
 
 func validateHandle(s string) error {
+	err := validateHandleSyntax(s)
+	if err != nil {
+		return err
+	}
+
+	// resolve the handle
+	err = resolveHandle(s)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateHandleSyntax checks a handle without resolving it.
+func validateHandleSyntax(s string) error {
 	// https://atproto.com/specs/handle#handle-identifier-syntax
 	// A reference regular expression (regex) for the handle syntax is:
 	// /^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$/
@@ -210,12 +253,6 @@ func validateHandle(s string) error {
 		}
 	}
 
-	// resolve the handle
-	err := resolveHandle(s)
-	if err != nil {
-		return err
-	}
-
 	return nil
 }
 
@@ -251,7 +288,7 @@ func resolveHandle(handle string) error {
 	}
 	// then check the HTTPS /.well-known/atproto-did file
 	httpsLocation := fmt.Sprintf("https://%s/.well-known/atproto-did", handle)
-	client := http.Client{}
+	client := http.Client{Timeout: utils.HTTP_TIMEOUT}
 	req, err := http.NewRequest(http.MethodGet, httpsLocation, nil)
 	if err != nil {
 		return ErrHttpClient
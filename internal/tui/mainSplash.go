@@ -4,11 +4,14 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/styles"
 )
 
 type splash struct {
 	countdown int
 	done      bool
+	w         int
+	h         int
 }
 
 func NewSplashModel(seconds int) splash {
@@ -24,23 +27,26 @@ func (s splash) Init() tea.Cmd {
 }
 
 func (s splash) Update(message tea.Msg) (NamedModel, tea.Cmd) {
-	if s.done {
-		return s, nil
-	}
-	switch message.(type) {
+	switch message := message.(type) {
+	case tea.WindowSizeMsg:
+		s.w = message.Width
+		s.h = message.Height
 	case messages.TickMsg:
+		if s.done {
+			return s, nil
+		}
 		if s.countdown > 0 {
 			s.countdown--
 			return s, messages.Tick()
 		}
 		s.done = true
-		return s, messages.Next
+		return s, messages.Replace(ROUTE_LOGIN, nil)
 	}
 	return s, nil
 }
 
 func (s splash) View() string {
-	return lipgloss.NewStyle().Foreground(skyBlue).Render(logo)
+	return Center(lipgloss.NewStyle().Foreground(styles.Primary).Render(logo), s.w, s.h)
 }
 
 const logo = `  ***                               ***  
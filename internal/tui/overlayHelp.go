@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+// HelpModel lists every key binding that is active in the current view, on top of it.
+type HelpModel struct {
+	pager  Pager
+	groups []HelpGroup
+}
+
+func NewHelpModel() HelpModel {
+	return HelpModel{pager: NewPager()}
+}
+
+func (h HelpModel) Name() string {
+	return "help"
+}
+
+func (h HelpModel) Init() tea.Cmd {
+	return nil
+}
+
+// Resize sets the size of the help overlay.
+func (h HelpModel) Resize(width, height int) HelpModel {
+	h.pager = h.pager.Resize(width, height)
+	return h.sync()
+}
+
+// Show replaces the bindings being listed and scrolls back to the top.
+func (h HelpModel) Show(groups []HelpGroup) HelpModel {
+	h.groups = groups
+	h.pager, _ = h.pager.Update(keymap.ActionMsg{Action: keymap.TOP})
+	return h.sync()
+}
+
+func (h HelpModel) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	h.pager, cmd = h.pager.Update(msg)
+	return h, cmd
+}
+
+func (h HelpModel) View() string {
+	return h.pager.View()
+}
+
+// sync renders the groups into the viewport, styled from the current theme.
+func (h HelpModel) sync() HelpModel {
+	title := lipgloss.NewStyle().Foreground(styles.Primary).Bold(true)
+	keyStyle := lipgloss.NewStyle().Foreground(styles.Highlight)
+	desc := lipgloss.NewStyle().Foreground(styles.Normal)
+	width := 0
+	for _, g := range h.groups {
+		for _, b := range enabled(g.Bindings) {
+			width = max(width, lipgloss.Width(b.Help().Key))
+		}
+	}
+	var sb strings.Builder
+	for _, g := range h.groups {
+		bindings := enabled(g.Bindings)
+		if len(bindings) == 0 {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(title.Render(g.Title) + "\n")
+		for _, b := range bindings {
+			sb.WriteString("  " + keyStyle.Width(width+2).Render(b.Help().Key) + desc.Render(b.Help().Desc) + "\n")
+		}
+	}
+	h.pager = h.pager.SetContent(sb.String())
+	return h
+}
+
+// enabled drops bindings that have no keys, e.g. actions unbound in the config.
+func enabled(bindings []key.Binding) []key.Binding {
+	var b []key.Binding
+	for _, binding := range bindings {
+		if binding.Enabled() {
+			b = append(b, binding)
+		}
+	}
+	return b
+}
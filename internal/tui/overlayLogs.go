@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/haukened/tsky/internal/logging"
+)
+
+// LogsModel shows the most recent log lines on top of whatever view is active.
+type LogsModel struct {
+	pager  Pager
+	follow bool
+}
+
+func NewLogsModel() LogsModel {
+	return LogsModel{
+		pager:  NewPager(),
+		follow: true,
+	}
+}
+
+func (l LogsModel) Name() string {
+	return "logs"
+}
+
+func (l LogsModel) Init() tea.Cmd {
+	return nil
+}
+
+// Resize sets the size of the log viewer.
+func (l LogsModel) Resize(w, h int) LogsModel {
+	l.pager = l.pager.Resize(w, h)
+	return l.sync()
+}
+
+func (l LogsModel) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	l.pager, cmd = l.pager.Update(msg)
+	// keep following new lines until the user scrolls away from the bottom
+	l.follow = l.pager.AtBottom()
+	return l.sync(), cmd
+}
+
+func (l LogsModel) View() string {
+	return l.pager.View()
+}
+
+// sync reloads the recent log lines into the pager.
+func (l LogsModel) sync() LogsModel {
+	lines := logging.Recent()
+	if len(lines) == 0 {
+		lines = []string{"No log lines yet"}
+	}
+	l.pager = l.pager.SetContent(strings.Join(lines, "\n"))
+	if l.follow {
+		l.pager = l.pager.GotoBottom()
+	}
+	return l
+}
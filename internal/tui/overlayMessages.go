@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+// MessagesModel shows the toasts that have been shown in the status bar, newest at the bottom.
+type MessagesModel struct {
+	pager Pager
+	width int
+}
+
+func NewMessagesModel() MessagesModel {
+	return MessagesModel{pager: NewPager()}
+}
+
+func (m MessagesModel) Name() string {
+	return "messages"
+}
+
+func (m MessagesModel) Init() tea.Cmd {
+	return nil
+}
+
+// Resize sets the size of the message history.
+func (m MessagesModel) Resize(w, h int) MessagesModel {
+	m.width = w
+	m.pager = m.pager.Resize(w, h)
+	return m
+}
+
+// Show fills the history with toasts, scrolled to the newest.
+func (m MessagesModel) Show(toasts []toast) MessagesModel {
+	if len(toasts) == 0 {
+		m.pager = m.pager.SetContent("No messages yet")
+		return m
+	}
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	lines := make([]string, len(toasts))
+	for i, t := range toasts {
+		severity := toastStyle(t.Severity).Render(fmt.Sprintf("%-7s", t.Severity))
+		lines[i] = Wrap(fmt.Sprintf("%s %s %s", muted.Render(t.at.Format("15:04:05")), severity, t.Text), m.width)
+	}
+	m.pager = m.pager.SetContent(strings.Join(lines, "\n")).GotoBottom()
+	return m
+}
+
+func (m MessagesModel) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.pager, cmd = m.pager.Update(msg)
+	return m, cmd
+}
+
+func (m MessagesModel) View() string {
+	return m.pager.View()
+}
@@ -0,0 +1,279 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+// commandAliases are the short names vim users expect, for actions with longer names.
+var commandAliases = map[string]string{
+	"q":        keymap.QUIT,
+	"o":        keymap.OPEN,
+	"tabnew":   keymap.NEW_TAB,
+	"tabclose": keymap.CLOSE_TAB,
+	"tabn":     keymap.NEXT_TAB,
+	"tabp":     keymap.PREV_TAB,
+}
+
+// PALETTE_HEADER is the lines above the entries, the input and a blank line.
+const PALETTE_HEADER = 2
+
+// paletteEntry is one line of the palette, and the action it runs.
+type paletteEntry struct {
+	title  string
+	keys   string
+	action keymap.ActionMsg
+	score  int
+}
+
+// PaletteModel lists every action in the keymap registry, narrowed down by fuzzy matching what
+// is typed. A link, handle or full command line can also be typed to run it directly.
+type PaletteModel struct {
+	input    textinput.Model
+	entries  []paletteEntry
+	selected int
+	active   bool
+	w        int
+	h        int
+}
+
+func NewPaletteModel() PaletteModel {
+	input := textinput.New()
+	input.Prompt = ": "
+	input.Placeholder = "type an action, a handle or a link"
+	return PaletteModel{input: input}
+}
+
+// Open shows the palette with every action listed.
+func (p PaletteModel) Open() (PaletteModel, tea.Cmd) {
+	p.active = true
+	p.input.Reset()
+	p = p.filter()
+	return p, p.input.Focus()
+}
+
+// Active reports whether the palette is showing.
+func (p PaletteModel) Active() bool {
+	return p.active
+}
+
+// Resize sets the size of the palette.
+func (p PaletteModel) Resize(w, h int) PaletteModel {
+	p.w = w
+	p.h = h
+	p.input.Width = max(0, w-lipgloss.Width(p.input.Prompt)-1)
+	return p
+}
+
+// Update edits the filter and moves the selection. Enter or a click runs the selected entry, esc closes the palette.
+func (p PaletteModel) Update(msg tea.Msg) (PaletteModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return p.key(msg)
+	case tea.MouseMsg:
+		return p.mouse(msg)
+	}
+	return p, nil
+}
+
+func (p PaletteModel) key(msg tea.KeyMsg) (PaletteModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		return p.close(), nil
+	case "enter":
+		return p.run()
+	case "up", "ctrl+p", "ctrl+k", "shift+tab":
+		p.selected = max(0, p.selected-1)
+		return p, nil
+	case "down", "ctrl+n", "ctrl+j", "tab":
+		p.selected = min(len(p.entries)-1, p.selected+1)
+		return p, nil
+	}
+	p.input, cmd = p.input.Update(msg)
+	return p.filter(), cmd
+}
+
+// mouse selects the entry under the pointer and runs it when clicked, the wheel moves the selection.
+func (p PaletteModel) mouse(msg tea.MouseMsg) (PaletteModel, tea.Cmd) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		p.selected = max(0, p.selected-1)
+		return p, nil
+	case tea.MouseButtonWheelDown:
+		p.selected = min(len(p.entries)-1, p.selected+1)
+		return p, nil
+	}
+	i, ok := p.entryAt(msg.Y)
+	if !ok {
+		return p, nil
+	}
+	p.selected = i
+	if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+		return p.run()
+	}
+	return p, nil
+}
+
+// run closes the palette and runs the selected entry.
+func (p PaletteModel) run() (PaletteModel, tea.Cmd) {
+	if len(p.entries) == 0 {
+		return p.close(), messages.SendErrorMsg(fmt.Sprintf("Not a command: %s", p.input.Value()))
+	}
+	action := p.entries[p.selected].action
+	return p.close(), func() tea.Msg { return action }
+}
+
+// entryAt returns the entry on line y of the palette, hit-tested against the rows View draws.
+func (p PaletteModel) entryAt(y int) (int, bool) {
+	first, _ := p.rows()
+	i := first + y - PALETTE_HEADER
+	if y < PALETTE_HEADER || i >= len(p.entries) || y >= p.h {
+		return 0, false
+	}
+	return i, true
+}
+
+// rows returns the first entry on screen and how many entries fit, keeping the selection on screen.
+func (p PaletteModel) rows() (first, rows int) {
+	rows = max(1, p.h-PALETTE_HEADER)
+	return max(0, p.selected-rows+1), rows
+}
+
+func (p PaletteModel) close() PaletteModel {
+	p.active = false
+	p.input.Blur()
+	return p
+}
+
+// filter rebuilds the entries for what has been typed. Links and complete commands come
+// first, then the actions that match, best match first.
+func (p PaletteModel) filter() PaletteModel {
+	query := strings.TrimSpace(p.input.Value())
+	p.entries = nil
+	p.selected = 0
+	if isLink(query) {
+		if _, err := ParseLink(query); err == nil {
+			p.entries = append(p.entries, paletteEntry{
+				title:  "Open " + query,
+				action: keymap.ActionMsg{Action: keymap.OPEN, Arg: query},
+			})
+		}
+	}
+	if action, err := parseCommand(query); err == nil && (action.Arg != "" || action.Count > 0) {
+		p.entries = append(p.entries, paletteEntry{title: query, action: action})
+	}
+	var matched []paletteEntry
+	for _, a := range keymap.Actions() {
+		score, ok := fuzzyScore(query, a.Name+" "+a.Help)
+		if !ok {
+			continue
+		}
+		matched = append(matched, paletteEntry{
+			title:  fmt.Sprintf("%s (%s)", a.Help, a.Name),
+			keys:   keymap.Get(a.Name).Help().Key,
+			action: keymap.ActionMsg{Action: a.Name},
+			score:  score,
+		})
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].score > matched[j].score
+	})
+	p.entries = append(p.entries, matched...)
+	return p
+}
+
+func (p PaletteModel) View() string {
+	selected := lipgloss.NewStyle().Foreground(styles.Primary).Bold(true)
+	normal := lipgloss.NewStyle().Foreground(styles.Normal)
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	lines := []string{p.input.View(), ""}
+	first, rows := p.rows()
+	for i := first; i < len(p.entries) && i < first+rows; i++ {
+		e := p.entries[i]
+		style, marker := normal, "  "
+		if i == p.selected {
+			style, marker = selected, "> "
+		}
+		title := style.Render(marker + e.title)
+		keys := muted.Render(e.keys)
+		gap := max(1, p.w-lipgloss.Width(title)-lipgloss.Width(keys))
+		lines = append(lines, title+strings.Repeat(" ", gap)+keys)
+	}
+	if len(p.entries) == 0 {
+		lines = append(lines, muted.Render("  no matching actions"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isLink reports whether s looks like something ParseLink should be given, rather than an action name.
+func isLink(s string) bool {
+	for _, prefix := range []string{"at://", "https://", "http://", "did:", "@", "bsky.app/"} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCommand turns a command line into an action. A number on its own goes to that line,
+// anything else is the name of an action, or an alias for one, followed by its argument.
+// A number as the argument is the count, so :select_tab 3 goes to the third tab.
+func parseCommand(line string) (keymap.ActionMsg, error) {
+	if n, err := strconv.Atoi(line); err == nil && n > 0 {
+		return keymap.ActionMsg{Action: keymap.BOTTOM, Count: n}, nil
+	}
+	name, arg, _ := strings.Cut(line, " ")
+	if alias, ok := commandAliases[name]; ok {
+		name = alias
+	}
+	if !keymap.Has(name) {
+		//lint:ignore ST1005 this error is shown to the user in the status bar
+		return keymap.ActionMsg{}, fmt.Errorf("Not a command: %s", name)
+	}
+	arg = strings.TrimSpace(arg)
+	if n, err := strconv.Atoi(arg); err == nil && n > 0 {
+		return keymap.ActionMsg{Action: name, Count: n}, nil
+	}
+	return keymap.ActionMsg{Action: name, Arg: arg}, nil
+}
+
+// fuzzyScore reports whether every rune of pattern appears in s, in order and ignoring case.
+// Runs of consecutive runes and matches at the start of words score higher, gaps between matches lower.
+func fuzzyScore(pattern, s string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	p := []rune(strings.ToLower(pattern))
+	score, run, j, last := 0, 0, 0, -1
+	prev := ' '
+	for i, r := range []rune(strings.ToLower(s)) {
+		if j < len(p) && r == p[j] {
+			if last >= 0 {
+				score -= i - last - 1
+			}
+			j++
+			run++
+			score += run
+			if !unicode.IsLetter(prev) {
+				// the start of a word
+				score += 3
+			}
+			last = i
+		} else {
+			run = 0
+		}
+		prev = r
+	}
+	return score, j == len(p)
+}
@@ -1,32 +1,81 @@
 package styles
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/gamut"
 )
 
+// Theme is the set of colors every style in tsky is derived from.
+type Theme struct {
+	Primary   lipgloss.TerminalColor
+	Normal    lipgloss.TerminalColor
+	Error     lipgloss.TerminalColor
+	Warning   lipgloss.TerminalColor
+	Subtle    lipgloss.TerminalColor
+	Highlight lipgloss.TerminalColor
+	Special   lipgloss.TerminalColor
+	Muted     lipgloss.TerminalColor
+}
+
+// Themes are the themes that can be selected with the theme config key.
+var Themes = map[string]Theme{
+	"default": {
+		Primary:   lipgloss.Color("#2081FE"),
+		Normal:    lipgloss.Color("#EEEEEE"),
+		Error:     lipgloss.Color("#FF0000"),
+		Warning:   lipgloss.Color("#FFB000"),
+		Subtle:    lipgloss.AdaptiveColor{Light: "#D9DCCF", Dark: "#383838"},
+		Highlight: lipgloss.AdaptiveColor{Light: "#874BFD", Dark: "#7D56F4"},
+		Special:   lipgloss.AdaptiveColor{Light: "#43BF6D", Dark: "#73F59F"},
+		Muted:     lipgloss.Color("#5e5e5e"),
+	},
+	"dusk": {
+		Primary:   lipgloss.Color("#F25D94"),
+		Normal:    lipgloss.Color("#FFF7DB"),
+		Error:     lipgloss.Color("#FF5F5F"),
+		Warning:   lipgloss.Color("#FFD787"),
+		Subtle:    lipgloss.AdaptiveColor{Light: "#E3D7E8", Dark: "#3C3046"},
+		Highlight: lipgloss.AdaptiveColor{Light: "#C74DED", Dark: "#EDFF82"},
+		Special:   lipgloss.AdaptiveColor{Light: "#00A29C", Dark: "#6EEFC0"},
+		Muted:     lipgloss.Color("#7A6A83"),
+	},
+	"mono": {
+		Primary:   lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		Normal:    lipgloss.AdaptiveColor{Light: "#1A1A1A", Dark: "#EEEEEE"},
+		Error:     lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		Warning:   lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		Subtle:    lipgloss.AdaptiveColor{Light: "#D0D0D0", Dark: "#3A3A3A"},
+		Highlight: lipgloss.AdaptiveColor{Light: "#444444", Dark: "#BBBBBB"},
+		Special:   lipgloss.AdaptiveColor{Light: "#222222", Dark: "#DDDDDD"},
+		Muted:     lipgloss.Color("#808080"),
+	},
+}
+
 // Style definitions.
 // initially sourced from https://github.com/charmbracelet/lipgloss/blob/master/examples/layout/main.go
+// they are rebuilt from the active theme by Apply, so never cache them in a model.
 var (
 
 	// General.
 
-	Normal    = lipgloss.Color("#EEEEEE")
-	Error     = lipgloss.Color("#FF0000")
-	Subtle    = lipgloss.AdaptiveColor{Light: "#D9DCCF", Dark: "#383838"}
-	Highlight = lipgloss.AdaptiveColor{Light: "#874BFD", Dark: "#7D56F4"}
-	Special   = lipgloss.AdaptiveColor{Light: "#43BF6D", Dark: "#73F59F"}
+	Primary   lipgloss.TerminalColor
+	Normal    lipgloss.TerminalColor
+	Error     lipgloss.TerminalColor
+	Warning   lipgloss.TerminalColor
+	Subtle    lipgloss.TerminalColor
+	Highlight lipgloss.TerminalColor
+	Special   lipgloss.TerminalColor
+	Muted     lipgloss.TerminalColor
 	Blends    = gamut.Blends(lipgloss.Color("#F25D94"), lipgloss.Color("#EDFF82"), 50)
 
-	Base = lipgloss.NewStyle().Foreground(Normal)
+	Base lipgloss.Style
 
-	Divider = lipgloss.NewStyle().
-		SetString("•").
-		Padding(0, 1).
-		Foreground(Subtle).
-		String()
+	Divider string
 
-	URL = lipgloss.NewStyle().Foreground(Special).Render
+	URL func(strs ...string) string
 
 	// Tabs.
 
@@ -52,17 +101,30 @@ var (
 		BottomRight: "┴",
 	}
 
-	Tab = lipgloss.NewStyle().
-		Border(TabBorder, true).
-		BorderForeground(Highlight).
-		Padding(0, 1)
+	Tab lipgloss.Style
 
-	ActiveTab = Tab.Border(ActiveTabBorder, true)
+	ActiveTab lipgloss.Style
 
-	TabGap = Tab.
-		BorderTop(false).
-		BorderLeft(false).
-		BorderRight(false)
+	TabGap lipgloss.Style
+
+	// HoverTab is a tab under the mouse.
+	HoverTab lipgloss.Style
+
+	// Hover is a link under the mouse.
+	Hover lipgloss.Style
+
+	// Focus and HoverItem mark the item that was clicked, and the one under the mouse, in a list.
+	Focus     lipgloss.Style
+	HoverItem lipgloss.Style
+
+	// Toasts, by severity. Errors are bold as well as colored so they stand out in the mono theme.
+	ToastInfo    lipgloss.Style
+	ToastSuccess lipgloss.Style
+	ToastWarning lipgloss.Style
+	ToastError   lipgloss.Style
+
+	// Badge is a count that wants attention, like the unread notifications in the tab bar.
+	Badge lipgloss.Style
 
 	// Title.
 
@@ -77,4 +139,94 @@ var (
 	// Page.
 
 	DocStyle = lipgloss.NewStyle().Padding(1, 2, 1, 2)
+
+	current string
 )
+
+func init() {
+	Apply("default")
+}
+
+// Check reports whether the named theme exists, without applying it.
+func Check(name string) error {
+	if _, ok := Themes[name]; !ok {
+		return fmt.Errorf("unknown theme %q, available themes are %v", name, ThemeNames())
+	}
+	return nil
+}
+
+// Apply switches every style to the named theme.
+// If the theme does not exist the current styles are left untouched.
+func Apply(name string) error {
+	if err := Check(name); err != nil {
+		return err
+	}
+	t := Themes[name]
+	current = name
+
+	Primary = t.Primary
+	Normal = t.Normal
+	Error = t.Error
+	Warning = t.Warning
+	Subtle = t.Subtle
+	Highlight = t.Highlight
+	Special = t.Special
+	Muted = t.Muted
+
+	Base = lipgloss.NewStyle().Foreground(Normal)
+
+	Divider = lipgloss.NewStyle().
+		SetString("•").
+		Padding(0, 1).
+		Foreground(Subtle).
+		String()
+
+	URL = lipgloss.NewStyle().Foreground(Special).Render
+
+	Tab = lipgloss.NewStyle().
+		Border(TabBorder, true).
+		BorderForeground(Highlight).
+		Padding(0, 1)
+
+	ActiveTab = Tab.Border(ActiveTabBorder, true)
+
+	TabGap = Tab.
+		BorderTop(false).
+		BorderLeft(false).
+		BorderRight(false)
+
+	HoverTab = Tab.Foreground(Primary)
+
+	Hover = lipgloss.NewStyle().Foreground(Primary).Underline(true)
+
+	Focus = lipgloss.NewStyle().Foreground(Primary)
+
+	HoverItem = lipgloss.NewStyle().Foreground(Muted)
+
+	ToastInfo = lipgloss.NewStyle().Foreground(Normal)
+
+	ToastSuccess = lipgloss.NewStyle().Foreground(Special)
+
+	ToastWarning = lipgloss.NewStyle().Foreground(Warning)
+
+	ToastError = lipgloss.NewStyle().Foreground(Error).Bold(true)
+
+	Badge = lipgloss.NewStyle().Foreground(Error).Bold(true)
+
+	return nil
+}
+
+// Current returns the name of the active theme.
+func Current() string {
+	return current
+}
+
+// ThemeNames returns the sorted names of all available themes.
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,399 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/richtext"
+	"github.com/haukened/tsky/internal/tui/loader"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+const (
+	// MAX_LANGS is how many languages a post can be tagged with.
+	MAX_LANGS = 3
+	// COMPOSE_WARN is how many graphemes from the limit the counter starts to warn.
+	COMPOSE_WARN = 20
+	// SUBJECT_LINES is how much of the post being replied to or quoted is shown.
+	SUBJECT_LINES = 8
+)
+
+// langPattern is a language tag like en or pt-BR, which is what the langs of a post hold.
+var langPattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{1,8})*$`)
+
+// nextCompose tells the results of publishing apart when more than one composer is open.
+var nextCompose atomic.Int64
+
+// postedMsg is the outcome of publishing a post. unresolved are the mentions that were posted as plain text.
+type postedMsg struct {
+	id         int64
+	ref        messages.StrongRef
+	record     messages.PostRecord
+	unresolved []string
+	err        error
+}
+
+// ComposeView writes a new post, a reply, or a quote of another post. It takes every key while it is open:
+// ctrl+s publishes, tab switches between the text and the languages, and esc discards the post.
+type ComposeView struct {
+	id      int64
+	client  *client.Client
+	reply   string
+	quote   string
+	subject loader.Resource[messages.PostView]
+	text    textarea.Model
+	langs   textinput.Model
+	posting bool
+	discard bool
+	done    bool
+	w       int
+	h       int
+}
+
+// NewComposeView writes a reply to the post at reply, a quote of the post at quote, or a new post if both are empty.
+func NewComposeView(reply, quote string, c *client.Client) ComposeView {
+	text := textarea.New()
+	text.ShowLineNumbers = false
+	text.Prompt = ""
+	text.CharLimit = 0
+	text.Placeholder = "What's up?"
+	text.Focus()
+	langs := textinput.New()
+	langs.Prompt = ""
+	langs.Placeholder = "en"
+	langs.SetValue(strings.Join(defaultLangs(), ", "))
+	v := ComposeView{
+		id:     nextCompose.Add(1),
+		client: c,
+		reply:  reply,
+		quote:  quote,
+		text:   text,
+		langs:  langs,
+	}
+	if uri := v.subjectURI(); uri != "" {
+		v.subject = loader.New("post", func() (messages.PostView, error) {
+			posts, err := c.GetPosts(uri)
+			if err != nil {
+				return messages.PostView{}, err
+			}
+			if len(posts) == 0 {
+				//lint:ignore ST1005 shown in the status bar
+				return messages.PostView{}, errors.New("Post not found, it may have been deleted")
+			}
+			return posts[0], nil
+		})
+	}
+	return v
+}
+
+func (v ComposeView) Name() string {
+	switch {
+	case v.reply != "":
+		return "Reply"
+	case v.quote != "":
+		return "Quote"
+	}
+	return "New post"
+}
+
+func (v ComposeView) Init() tea.Cmd {
+	if v.subjectURI() != "" {
+		return tea.Batch(textarea.Blink, v.subject.Init())
+	}
+	return textarea.Blink
+}
+
+// CapturesInput is true until the post is published or discarded, the composer is for typing.
+func (v ComposeView) CapturesInput() bool {
+	return !v.done
+}
+
+// Closed reports whether the post was published or discarded.
+func (v ComposeView) Closed() bool {
+	return v.done
+}
+
+func (v ComposeView) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.w, v.h = msg.Width, msg.Height
+		return v.resize(), nil
+	case tea.KeyMsg:
+		return v.key(msg)
+	case postedMsg:
+		if msg.id != v.id {
+			return v, nil
+		}
+		v.posting = false
+		if msg.err != nil {
+			logger.Warn("unable to publish post", "err", msg.err)
+			return v, messages.SendErrorMsg(fmt.Sprintf("Unable to post: %s", msg.err))
+		}
+		logger.Info("published post", "uri", msg.ref.URI)
+		v.done = true
+		cmds := []tea.Cmd{
+			messages.SendSuccessMsg("Posted"),
+			func() tea.Msg { return messages.PostedMsg{Ref: msg.ref, Record: msg.record} },
+		}
+		if len(msg.unresolved) > 0 {
+			cmds = append(cmds, messages.SendWarningMsg(fmt.Sprintf("%s could not be found, posted as plain text",
+				strings.Join(msg.unresolved, ", "))))
+		}
+		return v, tea.Batch(cmds...)
+	}
+	v.subject, cmd = v.subject.Update(msg)
+	if v.subject.HasData() {
+		v = v.resize()
+	}
+	var input tea.Cmd
+	if v.text.Focused() {
+		v.text, input = v.text.Update(msg)
+	} else {
+		v.langs, input = v.langs.Update(msg)
+	}
+	return v, tea.Batch(cmd, input)
+}
+
+// key edits the post, except for the keys that publish, discard and switch fields.
+func (v ComposeView) key(msg tea.KeyMsg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	discard := v.discard
+	v.discard = false
+	switch msg.String() {
+	case "esc":
+		if discard || strings.TrimSpace(v.text.Value()) == "" {
+			v.done = true
+			return v, nil
+		}
+		v.discard = true
+		return v, messages.SendWarningMsg("Press esc again to discard the post")
+	case "ctrl+s":
+		return v.publish()
+	case "tab", "shift+tab":
+		if v.text.Focused() {
+			v.text.Blur()
+			return v, v.langs.Focus()
+		}
+		v.langs.Blur()
+		return v, v.text.Focus()
+	}
+	if v.posting {
+		// the post is on its way, it can not change now
+		return v, nil
+	}
+	if v.text.Focused() {
+		v.text, cmd = v.text.Update(msg)
+	} else {
+		v.langs, cmd = v.langs.Update(msg)
+	}
+	return v, cmd
+}
+
+// publish checks the post and creates its record. Mentions are resolved to DIDs as it is published,
+// along with the rest of the facets.
+func (v ComposeView) publish() (NamedModel, tea.Cmd) {
+	if v.posting || v.done {
+		return v, nil
+	}
+	text := strings.TrimSpace(v.text.Value())
+	if n := richtext.Length(text); n > richtext.MAX_GRAPHEMES {
+		over := n - richtext.MAX_GRAPHEMES
+		return v, messages.SendErrorMsg(fmt.Sprintf("The post is %d %s too long", over, plural(over, "character", "characters")))
+	}
+	if text == "" && v.quote == "" {
+		return v, messages.SendWarningMsg("Nothing to post")
+	}
+	langs, err := parseLangs(v.langs.Value())
+	if err != nil {
+		return v, messages.SendErrorMsg(err.Error())
+	}
+	post := messages.PostRecord{Text: text, Langs: langs}
+	if v.subjectURI() != "" {
+		if !v.subject.HasData() {
+			return v, messages.SendWarningMsg("The post is still loading")
+		}
+		subject := v.subject.Data()
+		ref := messages.StrongRef{URI: subject.URI, CID: subject.CID}
+		viewer := messages.PostViewerState{}
+		if subject.Viewer != nil {
+			viewer = *subject.Viewer
+		}
+		switch {
+		case v.reply != "" && viewer.ReplyDisabled:
+			return v, messages.SendErrorMsg("Replies to this post are limited, you can not reply")
+		case v.reply != "":
+			root := ref
+			if subject.Record.Reply != nil {
+				root = subject.Record.Reply.Root
+			}
+			post.Reply = &messages.ReplyRef{Root: root, Parent: ref}
+		case viewer.EmbeddingDisabled:
+			return v, messages.SendErrorMsg("The author of this post has disabled quoting it")
+		default:
+			post.Embed = &messages.RecordEmbed{
+				Type:   messages.EMBED_RECORD,
+				Record: &messages.EmbedRecordRef{URI: ref.URI, CID: ref.CID},
+			}
+		}
+	}
+	v.posting = true
+	id, c := v.id, v.client
+	return v, func() tea.Msg {
+		var unresolved []string
+		resolve := richtext.CachedResolver(func(handle string) (string, error) {
+			did, err := c.ResolveHandle(handle)
+			if err != nil {
+				unresolved = append(unresolved, "@"+handle)
+			}
+			return did, err
+		})
+		post.Facets = richtext.Facets(post.Text, resolve)
+		ref, err := c.CreatePost(post)
+		return postedMsg{id: id, ref: ref, record: post, unresolved: unresolved, err: err}
+	}
+}
+
+// subjectURI is the post being replied to or quoted, if there is one.
+func (v ComposeView) subjectURI() string {
+	if v.reply != "" {
+		return v.reply
+	}
+	return v.quote
+}
+
+// resize gives the text whatever height is left around the post being replied to or quoted.
+func (v ComposeView) resize() ComposeView {
+	v.text.SetWidth(max(1, v.w))
+	// the input draws its cursor one past its width
+	v.langs.Width = max(1, v.w-lipgloss.Width(v.langsLabel())-2)
+	rest := lipgloss.Height(v.header()) + 2
+	if subject := v.subjectView(); subject != "" {
+		rest += lipgloss.Height(subject)
+	}
+	v.text.SetHeight(max(3, v.h-rest))
+	return v
+}
+
+// header is the title, with the post being replied to under it.
+func (v ComposeView) header() string {
+	title := lipgloss.NewStyle().Bold(true).Render(v.Name())
+	if v.subject.HasData() {
+		title += lipgloss.NewStyle().Foreground(styles.Muted).Render(" · @" + v.subject.Data().Author.Handle)
+	}
+	return title
+}
+
+// subjectView is the post being replied to or quoted, cut short.
+func (v ComposeView) subjectView() string {
+	if v.subjectURI() == "" {
+		return ""
+	}
+	body := v.subject.View(func(post messages.PostView) string {
+		lines := strings.Split(renderPostView(post, max(1, v.w-2)), "\n")
+		if len(lines) > SUBJECT_LINES {
+			lines = append(lines[:SUBJECT_LINES-1], lipgloss.NewStyle().Foreground(styles.Muted).Render("…"))
+		}
+		return strings.Join(lines, "\n")
+	})
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(styles.Muted).
+		PaddingLeft(1).
+		Render(body)
+}
+
+func (v ComposeView) langsLabel() string {
+	return lipgloss.NewStyle().Foreground(styles.Muted).Render("Languages:")
+}
+
+// status is the grapheme counter, what was detected in the text, and the keys that publish and discard.
+func (v ComposeView) status() string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	text := strings.TrimSpace(v.text.Value())
+	n := richtext.Length(text)
+	counter := muted
+	switch {
+	case n > richtext.MAX_GRAPHEMES:
+		counter = lipgloss.NewStyle().Foreground(styles.Error).Bold(true)
+	case n > richtext.MAX_GRAPHEMES-COMPOSE_WARN:
+		counter = lipgloss.NewStyle().Foreground(styles.Warning)
+	}
+	parts := []string{counter.Render(fmt.Sprintf("%d/%d", n, richtext.MAX_GRAPHEMES))}
+	found := map[string]int{}
+	for _, e := range richtext.Detect(text) {
+		found[e.Type]++
+	}
+	for _, kind := range []struct{ entity, one, many string }{
+		{richtext.ENTITY_MENTION, "mention", "mentions"},
+		{richtext.ENTITY_LINK, "link", "links"},
+		{richtext.ENTITY_TAG, "tag", "tags"},
+	} {
+		if n := found[kind.entity]; n > 0 {
+			parts = append(parts, muted.Render(fmt.Sprintf("%d %s", n, plural(n, kind.one, kind.many))))
+		}
+	}
+	hint := "ctrl+s post · tab languages · esc discard"
+	if v.posting {
+		hint = loader.Spinner() + " Posting…"
+	}
+	left := strings.Join(parts, muted.Render(" · "))
+	gap := max(1, v.w-lipgloss.Width(left)-lipgloss.Width(hint))
+	return left + strings.Repeat(" ", gap) + muted.Render(hint)
+}
+
+func (v ComposeView) View() string {
+	sections := []string{v.header()}
+	subject := v.subjectView()
+	if v.reply != "" {
+		sections = append(sections, subject)
+	}
+	sections = append(sections, v.text.View())
+	if v.quote != "" {
+		sections = append(sections, subject)
+	}
+	sections = append(sections, v.langsLabel()+" "+v.langs.View(), v.status())
+	return strings.Join(sections, "\n")
+}
+
+// parseLangs reads a comma or space separated list of language tags.
+func parseLangs(s string) ([]string, error) {
+	langs := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(langs) > MAX_LANGS {
+		//lint:ignore ST1005 shown in the status bar
+		return nil, fmt.Errorf("A post can have at most %d languages", MAX_LANGS)
+	}
+	for _, lang := range langs {
+		if !langPattern.MatchString(lang) {
+			return nil, fmt.Errorf("%q is not a language tag, try something like en or pt-BR", lang)
+		}
+	}
+	return langs, nil
+}
+
+// defaultLangs is the language of the locale, e.g. en for en_US.UTF-8.
+func defaultLangs() []string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(env)
+		if locale == "" {
+			continue
+		}
+		lang, _, _ := strings.Cut(locale, "_")
+		lang, _, _ = strings.Cut(lang, ".")
+		if langPattern.MatchString(lang) {
+			return []string{strings.ToLower(lang)}
+		}
+		return nil
+	}
+	return nil
+}
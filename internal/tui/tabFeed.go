@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/loader"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+// FEED_HEADER is the rows above the posts of a custom feed: its name and its description.
+const FEED_HEADER = 2
+
+// FeedTab is a custom feed, the posts a feed generator picks, under the name and description of the feed.
+type FeedTab struct {
+	uri       string
+	generator loader.Resource[messages.GeneratorView]
+	feed      FeedList
+	w         int
+	h         int
+}
+
+func NewFeedTab(uri string, c *client.Client) FeedTab {
+	return FeedTab{
+		uri: uri,
+		generator: loader.New("feed", func() (messages.GeneratorView, error) {
+			uri, err := didURI(c, uri)
+			if err != nil {
+				return messages.GeneratorView{}, err
+			}
+			feeds, err := c.GetFeedGenerators(uri)
+			if err == nil && len(feeds) == 0 {
+				//lint:ignore ST1005 shown in the status bar
+				err = errors.New("Feed not found")
+			}
+			if err != nil {
+				return messages.GeneratorView{}, err
+			}
+			return feeds[0], nil
+		}),
+		feed: NewFeedList("feed", c, func(cursor string) (messages.FeedMessage, error) {
+			uri, err := didURI(c, uri)
+			if err != nil {
+				return messages.FeedMessage{}, err
+			}
+			return c.GetFeed(uri, FEED_PAGE_SIZE, cursor)
+		}),
+	}
+}
+
+// didURI swaps the handle in an at:// URI, as bsky.app links have, for the DID it points at.
+func didURI(c *client.Client, uri string) (string, error) {
+	authority, rest, _ := strings.Cut(strings.TrimPrefix(uri, "at://"), "/")
+	if strings.HasPrefix(authority, "did:") {
+		return uri, nil
+	}
+	did, err := c.ResolveHandle(authority)
+	if err != nil {
+		return "", err
+	}
+	return "at://" + did + "/" + rest, nil
+}
+
+func (f FeedTab) Name() string {
+	if f.generator.HasData() {
+		return f.generator.Data().DisplayName
+	}
+	return "Feed"
+}
+
+func (f FeedTab) Init() tea.Cmd {
+	return tea.Batch(f.generator.Init(), f.feed.Init())
+}
+
+func (f FeedTab) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd, feed tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.w, f.h = msg.Width, msg.Height
+		f.feed = f.feed.Resize(f.w, max(1, f.h-FEED_HEADER))
+		return f, nil
+	case keymap.ActionMsg:
+		if msg.Action == keymap.RELOAD && f.generator.State() == loader.Failed {
+			f.generator, cmd = f.generator.Reload()
+		}
+	case tea.MouseMsg:
+		if msg.Y < FEED_HEADER {
+			return f, nil
+		}
+		msg.Y -= FEED_HEADER
+		f.feed, cmd = f.feed.Update(msg)
+		return f, cmd
+	default:
+		f.generator, cmd = f.generator.Update(msg)
+	}
+	f.feed, feed = f.feed.Update(msg)
+	return f, tea.Batch(cmd, feed)
+}
+
+// header is the name of the feed, who made it and its likes, over the first line of its description.
+func (f FeedTab) header() []string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	lines := strings.Split(f.generator.View(func(g messages.GeneratorView) string {
+		title := lipgloss.NewStyle().Bold(true).Render(g.DisplayName) + " " +
+			muted.Render(fmt.Sprintf("by @%s · ♥ %d", g.Creator.Handle, g.LikeCount))
+		return title + "\n" + muted.Render(strings.Join(strings.Fields(g.Description), " "))
+	}), "\n")
+	for len(lines) < FEED_HEADER {
+		lines = append(lines, "")
+	}
+	lines = lines[:FEED_HEADER]
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, f.w, "…")
+	}
+	return lines
+}
+
+func (f FeedTab) View() string {
+	return strings.Join(f.header(), "\n") + "\n" + f.feed.View()
+}
+
+// Link returns the bsky.app link of the focused post, or of the feed if no post is focused.
+func (f FeedTab) Link() string {
+	if item, ok := f.feed.Focused(); ok {
+		return postURL(item.Post.URI, item.Post.Author.Handle)
+	}
+	if f.generator.HasData() {
+		return feedURL(f.uri, f.generator.Data().Creator.Handle)
+	}
+	return ""
+}
+
+// KeyHelp lists the feed and scrolling bindings.
+func (f FeedTab) KeyHelp() []HelpGroup {
+	return []HelpGroup{
+		{Title: "Feed", Bindings: keymap.Bindings(keymap.SELECT, keymap.LIKE, keymap.REPOST, keymap.REPLY, keymap.QUOTE, keymap.RELOAD)},
+		{Title: keymap.GROUP_SCROLL, Bindings: keymap.Group(keymap.GROUP_SCROLL)},
+		{Title: keymap.GROUP_SEARCH, Bindings: keymap.Group(keymap.GROUP_SEARCH)},
+	}
+}
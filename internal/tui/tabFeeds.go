@@ -0,0 +1,426 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+// The sections of the feed manager.
+const (
+	FEEDS_SAVED = iota
+	FEEDS_SUGGESTED
+)
+
+// feedsSections are the names of the sections, in order.
+var feedsSections = []string{"Saved", "Suggested"}
+
+// FEEDS_HEADER is the rows above the feeds: the sections and a blank line.
+const FEEDS_HEADER = 2
+
+// savedRow is a feed in the manager. saved is empty if the feed is not saved, feed is empty if it is
+// not a custom feed, and list if it is not a list, or if either could not be found.
+type savedRow struct {
+	saved messages.SavedFeed
+	feed  messages.GeneratorView
+	list  messages.ListView
+}
+
+// FeedsTab manages the saved feeds. Feeds are previewed, saved, pinned as tabs and put in order here,
+// and every change is written back to the account's preferences.
+type FeedsTab struct {
+	client    *client.Client
+	section   int
+	saved     ResultList[savedRow]
+	suggested ResultList[savedRow]
+	started   []bool
+	w         int
+	h         int
+}
+
+func NewFeedsTab(c *client.Client) FeedsTab {
+	return FeedsTab{
+		client: c,
+		saved: NewResultList("saved feeds", "No saved feeds", func(string) (ResultPage[savedRow], error) {
+			prefs, err := c.GetPreferences()
+			if err != nil {
+				return ResultPage[savedRow]{}, err
+			}
+			items := prefs.SavedFeeds()
+			var uris []string
+			for _, item := range items {
+				if item.Type == messages.SAVED_FEED_FEED {
+					uris = append(uris, item.Value)
+				}
+			}
+			feeds := map[string]messages.GeneratorView{}
+			if len(uris) > 0 {
+				views, err := c.GetFeedGenerators(uris...)
+				if err != nil {
+					return ResultPage[savedRow]{}, err
+				}
+				for _, view := range views {
+					feeds[view.URI] = view
+				}
+			}
+			rows := make([]savedRow, len(items))
+			for i, item := range items {
+				rows[i] = savedRow{saved: item, feed: feeds[item.Value]}
+				if item.Type == messages.SAVED_FEED_LIST {
+					// there is no way to get several lists at once, and few are ever saved
+					page, err := c.GetList(item.Value, 1, "")
+					if err != nil {
+						logger.Warn("unable to load saved list", "list", item.Value, "err", err)
+					}
+					rows[i].list = page.List
+				}
+			}
+			return ResultPage[savedRow]{Items: rows}, nil
+		}, renderSavedRow),
+		suggested: NewResultList("suggested feeds", "No feeds to suggest", func(cursor string) (ResultPage[savedRow], error) {
+			page, err := c.GetSuggestedFeeds(FEED_PAGE_SIZE, cursor)
+			rows := make([]savedRow, len(page.Feeds))
+			for i, feed := range page.Feeds {
+				rows[i] = savedRow{feed: feed}
+			}
+			return ResultPage[savedRow]{Items: rows, Cursor: page.Cursor}, err
+		}, renderSavedRow),
+		started: []bool{true, false},
+	}
+}
+
+func (f FeedsTab) Name() string {
+	return "Feeds"
+}
+
+func (f FeedsTab) Init() tea.Cmd {
+	return f.saved.Init()
+}
+
+func (f FeedsTab) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd, suggested tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.w, f.h = msg.Width, msg.Height
+		h := max(1, f.h-FEEDS_HEADER)
+		f.saved = f.saved.Resize(f.w, h)
+		f.suggested = f.suggested.Resize(f.w, h)
+		return f, nil
+	case messages.SavedFeedsMsg:
+		return f.apply(msg.Items), nil
+	case messages.ListChangedMsg:
+		if msg.List != nil {
+			f.saved = f.saved.Map(func(row savedRow) savedRow {
+				if row.list.URI == msg.URI {
+					row.list = *msg.List
+				}
+				return row
+			})
+		}
+		return f, nil
+	case keymap.ActionMsg:
+		return f.action(msg)
+	case tea.MouseMsg:
+		return f.mouse(msg)
+	case messages.RefreshMsg, SearchMsg:
+		return f.updateCurrent(msg)
+	}
+	f.saved, cmd = f.saved.Update(msg)
+	f.suggested, suggested = f.suggested.Update(msg)
+	return f.mark(), tea.Batch(cmd, suggested)
+}
+
+func (f FeedsTab) action(msg keymap.ActionMsg) (NamedModel, tea.Cmd) {
+	switch msg.Action {
+	case keymap.FILTER:
+		return f.show((f.section + 1) % len(feedsSections))
+	case keymap.SELECT:
+		if row, ok := f.focused(); ok {
+			return f, previewFeed(row)
+		}
+		return f, nil
+	case keymap.SAVE:
+		return f.toggleSaved()
+	case keymap.PIN:
+		return f.togglePinned()
+	case keymap.MOVE_UP:
+		return f.moveFocused(-msg.Times())
+	case keymap.MOVE_DOWN:
+		return f.moveFocused(msg.Times())
+	}
+	return f.updateCurrent(msg)
+}
+
+// show switches to section i, fetching its feeds the first time it is shown.
+func (f FeedsTab) show(i int) (NamedModel, tea.Cmd) {
+	f.section = i
+	if f.started[i] {
+		return f, nil
+	}
+	f.started = slices.Clone(f.started)
+	f.started[i] = true
+	return f, f.suggested.Init()
+}
+
+// focused returns the feed that is focused in the section on screen.
+func (f FeedsTab) focused() (savedRow, bool) {
+	if f.section == FEEDS_SAVED {
+		return f.saved.Focused()
+	}
+	return f.suggested.Focused()
+}
+
+// items returns the saved feeds as they are now.
+func (f FeedsTab) items() []messages.SavedFeed {
+	rows := f.saved.Items()
+	items := make([]messages.SavedFeed, len(rows))
+	for i, row := range rows {
+		items[i] = row.saved
+	}
+	return items
+}
+
+// toggleSaved saves the focused feed, or removes it if it is saved.
+func (f FeedsTab) toggleSaved() (NamedModel, tea.Cmd) {
+	row, ok := f.focused()
+	if !ok {
+		return f, nil
+	}
+	if !f.saved.Loaded() {
+		return f, messages.SendStatusMsg("Still loading your saved feeds…")
+	}
+	items := f.items()
+	if i := f.savedIndex(row); i >= 0 {
+		if items[i].Type == messages.SAVED_FEED_TIMELINE {
+			return f, messages.SendWarningMsg("The Following feed can not be removed, only unpinned")
+		}
+		return f.change(slices.Delete(slices.Clone(items), i, i+1))
+	}
+	return f.change(append(items, messages.NewSavedFeed(messages.SAVED_FEED_FEED, row.feed.URI, false)))
+}
+
+// togglePinned pins the focused feed as a tab, saving it first if it is not saved, or unpins it.
+func (f FeedsTab) togglePinned() (NamedModel, tea.Cmd) {
+	row, ok := f.focused()
+	if !ok {
+		return f, nil
+	}
+	if !f.saved.Loaded() {
+		return f, messages.SendStatusMsg("Still loading your saved feeds…")
+	}
+	items := slices.Clone(f.items())
+	if i := f.savedIndex(row); i >= 0 {
+		items[i].Pinned = !items[i].Pinned
+		return f.change(items)
+	}
+	return f.change(append(items, messages.NewSavedFeed(messages.SAVED_FEED_FEED, row.feed.URI, true)))
+}
+
+// moveFocused moves the focused saved feed n places, pinned feeds are shown as tabs in this order.
+func (f FeedsTab) moveFocused(n int) (NamedModel, tea.Cmd) {
+	if f.section != FEEDS_SAVED {
+		return f, nil
+	}
+	row, ok := f.saved.Focused()
+	if !ok {
+		return f, nil
+	}
+	items := slices.Clone(f.items())
+	i := f.savedIndex(row)
+	to := min(max(i+n, 0), len(items)-1)
+	if i < 0 || to == i {
+		return f, nil
+	}
+	item := items[i]
+	items = slices.Insert(slices.Delete(items, i, i+1), to, item)
+	model, cmd := f.change(items)
+	f = model.(FeedsTab)
+	f.saved = f.saved.Focus(to)
+	return f, cmd
+}
+
+// savedIndex returns where row is in the saved feeds, or -1 if it is not saved.
+func (f FeedsTab) savedIndex(row savedRow) int {
+	return slices.IndexFunc(f.items(), func(item messages.SavedFeed) bool {
+		if row.saved.ID != "" {
+			return item.ID == row.saved.ID
+		}
+		return item.Type == messages.SAVED_FEED_FEED && item.Value == row.feed.URI
+	})
+}
+
+// change shows items as the saved feeds straight away, and asks for them to be written to the preferences.
+// Every view is told, so the pinned tabs follow, and told again if they could not be saved.
+func (f FeedsTab) change(items []messages.SavedFeed) (NamedModel, tea.Cmd) {
+	f = f.apply(items)
+	return f, func() tea.Msg {
+		return messages.SavedFeedsMsg{Items: items, Save: true}
+	}
+}
+
+// apply shows items as the saved feeds, describing them with the custom feeds already loaded.
+func (f FeedsTab) apply(items []messages.SavedFeed) FeedsTab {
+	feeds := map[string]messages.GeneratorView{}
+	for _, row := range append(slices.Clone(f.saved.Items()), f.suggested.Items()...) {
+		if row.feed.URI != "" {
+			feeds[row.feed.URI] = row.feed
+		}
+	}
+	lists := map[string]messages.ListView{}
+	for _, row := range f.saved.Items() {
+		if row.list.URI != "" {
+			lists[row.list.URI] = row.list
+		}
+	}
+	rows := make([]savedRow, len(items))
+	for i, item := range items {
+		rows[i] = savedRow{saved: item, feed: feeds[item.Value], list: lists[item.Value]}
+	}
+	f.saved = f.saved.Set(rows)
+	return f.mark()
+}
+
+// mark tells the suggested feeds which of them are saved, as they are loaded and whenever that changes.
+func (f FeedsTab) mark() FeedsTab {
+	saved := map[string]messages.SavedFeed{}
+	for _, item := range f.items() {
+		saved[item.Value] = item
+	}
+	stale := slices.ContainsFunc(f.suggested.Items(), func(row savedRow) bool {
+		return row.saved != saved[row.feed.URI]
+	})
+	if !stale {
+		return f
+	}
+	f.suggested = f.suggested.Map(func(row savedRow) savedRow {
+		row.saved = saved[row.feed.URI]
+		return row
+	})
+	return f
+}
+
+// mouse switches sections for clicks above the feeds, everything else goes to them.
+func (f FeedsTab) mouse(msg tea.MouseMsg) (NamedModel, tea.Cmd) {
+	if msg.Y >= FEEDS_HEADER {
+		msg.Y -= FEEDS_HEADER
+		return f.updateCurrent(msg)
+	}
+	if msg.Y != 0 || msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return f, nil
+	}
+	left := 0
+	for i := range feedsSections {
+		right := left + lipgloss.Width(f.sectionLabel(i)) + 1
+		if msg.X >= left && msg.X < right {
+			return f.show(i)
+		}
+		left = right
+	}
+	return f, nil
+}
+
+// updateCurrent passes msg to the feeds of the section on screen.
+func (f FeedsTab) updateCurrent(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	if f.section == FEEDS_SAVED {
+		f.saved, cmd = f.saved.Update(msg)
+	} else {
+		f.suggested, cmd = f.suggested.Update(msg)
+	}
+	return f.mark(), cmd
+}
+
+// Link returns the bsky.app link of the focused feed.
+func (f FeedsTab) Link() string {
+	row, ok := f.focused()
+	switch {
+	case ok && row.feed.URI != "":
+		return feedURL(row.feed.URI, row.feed.Creator.Handle)
+	case ok && row.list.URI != "":
+		return listURL(row.list.URI, row.list.Creator.Handle)
+	}
+	return ""
+}
+
+// KeyHelp lists the feed managing and scrolling bindings.
+func (f FeedsTab) KeyHelp() []HelpGroup {
+	return []HelpGroup{
+		{Title: "Feeds", Bindings: keymap.Bindings(keymap.SELECT, keymap.SAVE, keymap.PIN, keymap.MOVE_UP, keymap.MOVE_DOWN, keymap.FILTER, keymap.RELOAD)},
+		{Title: keymap.GROUP_SCROLL, Bindings: keymap.Group(keymap.GROUP_SCROLL)},
+		{Title: keymap.GROUP_SEARCH, Bindings: keymap.Group(keymap.GROUP_SEARCH)},
+	}
+}
+
+// sectionLabel is the tab of section i, highlighted if it is the one shown.
+func (f FeedsTab) sectionLabel(i int) string {
+	label := " " + feedsSections[i] + " "
+	if i == f.section {
+		return styles.Focus.Bold(true).Underline(true).Render(label)
+	}
+	return lipgloss.NewStyle().Foreground(styles.Muted).Render(label)
+}
+
+func (f FeedsTab) View() string {
+	tabs := make([]string, len(feedsSections))
+	for i := range feedsSections {
+		tabs[i] = f.sectionLabel(i)
+	}
+	header := ansi.Truncate(strings.Join(tabs, " "), f.w, "") + "\n\n"
+	if f.section == FEEDS_SAVED {
+		return header + f.saved.View()
+	}
+	return header + f.suggested.View()
+}
+
+// previewFeed opens the saved feed or custom feed of row.
+func previewFeed(row savedRow) tea.Cmd {
+	switch {
+	case row.saved.Type == messages.SAVED_FEED_TIMELINE:
+		return messages.Navigate(ROUTE_HOME, nil)
+	case row.saved.Type == messages.SAVED_FEED_LIST:
+		return messages.Navigate(ROUTE_LIST, messages.Params{"uri": row.saved.Value})
+	}
+	uri := row.feed.URI
+	if uri == "" {
+		uri = row.saved.Value
+	}
+	return messages.Navigate(ROUTE_FEED, messages.Params{"uri": uri})
+}
+
+// renderSavedRow draws a feed in the manager, with whether it is saved or pinned.
+func renderSavedRow(row savedRow, width int) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	var text string
+	switch {
+	case row.saved.Type == messages.SAVED_FEED_TIMELINE:
+		text = lipgloss.NewStyle().Bold(true).Render("Following") + " " + muted.Render("the posts of the accounts you follow")
+	case row.saved.Type == messages.SAVED_FEED_LIST && row.list.URI != "":
+		return renderList(row.list, width) + savedTag(row.saved)
+	case row.saved.Type == messages.SAVED_FEED_LIST:
+		text = lipgloss.NewStyle().Bold(true).Render("List") + " " + muted.Render(row.saved.Value)
+	case row.feed.URI == "":
+		text = lipgloss.NewStyle().Bold(true).Render(row.saved.Value) + " " + muted.Render("could not be found")
+	default:
+		return renderGenerator(row.feed, width) + savedTag(row.saved)
+	}
+	return closeLinks(Wrap(text, width)) + savedTag(row.saved)
+}
+
+// savedTag is the line under a feed that says whether it is saved or pinned.
+func savedTag(item messages.SavedFeed) string {
+	tag := lipgloss.NewStyle().Foreground(styles.Special)
+	switch {
+	case item.Pinned:
+		return "\n" + tag.Bold(true).Render("pinned")
+	case item.ID != "":
+		return "\n" + tag.Render("saved")
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+)
+
+// HomeTab is the home timeline, the posts of the accounts the logged in account follows.
+type HomeTab struct {
+	feed FeedList
+}
+
+func NewHomeTab(c *client.Client) HomeTab {
+	return HomeTab{
+		feed: NewFeedList("timeline", c, func(cursor string) (messages.FeedMessage, error) {
+			return c.GetTimeline(FEED_PAGE_SIZE, cursor)
+		}),
+	}
+}
+
+func (h HomeTab) Name() string {
+	return "Home"
+}
+
+func (h HomeTab) Init() tea.Cmd {
+	return h.feed.Init()
+}
+
+func (h HomeTab) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	if _, ok := msg.(messages.PostedMsg); ok {
+		// the new post belongs at the top of the timeline
+		msg = messages.RefreshMsg{}
+	}
+	h.feed, cmd = h.feed.Update(msg)
+	return h, cmd
+}
+
+func (h HomeTab) View() string {
+	return h.feed.View()
+}
+
+// Link returns the bsky.app link of the focused post.
+func (h HomeTab) Link() string {
+	item, ok := h.feed.Focused()
+	if !ok {
+		return ""
+	}
+	return postURL(item.Post.URI, item.Post.Author.Handle)
+}
+
+// KeyHelp lists the timeline and scrolling bindings.
+func (h HomeTab) KeyHelp() []HelpGroup {
+	return []HelpGroup{
+		{Title: "Home", Bindings: keymap.Bindings(keymap.SELECT, keymap.LIKE, keymap.REPOST, keymap.REPLY, keymap.QUOTE, keymap.RELOAD)},
+		{Title: keymap.GROUP_SCROLL, Bindings: keymap.Group(keymap.GROUP_SCROLL)},
+		{Title: keymap.GROUP_SEARCH, Bindings: keymap.Group(keymap.GROUP_SEARCH)},
+	}
+}
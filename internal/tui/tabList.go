@@ -0,0 +1,457 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/loader"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+// The sections of a list.
+const (
+	LIST_POSTS = iota
+	LIST_MEMBERS
+)
+
+// listSections are the names of the sections, in order.
+var listSections = []string{"Posts", "Members"}
+
+// LIST_HEADER is the rows above the posts or members: the name of the list, its description, the
+// sections, and the line a name or handle is typed on.
+const LIST_HEADER = 4
+
+// ListTab is a list of accounts, with the posts of its members and the members themselves in sections.
+// Lists of the logged in account can be renamed and deleted, and members added and removed.
+type ListTab struct {
+	uri     string
+	client  *client.Client
+	list    loader.Resource[messages.ListView]
+	loaded  time.Time
+	shown   messages.ListView
+	posts   FeedList
+	members ResultList[messages.ListItemView]
+	started []bool
+	section int
+	field   Field
+	// deleting is set once delete has been pressed, and closed once the list is gone
+	deleting bool
+	closed   bool
+	w        int
+	h        int
+}
+
+func NewListTab(uri string, c *client.Client) ListTab {
+	return ListTab{
+		uri:    uri,
+		client: c,
+		list: loader.New("list", func() (messages.ListView, error) {
+			uri, err := didURI(c, uri)
+			if err != nil {
+				return messages.ListView{}, err
+			}
+			page, err := c.GetList(uri, 1, "")
+			return page.List, err
+		}),
+		posts: NewFeedList("list posts", c, func(cursor string) (messages.FeedMessage, error) {
+			uri, err := didURI(c, uri)
+			if err != nil {
+				return messages.FeedMessage{}, err
+			}
+			return c.GetListFeed(uri, FEED_PAGE_SIZE, cursor)
+		}),
+		members: NewResultList("members", "No one is on this list yet", func(cursor string) (ResultPage[messages.ListItemView], error) {
+			uri, err := didURI(c, uri)
+			if err != nil {
+				return ResultPage[messages.ListItemView]{}, err
+			}
+			page, err := c.GetList(uri, FEED_PAGE_SIZE, cursor)
+			return ResultPage[messages.ListItemView]{Items: page.Items, Cursor: page.Cursor}, err
+		}, func(item messages.ListItemView, width int) string {
+			return renderActor(item.Subject, width)
+		}),
+		started: []bool{true, false},
+		field:   NewField(),
+	}
+}
+
+func (t ListTab) Name() string {
+	if t.shown.Name != "" {
+		return t.shown.Name
+	}
+	return "List"
+}
+
+func (t ListTab) Init() tea.Cmd {
+	return tea.Batch(t.list.Init(), t.posts.Init())
+}
+
+// CapturesInput reports whether a name or handle is being typed.
+func (t ListTab) CapturesInput() bool {
+	return t.field.Active()
+}
+
+// Closed reports whether the list has been deleted.
+func (t ListTab) Closed() bool {
+	return t.closed
+}
+
+func (t ListTab) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd, posts, members tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		t.w, t.h = msg.Width, msg.Height
+		h := max(1, t.h-LIST_HEADER)
+		t.posts = t.posts.Resize(t.w, h)
+		t.members = t.members.Resize(t.w, h)
+		t.field = t.field.Resize(t.w)
+		return t, nil
+	case tea.KeyMsg:
+		if t.field.Active() {
+			return t.typed(msg)
+		}
+	case messages.ListChangedMsg:
+		if !t.is(msg.URI) {
+			return t, nil
+		}
+		if msg.List == nil {
+			t.closed = true
+			return t, nil
+		}
+		t.shown = *msg.List
+		return t, nil
+	case messages.ListMemberMsg:
+		if !t.is(msg.List) {
+			return t, nil
+		}
+		return t.member(msg), nil
+	case keymap.ActionMsg:
+		return t.action(msg)
+	case tea.MouseMsg:
+		return t.mouse(msg)
+	case messages.RefreshMsg, SearchMsg:
+		return t.updateCurrent(msg)
+	}
+	t.list, cmd = t.list.Update(msg)
+	if t.list.HasData() && t.list.LoadedAt() != t.loaded {
+		t.loaded = t.list.LoadedAt()
+		t.shown = t.list.Data()
+	}
+	t.posts, posts = t.posts.Update(msg)
+	t.members, members = t.members.Update(msg)
+	return t, tea.Batch(cmd, posts, members)
+}
+
+// is reports whether uri is this list, as it was opened or as it was loaded.
+func (t ListTab) is(uri string) bool {
+	return uri == t.uri || (t.shown.URI != "" && uri == t.shown.URI)
+}
+
+// own reports whether the list was made by the logged in account, only those can be changed.
+func (t ListTab) own() bool {
+	return t.shown.URI != "" && t.shown.Creator.Did == t.client.Did()
+}
+
+// member adds or takes away a member that was added or removed in any view of the list.
+func (t ListTab) member(msg messages.ListMemberMsg) ListTab {
+	items := t.members.Items()
+	at := slices.IndexFunc(items, func(item messages.ListItemView) bool { return item.URI == msg.Item.URI })
+	switch {
+	case msg.Delta > 0 && at < 0:
+		t.members = t.members.Set(append([]messages.ListItemView{msg.Item}, items...))
+	case msg.Delta < 0 && at >= 0:
+		t.members = t.members.Remove(func(item messages.ListItemView) bool { return item.URI == msg.Item.URI })
+	default:
+		return t
+	}
+	t.shown.ListItemCount = max(0, t.shown.ListItemCount+msg.Delta)
+	return t
+}
+
+func (t ListTab) action(msg keymap.ActionMsg) (NamedModel, tea.Cmd) {
+	deleting := t.deleting
+	t.deleting = false
+	switch msg.Action {
+	case keymap.FILTER:
+		return t.show((t.section + 1) % len(listSections))
+	case keymap.RENAME, keymap.DELETE, keymap.ADD, keymap.REMOVE:
+		if !t.own() {
+			return t, messages.SendWarningMsg("Only your own lists can be changed")
+		}
+	}
+	var cmd tea.Cmd
+	switch msg.Action {
+	case keymap.RENAME:
+		t.field, cmd = t.field.Open("Rename: ", t.shown.Name, keymap.RENAME)
+		return t, cmd
+	case keymap.ADD:
+		t.field, cmd = t.field.Open("Add @", "", keymap.ADD)
+		return t, cmd
+	case keymap.DELETE:
+		if !deleting {
+			t.deleting = true
+			again := keymap.Get(keymap.DELETE).Help().Key
+			return t, messages.SendWarningMsg(fmt.Sprintf("Press %s again to delete %s", again, t.shown.Name))
+		}
+		return t, deleteList(t.client, t.shown)
+	case keymap.REMOVE:
+		if item, ok := t.members.Focused(); ok && t.section == LIST_MEMBERS {
+			return t, removeMember(t.client, t.shown.URI, item)
+		}
+		return t, nil
+	case keymap.SELECT:
+		if t.section == LIST_MEMBERS {
+			if item, ok := t.members.Focused(); ok {
+				return t, messages.Navigate(ROUTE_PROFILE, messages.Params{"actor": item.Subject.Did})
+			}
+			return t, nil
+		}
+	case keymap.RELOAD:
+		if t.list.State() == loader.Failed {
+			t.list, cmd = t.list.Reload()
+		}
+		model, current := t.updateCurrent(msg)
+		return model, tea.Batch(cmd, current)
+	}
+	return t.updateCurrent(msg)
+}
+
+// typed edits the name or handle being typed, and renames the list or adds the account once enter is pressed.
+func (t ListTab) typed(msg tea.KeyMsg) (NamedModel, tea.Cmd) {
+	var value string
+	var done bool
+	var cmd tea.Cmd
+	t.field, value, done, cmd = t.field.Update(msg)
+	if !done {
+		return t, cmd
+	}
+	if t.field.What() == keymap.RENAME {
+		return t, renameList(t.client, t.shown, value)
+	}
+	return t, addMember(t.client, t.shown.URI, value)
+}
+
+// show switches to section i, fetching it the first time it is shown.
+func (t ListTab) show(i int) (NamedModel, tea.Cmd) {
+	t.section = i
+	if t.started[i] {
+		return t, nil
+	}
+	t.started = slices.Clone(t.started)
+	t.started[i] = true
+	return t, t.members.Init()
+}
+
+// mouse switches sections for clicks on them, clicks under the header go to the section shown.
+func (t ListTab) mouse(msg tea.MouseMsg) (NamedModel, tea.Cmd) {
+	if msg.Y >= LIST_HEADER {
+		msg.Y -= LIST_HEADER
+		return t.updateCurrent(msg)
+	}
+	if msg.Y != 2 || msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return t, nil
+	}
+	left := 0
+	for i := range listSections {
+		right := left + lipgloss.Width(t.sectionLabel(i)) + 1
+		if msg.X >= left && msg.X < right {
+			return t.show(i)
+		}
+		left = right
+	}
+	return t, nil
+}
+
+// updateCurrent passes msg to the section on screen.
+func (t ListTab) updateCurrent(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	if t.section == LIST_POSTS {
+		t.posts, cmd = t.posts.Update(msg)
+	} else {
+		t.members, cmd = t.members.Update(msg)
+	}
+	return t, cmd
+}
+
+// Link returns the bsky.app link of the focused post or member, or of the list.
+func (t ListTab) Link() string {
+	switch t.section {
+	case LIST_POSTS:
+		if item, ok := t.posts.Focused(); ok {
+			return postURL(item.Post.URI, item.Post.Author.Handle)
+		}
+	case LIST_MEMBERS:
+		if item, ok := t.members.Focused(); ok {
+			return profileURL(item.Subject.Handle)
+		}
+	}
+	if t.shown.URI != "" {
+		return listURL(t.shown.URI, t.shown.Creator.Handle)
+	}
+	return ""
+}
+
+// KeyHelp lists the list, post and scrolling bindings.
+func (t ListTab) KeyHelp() []HelpGroup {
+	return []HelpGroup{
+		{Title: "List", Bindings: keymap.Bindings(keymap.FILTER, keymap.SELECT, keymap.ADD, keymap.REMOVE, keymap.RENAME, keymap.DELETE, keymap.RELOAD)},
+		{Title: "Posts", Bindings: keymap.Bindings(keymap.LIKE, keymap.REPOST, keymap.REPLY, keymap.QUOTE)},
+		{Title: keymap.GROUP_SCROLL, Bindings: keymap.Group(keymap.GROUP_SCROLL)},
+		{Title: keymap.GROUP_SEARCH, Bindings: keymap.Group(keymap.GROUP_SEARCH)},
+	}
+}
+
+// sectionLabel is the tab of section i, highlighted if it is the one shown.
+func (t ListTab) sectionLabel(i int) string {
+	label := " " + listSections[i] + " "
+	if i == t.section {
+		return styles.Focus.Bold(true).Underline(true).Render(label)
+	}
+	return lipgloss.NewStyle().Foreground(styles.Muted).Render(label)
+}
+
+// header is the name of the list, who made it and how many are on it, its description, the sections,
+// and the name or handle being typed.
+func (t ListTab) header() []string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	var lines []string
+	if t.shown.URI == "" {
+		lines = strings.Split(t.list.View(func(messages.ListView) string { return "" }), "\n")
+	} else {
+		title := lipgloss.NewStyle().Bold(true).Render(t.shown.Name) + " " +
+			muted.Render(fmt.Sprintf("%s by @%s · %d %s", listPurpose(t.shown.Purpose), t.shown.Creator.Handle,
+				t.shown.ListItemCount, plural(t.shown.ListItemCount, "member", "members")))
+		lines = []string{title, muted.Render(strings.Join(strings.Fields(t.shown.Description), " "))}
+	}
+	for len(lines) < 2 {
+		lines = append(lines, "")
+	}
+	tabs := make([]string, len(listSections))
+	for i := range listSections {
+		tabs[i] = t.sectionLabel(i)
+	}
+	lines = append(lines[:2], strings.Join(tabs, " "), t.field.View())
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, t.w, "…")
+	}
+	return lines
+}
+
+func (t ListTab) View() string {
+	body := t.posts.View()
+	if t.section == LIST_MEMBERS {
+		body = t.members.View()
+	}
+	return strings.Join(t.header(), "\n") + "\n" + body
+}
+
+// listPurpose is what kind of list purpose makes it.
+func listPurpose(purpose string) string {
+	switch purpose {
+	case messages.LIST_PURPOSE_MOD:
+		return "moderation list"
+	case messages.LIST_PURPOSE_REFERENCE:
+		return "reference list"
+	}
+	return "curation list"
+}
+
+// createList makes a new curation list called name, and tells every view about it once it is made.
+func createList(c *client.Client, name string) tea.Cmd {
+	return func() tea.Msg {
+		ref, err := c.CreateList(name)
+		if err != nil {
+			logger.Warn("unable to create list", "name", name, "err", err)
+			return tea.BatchMsg{messages.SendErrorMsg(fmt.Sprintf("Unable to create the list: %s", err))}
+		}
+		// the list may not be indexed yet, so it is described from what was written
+		list := &messages.ListView{URI: ref.URI, CID: ref.CID, Name: name, Purpose: messages.LIST_PURPOSE_CURATE,
+			Creator: messages.ProfileView{Did: c.Did(), Handle: c.Handle()}, IndexedAt: time.Now()}
+		return tea.BatchMsg{func() tea.Msg {
+			return messages.ListChangedMsg{URI: ref.URI, List: list}
+		}, messages.SendSuccessMsg("Created " + name)}
+	}
+}
+
+// renameList renames list to name, and tells every view once it is renamed.
+func renameList(c *client.Client, list messages.ListView, name string) tea.Cmd {
+	return func() tea.Msg {
+		ref, err := c.RenameList(list.URI, name)
+		if err != nil {
+			logger.Warn("unable to rename list", "list", list.URI, "err", err)
+			return tea.BatchMsg{messages.SendErrorMsg(fmt.Sprintf("Unable to rename the list: %s", err))}
+		}
+		list.Name, list.CID = name, ref.CID
+		return tea.BatchMsg{func() tea.Msg {
+			return messages.ListChangedMsg{URI: list.URI, List: &list}
+		}, messages.SendSuccessMsg("Renamed to " + name)}
+	}
+}
+
+// deleteList deletes list and everyone on it, and tells every view once it is gone. It is taken out of
+// the saved feeds by the app, which writes them.
+func deleteList(c *client.Client, list messages.ListView) tea.Cmd {
+	return tea.Batch(messages.SendStatusMsg("Deleting "+list.Name+"…"), func() tea.Msg {
+		if err := c.DeleteList(list.URI); err != nil {
+			logger.Warn("unable to delete list", "list", list.URI, "err", err)
+			return tea.BatchMsg{messages.SendErrorMsg(fmt.Sprintf("Unable to delete the list: %s", err))}
+		}
+		return tea.BatchMsg{func() tea.Msg {
+			return messages.ListChangedMsg{URI: list.URI}
+		}, messages.SendSuccessMsg("Deleted " + list.Name)}
+	})
+}
+
+// addMember looks up the account with handle and puts it on the list, unless it is already on it, then
+// tells every view of the list.
+func addMember(c *client.Client, list, handle string) tea.Cmd {
+	handle = strings.TrimPrefix(handle, "@")
+	return func() tea.Msg {
+		profile, err := c.GetProfile(handle)
+		if err != nil {
+			return tea.BatchMsg{messages.SendErrorMsg(fmt.Sprintf("Unable to find @%s: %s", handle, err))}
+		}
+		member, err := c.ListMembership(list, profile.Did)
+		if err != nil {
+			return tea.BatchMsg{messages.SendErrorMsg(fmt.Sprintf("Unable to add @%s: %s", profile.Handle, err))}
+		}
+		if member != "" {
+			return tea.BatchMsg{messages.SendWarningMsg("@" + profile.Handle + " is already on this list")}
+		}
+		ref, err := c.AddToList(list, profile.Did)
+		if err != nil {
+			logger.Warn("unable to add to list", "list", list, "did", profile.Did, "err", err)
+			return tea.BatchMsg{messages.SendErrorMsg(fmt.Sprintf("Unable to add @%s: %s", profile.Handle, err))}
+		}
+		item := messages.ListItemView{URI: ref.URI, Subject: messages.ProfileView{Did: profile.Did, Handle: profile.Handle,
+			DisplayName: profile.DisplayName, Description: profile.Description, Avatar: profile.Avatar}}
+		return tea.BatchMsg{func() tea.Msg {
+			return messages.ListMemberMsg{List: list, Item: item, Delta: 1}
+		}, messages.SendSuccessMsg("Added @" + profile.Handle)}
+	}
+}
+
+// removeMember takes item off the list straight away, then puts it back if the listitem record could
+// not be deleted.
+func removeMember(c *client.Client, list string, item messages.ListItemView) tea.Cmd {
+	changed := func(delta int) tea.Cmd {
+		return func() tea.Msg {
+			return messages.ListMemberMsg{List: list, Item: item, Delta: delta}
+		}
+	}
+	return tea.Sequence(changed(-1), func() tea.Msg {
+		if err := c.DeleteRecord(item.URI); err != nil {
+			logger.Warn("unable to remove from list", "list", list, "item", item.URI, "err", err)
+			return tea.BatchMsg{changed(1), messages.SendErrorMsg(fmt.Sprintf("Unable to remove @%s: %s", item.Subject.Handle, err))}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,208 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+// LISTS_HEADER is the rows above the lists: whose lists they are, and the line a name is typed on.
+const LISTS_HEADER = 2
+
+// ListsTab is the lists an account has made. The logged in account's own lists can be created and
+// renamed here, and each one opens to show its posts and members.
+type ListsTab struct {
+	actor  string
+	client *client.Client
+	lists  ResultList[messages.ListView]
+	field  Field
+	w      int
+	h      int
+}
+
+// NewListsTab lists the lists of actor, a handle or DID, or of the logged in account if it is empty.
+func NewListsTab(actor string, c *client.Client) ListsTab {
+	if actor == "" {
+		actor = c.Did()
+	}
+	return ListsTab{
+		actor:  actor,
+		client: c,
+		lists: NewResultList("lists", "No lists yet", func(cursor string) (ResultPage[messages.ListView], error) {
+			page, err := c.GetLists(actor, FEED_PAGE_SIZE, cursor)
+			return ResultPage[messages.ListView]{Items: page.Lists, Cursor: page.Cursor}, err
+		}, renderList),
+		field: NewField(),
+	}
+}
+
+func (l ListsTab) Name() string {
+	switch lists := l.lists.Items(); {
+	case l.own():
+		return "My lists"
+	case len(lists) > 0:
+		return "@" + lists[0].Creator.Handle + " lists"
+	}
+	return "Lists"
+}
+
+func (l ListsTab) Init() tea.Cmd {
+	return l.lists.Init()
+}
+
+// CapturesInput reports whether the name of a list is being typed.
+func (l ListsTab) CapturesInput() bool {
+	return l.field.Active()
+}
+
+// own reports whether these are the logged in account's lists.
+func (l ListsTab) own() bool {
+	lists := l.lists.Items()
+	return l.actor == l.client.Did() || (len(lists) > 0 && lists[0].Creator.Did == l.client.Did())
+}
+
+func (l ListsTab) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		l.w, l.h = msg.Width, msg.Height
+		l.lists = l.lists.Resize(l.w, max(1, l.h-LISTS_HEADER))
+		l.field = l.field.Resize(l.w)
+		return l, nil
+	case tea.KeyMsg:
+		if l.field.Active() {
+			return l.typed(msg)
+		}
+	case messages.ListChangedMsg:
+		return l.changed(msg), nil
+	case messages.ListMemberMsg:
+		l.lists = l.lists.Map(func(list messages.ListView) messages.ListView {
+			if list.URI == msg.List {
+				list.ListItemCount = max(0, list.ListItemCount+msg.Delta)
+			}
+			return list
+		})
+		return l, nil
+	case keymap.ActionMsg:
+		return l.action(msg)
+	case tea.MouseMsg:
+		if msg.Y < LISTS_HEADER {
+			return l, nil
+		}
+		msg.Y -= LISTS_HEADER
+	}
+	l.lists, cmd = l.lists.Update(msg)
+	return l, cmd
+}
+
+// changed shows a list that was created or renamed, and takes away one that was deleted.
+func (l ListsTab) changed(msg messages.ListChangedMsg) ListsTab {
+	lists := l.lists.Items()
+	at := slices.IndexFunc(lists, func(list messages.ListView) bool { return list.URI == msg.URI })
+	switch {
+	case msg.List == nil:
+		l.lists = l.lists.Remove(func(list messages.ListView) bool { return list.URI == msg.URI })
+	case at >= 0:
+		lists = slices.Clone(lists)
+		lists[at] = *msg.List
+		l.lists = l.lists.Set(lists)
+	case l.own() && msg.List.Creator.Did == l.client.Did():
+		// a new list goes at the top, like it does once it is loaded again
+		l.lists = l.lists.Set(append([]messages.ListView{*msg.List}, lists...))
+	}
+	return l
+}
+
+func (l ListsTab) action(msg keymap.ActionMsg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.Action {
+	case keymap.SELECT:
+		if list, ok := l.lists.Focused(); ok {
+			return l, messages.Navigate(ROUTE_LIST, messages.Params{"uri": list.URI})
+		}
+		return l, nil
+	case keymap.CREATE:
+		if !l.own() {
+			return l, messages.SendWarningMsg("Lists can only be made on your own account")
+		}
+		l.field, cmd = l.field.Open("New list: ", "", keymap.CREATE)
+		return l, cmd
+	case keymap.RENAME:
+		list, ok := l.lists.Focused()
+		if !ok {
+			return l, nil
+		}
+		if list.Creator.Did != l.client.Did() {
+			return l, messages.SendWarningMsg("Only your own lists can be changed")
+		}
+		l.field, cmd = l.field.Open("Rename: ", list.Name, keymap.RENAME)
+		return l, cmd
+	}
+	l.lists, cmd = l.lists.Update(msg)
+	return l, cmd
+}
+
+// typed edits the name being typed, and creates the list or renames the focused one once enter is pressed.
+func (l ListsTab) typed(msg tea.KeyMsg) (NamedModel, tea.Cmd) {
+	var value string
+	var done bool
+	var cmd tea.Cmd
+	l.field, value, done, cmd = l.field.Update(msg)
+	if !done {
+		return l, cmd
+	}
+	if l.field.What() == keymap.CREATE {
+		return l, createList(l.client, value)
+	}
+	if list, ok := l.lists.Focused(); ok {
+		return l, renameList(l.client, list, value)
+	}
+	return l, nil
+}
+
+// Link returns the bsky.app link of the focused list.
+func (l ListsTab) Link() string {
+	if list, ok := l.lists.Focused(); ok {
+		return listURL(list.URI, list.Creator.Handle)
+	}
+	return ""
+}
+
+// KeyHelp lists the list and scrolling bindings.
+func (l ListsTab) KeyHelp() []HelpGroup {
+	return []HelpGroup{
+		{Title: "Lists", Bindings: keymap.Bindings(keymap.SELECT, keymap.CREATE, keymap.RENAME, keymap.RELOAD)},
+		{Title: keymap.GROUP_SCROLL, Bindings: keymap.Group(keymap.GROUP_SCROLL)},
+		{Title: keymap.GROUP_SEARCH, Bindings: keymap.Group(keymap.GROUP_SEARCH)},
+	}
+}
+
+func (l ListsTab) View() string {
+	title := lipgloss.NewStyle().Bold(true).Render(l.Name())
+	if l.own() {
+		title += lipgloss.NewStyle().Foreground(styles.Muted).Render(
+			fmt.Sprintf("  %s makes a new list", keymap.Get(keymap.CREATE).Help().Key))
+	}
+	header := ansi.Truncate(title, l.w, "…") + "\n" + ansi.Truncate(l.field.View(), l.w, "…")
+	return header + "\n" + l.lists.View()
+}
+
+// renderList draws a list in a list of them: its name, what kind it is, how many are on it and its description.
+func renderList(list messages.ListView, width int) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	line := lipgloss.NewStyle().Bold(true).Render(list.Name) + " " +
+		muted.Render(fmt.Sprintf("%s · %d %s", listPurpose(list.Purpose), list.ListItemCount,
+			plural(list.ListItemCount, "member", "members")))
+	text := closeLinks(Wrap(line, width))
+	if list.Description != "" {
+		text += "\n" + renderSummary(list.Description, width)
+	}
+	return text
+}
@@ -0,0 +1,577 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/loader"
+	"github.com/haukened/tsky/internal/tui/styles"
+	"github.com/haukened/tsky/internal/utils"
+)
+
+const (
+	// GET_POSTS_LIMIT is how many posts app.bsky.feed.getPosts returns at once.
+	GET_POSTS_LIMIT = 25
+	// NOTIFY_GROUP_WINDOW is how far apart likes, reposts and follows can be and still be shown together.
+	NOTIFY_GROUP_WINDOW = 48 * time.Hour
+)
+
+// notificationFilter is a choice of which notifications are shown, by their reasons. No reasons shows them all.
+type notificationFilter struct {
+	name    string
+	reasons []string
+}
+
+// notificationFilters are what the filter action steps through, in order.
+var notificationFilters = []notificationFilter{
+	{name: "all"},
+	{name: "mentions", reasons: []string{messages.NOTIFY_MENTION, messages.NOTIFY_REPLY, messages.NOTIFY_QUOTE}},
+	{name: "likes", reasons: []string{messages.NOTIFY_LIKE, messages.NOTIFY_LIKE_VIA_REPOST}},
+	{name: "reposts", reasons: []string{messages.NOTIFY_REPOST, messages.NOTIFY_REPOST_VIA_REPOST}},
+	{name: "follows", reasons: []string{messages.NOTIFY_FOLLOW, messages.NOTIFY_STARTERPACK}},
+}
+
+// notificationPage is a page of notifications and the posts they are about, by URI.
+type notificationPage struct {
+	messages.NotificationsMessage
+	posts map[string]messages.PostView
+}
+
+// notificationGroup is one entry in the list: a post that mentions, quotes or replies to the account,
+// or the likes, reposts or follows that arrived around the same time for the same thing.
+type notificationGroup struct {
+	reason string
+	items  []messages.Notification
+}
+
+// key identifies a group by the newest notification in it.
+func (g notificationGroup) key() string {
+	return g.reason + " " + g.items[0].URI
+}
+
+// grouped reports whether notifications for reason are shown together when they are about the same thing.
+func grouped(reason string) bool {
+	switch reason {
+	case messages.NOTIFY_LIKE, messages.NOTIFY_REPOST, messages.NOTIFY_LIKE_VIA_REPOST,
+		messages.NOTIFY_REPOST_VIA_REPOST, messages.NOTIFY_FOLLOW, messages.NOTIFY_STARTERPACK:
+		return true
+	}
+	return false
+}
+
+// subjectURI is the post a notification is about: the liked or reposted post, or the reply, mention or quote itself.
+func subjectURI(n messages.Notification) string {
+	if grouped(n.Reason) {
+		return n.ReasonSubject
+	}
+	return n.URI
+}
+
+// NotificationsTab lists the notifications of the logged in account, newest first, with likes, reposts
+// and follows of the same thing grouped together. Older pages load as the list nears the end.
+type NotificationsTab struct {
+	client *client.Client
+	latest loader.Resource[notificationPage]
+	merged time.Time
+	older  loader.Resource[notificationPage]
+	more   bool
+	items  []messages.Notification
+	posts  map[string]messages.PostView
+	cursor string
+	filter int
+	groups []notificationGroup
+	body   Pager
+	theme  string
+	width  int
+}
+
+func NewNotificationsTab(c *client.Client) NotificationsTab {
+	n := NotificationsTab{
+		client: c,
+		body:   NewPager(),
+	}
+	n.latest = loader.New("notifications", func() (notificationPage, error) {
+		return fetchNotifications(c, "")
+	})
+	return n
+}
+
+// fetchNotifications fetches the page of notifications at cursor, and the posts they are about.
+func fetchNotifications(c *client.Client, cursor string) (notificationPage, error) {
+	msg, err := c.ListNotifications(FEED_PAGE_SIZE, cursor)
+	if err != nil {
+		return notificationPage{}, err
+	}
+	page := notificationPage{NotificationsMessage: msg, posts: map[string]messages.PostView{}}
+	var uris []string
+	for _, n := range msg.Notifications {
+		if uri := subjectURI(n); strings.HasPrefix(uri, "at://") && !slices.Contains(uris, uri) {
+			uris = append(uris, uri)
+		}
+	}
+	for chunk := range slices.Chunk(uris, GET_POSTS_LIMIT) {
+		posts, err := c.GetPosts(chunk...)
+		if err != nil {
+			// the notifications are still worth showing without what they are about
+			logger.Warn("unable to fetch notification subjects", "err", err)
+			break
+		}
+		for _, post := range posts {
+			page.posts[post.URI] = post
+		}
+	}
+	return page, nil
+}
+
+func (n NotificationsTab) Name() string {
+	return "Notifications"
+}
+
+func (n NotificationsTab) Init() tea.Cmd {
+	return n.latest.Init()
+}
+
+func (n NotificationsTab) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		n.width = msg.Width
+		n.body = n.body.Resize(msg.Width, msg.Height)
+		return n.sync(""), nil
+	case messages.RefreshMsg:
+		n.latest, cmd = n.latest.Reload()
+		return n, cmd
+	case keymap.ActionMsg:
+		return n.action(msg)
+	case SearchMsg:
+		n.body, cmd = n.body.Update(msg)
+		return n, cmd
+	case messages.InteractionMsg:
+		post, ok := n.posts[msg.URI]
+		if !ok {
+			return n, nil
+		}
+		n.posts = maps.Clone(n.posts)
+		n.posts[msg.URI] = msg.Apply(post)
+		return n.sync(""), nil
+	case tea.MouseMsg:
+		n.body, cmd = n.body.Update(msg)
+		return n.loadOlder(cmd)
+	}
+	var older tea.Cmd
+	n.latest, cmd = n.latest.Update(msg)
+	n.older, older = n.older.Update(msg)
+	cmd = tea.Batch(cmd, older)
+	switch {
+	case n.latest.HasData() && n.latest.LoadedAt() != n.merged:
+		n.merged = n.latest.LoadedAt()
+		n = n.mergeLatest().sync("")
+	case n.more && n.older.HasData():
+		n.more = false
+		n = n.mergeOlder().sync("")
+	case n.theme != styles.Current() || n.older.IsLoading():
+		// the footer says older notifications are loading
+		n = n.sync("")
+	}
+	return n.loadOlder(cmd)
+}
+
+// Newest returns when the newest notification that has been loaded was indexed, or the zero time
+// before any have loaded.
+func (n NotificationsTab) Newest() time.Time {
+	if len(n.items) == 0 {
+		return time.Time{}
+	}
+	return n.items[0].IndexedAt
+}
+
+func (n NotificationsTab) action(msg keymap.ActionMsg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.Action {
+	case keymap.RELOAD:
+		if n.older.State() == loader.Failed {
+			n.older, cmd = n.older.Reload()
+			return n, cmd
+		}
+		n.latest, cmd = n.latest.Reload()
+		return n, cmd
+	case keymap.UP:
+		return n.move(-msg.Times()).loadOlder(nil)
+	case keymap.DOWN:
+		return n.move(msg.Times()).loadOlder(nil)
+	case keymap.FILTER:
+		n.filter = (n.filter + 1) % len(notificationFilters)
+		n = n.sync("")
+		return n.loadOlder(messages.SendStatusMsg("Showing " + notificationFilters[n.filter].name + " notifications"))
+	case keymap.SELECT:
+		return n, n.open()
+	case keymap.LIKE, keymap.REPOST:
+		post, ok := n.focusedPost()
+		if !ok {
+			return n, nil
+		}
+		if msg.Action == keymap.LIKE {
+			return n, toggleLike(n.client, post)
+		}
+		return n, toggleRepost(n.client, post)
+	case keymap.REPLY, keymap.QUOTE:
+		post, ok := n.focusedPost()
+		if !ok {
+			return n, nil
+		}
+		param := "reply"
+		if msg.Action == keymap.QUOTE {
+			param = "quote"
+		}
+		return n, messages.Navigate(ROUTE_COMPOSE, messages.Params{param: post.URI})
+	}
+	n.body, cmd = n.body.Update(msg)
+	return n.loadOlder(cmd)
+}
+
+// move focuses the notification by places on from the focused one.
+func (n NotificationsTab) move(by int) NotificationsTab {
+	if len(n.groups) == 0 {
+		return n
+	}
+	i := n.body.Focused()
+	if i < 0 {
+		// nothing is focused yet, so start from the first notification
+		n.body = n.body.Focus(0)
+		return n
+	}
+	n.body = n.body.Focus(min(max(i+by, 0), len(n.groups)-1))
+	return n
+}
+
+// open shows what the focused notification is about: the post that was liked or reposted, the reply,
+// mention or quote in its thread, or the profile of whoever followed.
+func (n NotificationsTab) open() tea.Cmd {
+	group, ok := n.focused()
+	if !ok {
+		return nil
+	}
+	first := group.items[0]
+	switch group.reason {
+	case messages.NOTIFY_FOLLOW, messages.NOTIFY_STARTERPACK:
+		return messages.Navigate(ROUTE_PROFILE, messages.Params{"actor": first.Author.Did})
+	}
+	if post, ok := n.posts[subjectURI(first)]; ok {
+		return messages.Navigate(ROUTE_THREAD, messages.Params{"uri": post.URI})
+	}
+	if group.reason == messages.NOTIFY_LIKE || group.reason == messages.NOTIFY_REPOST {
+		return messages.SendWarningMsg("The post is no longer available")
+	}
+	if _, ok := first.Post(); ok {
+		return messages.Navigate(ROUTE_THREAD, messages.Params{"uri": first.URI})
+	}
+	return nil
+}
+
+// focused is the notification group that is focused.
+func (n NotificationsTab) focused() (notificationGroup, bool) {
+	i := n.body.Focused()
+	if i < 0 || i >= len(n.groups) {
+		return notificationGroup{}, false
+	}
+	return n.groups[i], true
+}
+
+// focusedPost is the post the focused notification is about, if it is one that can be liked or replied to.
+func (n NotificationsTab) focusedPost() (messages.PostView, bool) {
+	group, ok := n.focused()
+	if !ok {
+		return messages.PostView{}, false
+	}
+	post, ok := n.posts[subjectURI(group.items[0])]
+	return post, ok
+}
+
+// Link returns the bsky.app link of what the focused notification is about.
+func (n NotificationsTab) Link() string {
+	group, ok := n.focused()
+	if !ok {
+		return ""
+	}
+	if post, ok := n.posts[subjectURI(group.items[0])]; ok {
+		return postURL(post.URI, post.Author.Handle)
+	}
+	return profileURL(group.items[0].Author.Handle)
+}
+
+// KeyHelp lists the notification and scrolling bindings.
+func (n NotificationsTab) KeyHelp() []HelpGroup {
+	return []HelpGroup{
+		{Title: "Notifications", Bindings: keymap.Bindings(keymap.SELECT, keymap.FILTER, keymap.LIKE, keymap.REPOST, keymap.REPLY, keymap.QUOTE, keymap.RELOAD)},
+		{Title: keymap.GROUP_SCROLL, Bindings: keymap.Group(keymap.GROUP_SCROLL)},
+		{Title: keymap.GROUP_SEARCH, Bindings: keymap.Group(keymap.GROUP_SEARCH)},
+	}
+}
+
+func (n NotificationsTab) View() string {
+	if !n.latest.HasData() {
+		// the loader animates its own spinner and error states
+		return n.latest.View(func(notificationPage) string { return "" })
+	}
+	return n.body.View()
+}
+
+// loadOlder fetches the next page once the focus nears the end of the list, or the end is on screen.
+func (n NotificationsTab) loadOlder(cmd tea.Cmd) (NamedModel, tea.Cmd) {
+	if n.cursor == "" || n.older.IsLoading() || n.older.State() == loader.Failed || !n.latest.HasData() {
+		return n, cmd
+	}
+	if n.body.Focused() < len(n.groups)-FEED_LOAD_AHEAD && !n.body.AtBottom() {
+		return n, cmd
+	}
+	c, cursor := n.client, n.cursor
+	n.older = loader.New("older notifications", func() (notificationPage, error) {
+		return fetchNotifications(c, cursor)
+	})
+	n.more = true
+	return n.sync(""), tea.Batch(cmd, n.older.Init())
+}
+
+// mergeLatest puts the newest notifications above those already loaded, or starts again from them if
+// there is a gap between the two.
+func (n NotificationsTab) mergeLatest() NotificationsTab {
+	page := n.latest.Data()
+	n.posts = mergePosts(n.posts, page.posts)
+	if len(n.items) == 0 {
+		n.items, n.cursor = page.Notifications, page.Cursor
+		return n
+	}
+	loaded := slices.IndexFunc(page.Notifications, func(item messages.Notification) bool {
+		return item.URI == n.items[0].URI
+	})
+	if loaded < 0 {
+		n.items, n.cursor = page.Notifications, page.Cursor
+		return n
+	}
+	// the notifications that were already loaded may have been read since
+	fresh := make(map[string]bool, len(page.Notifications))
+	for _, item := range page.Notifications {
+		fresh[item.URI] = true
+	}
+	items := slices.Clone(page.Notifications)
+	for _, item := range n.items {
+		if !fresh[item.URI] {
+			items = append(items, item)
+		}
+	}
+	n.items = items
+	return n
+}
+
+// mergeOlder adds the next page to the end, skipping notifications that are already loaded.
+func (n NotificationsTab) mergeOlder() NotificationsTab {
+	page := n.older.Data()
+	n.posts = mergePosts(n.posts, page.posts)
+	loaded := make(map[string]bool, len(n.items))
+	for _, item := range n.items {
+		loaded[item.URI] = true
+	}
+	items := slices.Clone(n.items)
+	for _, item := range page.Notifications {
+		if !loaded[item.URI] {
+			items = append(items, item)
+		}
+	}
+	n.items = items
+	n.cursor = page.Cursor
+	if len(page.Notifications) == 0 {
+		n.cursor = ""
+	}
+	return n
+}
+
+// mergePosts returns the posts in both maps, preferring those in fresh.
+func mergePosts(posts, fresh map[string]messages.PostView) map[string]messages.PostView {
+	merged := maps.Clone(posts)
+	if merged == nil {
+		merged = map[string]messages.PostView{}
+	}
+	maps.Copy(merged, fresh)
+	return merged
+}
+
+// group puts the notifications that pass the filter into groups, newest first. Likes and reposts of the
+// same post, and follows, are grouped while they are within NOTIFY_GROUP_WINDOW of the newest in the group.
+func (n NotificationsTab) group() []notificationGroup {
+	filter := notificationFilters[n.filter]
+	var groups []notificationGroup
+	open := map[string]int{}
+	for _, item := range n.items {
+		if len(filter.reasons) > 0 && !slices.Contains(filter.reasons, item.Reason) {
+			continue
+		}
+		if !grouped(item.Reason) {
+			groups = append(groups, notificationGroup{reason: item.Reason, items: []messages.Notification{item}})
+			continue
+		}
+		key := item.Reason + " " + item.ReasonSubject
+		if i, ok := open[key]; ok && groups[i].items[0].IndexedAt.Sub(item.IndexedAt) < NOTIFY_GROUP_WINDOW {
+			groups[i].items = append(groups[i].items, item)
+			continue
+		}
+		open[key] = len(groups)
+		groups = append(groups, notificationGroup{reason: item.Reason, items: []messages.Notification{item}})
+	}
+	return groups
+}
+
+// sync groups the notifications again and puts them in the body. The focus moves to the group with key
+// if it is set, otherwise it stays on the group it was on.
+func (n NotificationsTab) sync(key string) NotificationsTab {
+	n.theme = styles.Current()
+	if !n.latest.HasData() {
+		return n
+	}
+	if i := n.body.Focused(); key == "" && i >= 0 && i < len(n.groups) {
+		key = n.groups[i].key()
+	}
+	n.groups = n.group()
+	width := max(1, n.width-ITEM_GUTTER)
+	var lines []string
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	if n.filter > 0 {
+		lines = append(lines, muted.Render(fmt.Sprintf("Showing %s, press f for more", notificationFilters[n.filter].name)), "")
+	}
+	starts := make([]int, len(n.groups))
+	for i, group := range n.groups {
+		starts[i] = len(lines)
+		lines = append(lines, strings.Split(n.render(group, width), "\n")...)
+		// a blank line between notifications
+		lines = append(lines, "")
+	}
+	if len(n.groups) == 0 {
+		lines = append(lines, muted.Render("No notifications yet"))
+	}
+	if footer := n.footer(); footer != "" {
+		lines = append(lines, footer)
+	}
+	n.body = n.body.SetContent(strings.Join(lines, "\n")).SetItems(starts)
+	if i := slices.IndexFunc(n.groups, func(g notificationGroup) bool { return g.key() == key }); i >= 0 && i != n.body.Focused() {
+		n.body = n.body.Focus(i)
+	}
+	return n
+}
+
+// footer is the line after the last notification, how loading the next page is going.
+func (n NotificationsTab) footer() string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	switch {
+	case n.older.IsLoading():
+		// the footer is drawn into the pager once, a spinner would never move
+		return muted.Render("Loading older notifications…")
+	case n.older.State() == loader.Failed:
+		return lipgloss.NewStyle().Foreground(styles.Error).Render(fmt.Sprintf("Unable to load older notifications: %s", n.older.Err())) +
+			muted.Render(" (press r to retry)")
+	case n.cursor == "" && len(n.groups) > 0:
+		return muted.Render("That's everything")
+	}
+	return ""
+}
+
+// render draws a notification group wrapped to width. Posts are drawn in full under what they are,
+// everything else is a line saying who did what, with the post it was done to under it.
+func (n NotificationsTab) render(group notificationGroup, width int) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	first := group.items[0]
+	unread := ""
+	if slices.ContainsFunc(group.items, func(item messages.Notification) bool { return !item.IsRead }) {
+		unread = styles.Focus.Render("● ")
+	}
+	age := muted.Render(" · " + utils.RelativeTime(first.IndexedAt))
+	post, hasPost := n.posts[subjectURI(first)]
+	if !grouped(group.reason) {
+		label := unread + muted.Render(notificationLabel(first)) + age
+		if hasPost {
+			return closeLinks(Wrap(label, width)) + "\n" + renderPostView(post, width)
+		}
+		lines := []string{label, postHeader(first.Author, first.IndexedAt)}
+		if record, ok := first.Post(); ok && record.Text != "" {
+			lines = append(lines, renderText(record.Text, record.Facets))
+		}
+		return closeLinks(Wrap(strings.Join(lines, "\n"), width))
+	}
+	icon, style := notificationIcon(group.reason)
+	line := unread + style.Render(icon) + " " + authorNames(group.items) + " " + notificationLabel(first) + age
+	text := closeLinks(Wrap(line, width))
+	if hasPost && post.Record.Text != "" {
+		// the post is a reminder of what was liked, a few lines of it are enough
+		subject := strings.Split(closeLinks(Wrap(post.Record.Text, max(1, width-2))), "\n")
+		if len(subject) > 3 {
+			subject = append(subject[:2], ansi.Truncate(subject[2], max(1, width-3), "…"))
+		}
+		text += "\n" + muted.Render("  "+strings.Join(subject, "\n  "))
+	}
+	return text
+}
+
+// authorNames names the first author, linked to their profile, and how many others there are.
+func authorNames(items []messages.Notification) string {
+	var authors []messages.ProfileViewBasic
+	for _, item := range items {
+		if !slices.ContainsFunc(authors, func(a messages.ProfileViewBasic) bool { return a.Did == item.Author.Did }) {
+			authors = append(authors, item.Author)
+		}
+	}
+	name := func(a messages.ProfileViewBasic) string {
+		return ansi.SetHyperlink(profileURL(a.Handle)) + lipgloss.NewStyle().Bold(true).Render(displayName(a)) + ansi.ResetHyperlink()
+	}
+	switch len(authors) {
+	case 1:
+		return name(authors[0])
+	case 2:
+		return name(authors[0]) + " and " + name(authors[1])
+	}
+	return fmt.Sprintf("%s and %d others", name(authors[0]), len(authors)-1)
+}
+
+// notificationLabel says what happened, after the names of who did it.
+func notificationLabel(n messages.Notification) string {
+	switch n.Reason {
+	case messages.NOTIFY_LIKE:
+		return "liked your post"
+	case messages.NOTIFY_REPOST:
+		return "reposted your post"
+	case messages.NOTIFY_LIKE_VIA_REPOST:
+		return "liked your repost"
+	case messages.NOTIFY_REPOST_VIA_REPOST:
+		return "reposted your repost"
+	case messages.NOTIFY_FOLLOW:
+		return "followed you"
+	case messages.NOTIFY_STARTERPACK:
+		return "signed up with your starter pack"
+	case messages.NOTIFY_MENTION:
+		return "@ mentioned you"
+	case messages.NOTIFY_REPLY:
+		return "↩ replied to you"
+	case messages.NOTIFY_QUOTE:
+		return "❝ quoted your post"
+	}
+	return n.Reason
+}
+
+// notificationIcon is the mark in front of a group of notifications, colored like the counts on posts.
+func notificationIcon(reason string) (string, lipgloss.Style) {
+	switch reason {
+	case messages.NOTIFY_LIKE, messages.NOTIFY_LIKE_VIA_REPOST:
+		return "♥", lipgloss.NewStyle().Foreground(styles.Error)
+	case messages.NOTIFY_REPOST, messages.NOTIFY_REPOST_VIA_REPOST:
+		return "⟲", lipgloss.NewStyle().Foreground(styles.Special)
+	case messages.NOTIFY_STARTERPACK:
+		return "★", lipgloss.NewStyle().Foreground(styles.Primary)
+	}
+	return "+", lipgloss.NewStyle().Foreground(styles.Primary)
+}
@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haukened/tsky/internal/messages"
+)
+
+func TestNotificationsGroup(t *testing.T) {
+	now := time.Date(2024, 11, 20, 12, 0, 0, 0, time.UTC)
+	item := func(uri, reason, subject string, ago time.Duration) messages.Notification {
+		return messages.Notification{URI: uri, Reason: reason, ReasonSubject: subject, IndexedAt: now.Add(-ago)}
+	}
+	// newest first, like the server returns them
+	items := []messages.Notification{
+		item("l1", messages.NOTIFY_LIKE, "post-a", 0),
+		item("l2", messages.NOTIFY_LIKE, "post-a", time.Hour),
+		item("r1", messages.NOTIFY_REPLY, "", 2*time.Hour),
+		item("l3", messages.NOTIFY_LIKE, "post-b", 3*time.Hour),
+		item("l4", messages.NOTIFY_LIKE, "post-a", 47*time.Hour),
+		item("l5", messages.NOTIFY_LIKE, "post-a", 49*time.Hour),
+		item("f1", messages.NOTIFY_FOLLOW, "", 50*time.Hour),
+		item("f2", messages.NOTIFY_FOLLOW, "", 51*time.Hour),
+		item("m1", messages.NOTIFY_MENTION, "", 52*time.Hour),
+	}
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		// l5 is too long after l1 to join its group, so it starts a new one
+		{"all", []string{"like l1 l2 l4", "reply r1", "like l3", "like l5", "follow f1 f2", "mention m1"}},
+		{"mentions", []string{"reply r1", "mention m1"}},
+		{"likes", []string{"like l1 l2 l4", "like l3", "like l5"}},
+		{"reposts", nil},
+		{"follows", []string{"follow f1 f2"}},
+	}
+	for i, tt := range tests {
+		if notificationFilters[i].name != tt.filter {
+			t.Fatalf("filter %d is %q, want %q", i, notificationFilters[i].name, tt.filter)
+		}
+		var got []string
+		for _, g := range (NotificationsTab{items: items, filter: i}).group() {
+			uris := []string{g.reason}
+			for _, n := range g.items {
+				uris = append(uris, n.URI)
+			}
+			got = append(got, strings.Join(uris, " "))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("group() with the %s filter = %q, want %q", tt.filter, got, tt.want)
+		}
+	}
+}
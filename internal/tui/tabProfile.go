@@ -1,84 +1,419 @@
 package tui
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/haukened/tsky/internal/debug"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/haukened/tsky/internal/client"
 	"github.com/haukened/tsky/internal/messages"
-	"github.com/haukened/tsky/internal/tokensvc"
-	"github.com/haukened/tsky/internal/utils"
+	"github.com/haukened/tsky/internal/richtext"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/loader"
+	"github.com/haukened/tsky/internal/tui/styles"
 )
 
-const PROFILE_URI_BASE = "https://%s/xrpc/app.bsky.actor.getProfile?actor=%s"
+// PROFILE_BIO_LINES is how much of a description is shown, so a long one leaves room for the posts under it.
+const PROFILE_BIO_LINES = 6
 
-var (
-	ErrNoJWT = fmt.Errorf("no JWT provided")
-)
+// profileSection is one of the lists of posts under a profile.
+type profileSection struct {
+	name string
+	// own sections are only shown on the logged in account's profile, no one else's can be seen
+	own   bool
+	fetch func(c *client.Client, actor, cursor string) (messages.FeedMessage, error)
+}
 
-type ProfileTab struct {
-	name     string
-	loaded   bool
-	Profile  messages.ProfileMessage
-	Did      string
-	server   string
-	jwt      *tokensvc.Refresher
-	TabIndex int
+// authorFeed fetches the posts of an author with the given filter.
+func authorFeed(filter string) func(c *client.Client, actor, cursor string) (messages.FeedMessage, error) {
+	return func(c *client.Client, actor, cursor string) (messages.FeedMessage, error) {
+		return c.GetAuthorFeed(actor, filter, FEED_PAGE_SIZE, cursor)
+	}
 }
 
-func (p ProfileTab) Name() string {
-	return p.name
+// profileSections are the lists of posts under a profile, in the order they are shown.
+var profileSections = []profileSection{
+	{name: "Posts", fetch: authorFeed(messages.AUTHOR_FEED_POSTS)},
+	{name: "Replies", fetch: authorFeed(messages.AUTHOR_FEED_REPLIES)},
+	{name: "Media", fetch: authorFeed(messages.AUTHOR_FEED_MEDIA)},
+	{name: "Likes", own: true, fetch: func(c *client.Client, actor, cursor string) (messages.FeedMessage, error) {
+		return c.GetActorLikes(actor, FEED_PAGE_SIZE, cursor)
+	}},
 }
 
-func (p ProfileTab) Server() string {
-	return fmt.Sprintf(PROFILE_URI_BASE, p.server, p.Did)
+// ProfileTab shows who an account is, their description, counts and who they are followed by, with
+// their posts, replies, media and likes in sections under it. Other accounts can be followed from here.
+type ProfileTab struct {
+	name    string
+	actor   string
+	client  *client.Client
+	profile loader.Resource[messages.ProfileMessage]
+	loaded  time.Time
+	shown   messages.ProfileMessage
+	feeds   []FeedList
+	started []bool
+	section int
+	// the header as it is drawn, the links in it, the one under the mouse, and the rows that can be clicked
+	header  []string
+	spans   []Span
+	hover   int
+	button  int
+	tabsRow int
+	theme   string
+	width   int
+	height  int
 }
 
-func NewProfileTab(did, server string, jwt *tokensvc.Refresher) ProfileTab {
-	return ProfileTab{
-		name:     "Profile",
-		Did:      did,
-		server:   server,
-		jwt:      jwt,
-		TabIndex: 0,
-		loaded:   false,
+func (p ProfileTab) Name() string {
+	if p.shown.Handle != "" {
+		return "@" + p.shown.Handle
 	}
+	return p.name
 }
 
-func (p ProfileTab) Init() tea.Cmd {
-	var msg messages.ProfileMessage
-	if p.jwt == nil {
-		msg.LoadingError = true
-		msg.Error = ErrNoJWT
-		return messages.SendProfileMsg(msg)
+// NewProfileTab shows the profile of actor, a handle or DID, or of the logged in account if actor is empty.
+func NewProfileTab(actor string, c *client.Client) ProfileTab {
+	p := ProfileTab{
+		name:    "Profile",
+		actor:   actor,
+		client:  c,
+		started: make([]bool, len(profileSections)),
+		hover:   -1,
+		button:  -1,
+		tabsRow: -1,
 	}
-	err := utils.HTTPGetAndParse(p.Server(), p.jwt.AuthToken(), &msg)
-	if err != nil {
-		msg.LoadingError = true
-		msg.Error = err
+	if actor != "" {
+		p.name = actor
+	} else {
+		actor = c.Did()
+	}
+	// the first section is shown first, so it loads along with the profile
+	p.started[0] = true
+	for _, section := range profileSections {
+		fetch := section.fetch
+		p.feeds = append(p.feeds, NewFeedList(strings.ToLower(section.name), c, func(cursor string) (messages.FeedMessage, error) {
+			return fetch(c, actor, cursor)
+		}))
 	}
-	debug.Debugf(prettyPrintProfile(msg))
-	return messages.SendProfileMsg(msg)
+	p.profile = loader.New("profile", p.fetchProfile)
+	return p
+}
+
+func (p ProfileTab) Init() tea.Cmd {
+	return tea.Batch(p.profile.Init(), p.feeds[0].Init())
 }
 
 func (p ProfileTab) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
 	switch msg := msg.(type) {
-	case messages.ProfileMessage:
-		p.loaded = true
-		p.Profile = msg
+	case tea.WindowSizeMsg:
+		p.width, p.height = msg.Width, msg.Height
+		return p.sync(), nil
+	case messages.RefreshMsg:
+		// reload in the background, the current profile stays on screen until it arrives
+		var feed tea.Cmd
+		p.profile, cmd = p.profile.Reload()
+		p.feeds = slices.Clone(p.feeds)
+		p.feeds[p.section], feed = p.feeds[p.section].Update(msg)
+		return p, tea.Batch(cmd, feed)
+	case keymap.ActionMsg:
+		return p.action(msg)
+	case SearchMsg:
+		p.feeds = slices.Clone(p.feeds)
+		p.feeds[p.section], cmd = p.feeds[p.section].Update(msg)
+		return p, cmd
+	case tea.MouseMsg:
+		return p.mouse(msg)
+	case messages.FollowMsg:
+		p.shown = msg.Apply(p.shown)
+		return p.sync(), nil
+	case messages.PostedMsg:
+		if p.own() {
+			// the new post belongs at the top of the logged in account's posts
+			p.feeds = slices.Clone(p.feeds)
+			p.feeds[0], cmd = p.feeds[0].Update(messages.RefreshMsg{})
+		}
+		return p, cmd
+	}
+	cmds := make([]tea.Cmd, 0, len(p.feeds)+1)
+	p.profile, cmd = p.profile.Update(msg)
+	cmds = append(cmds, cmd)
+	p.feeds = slices.Clone(p.feeds)
+	for i := range p.feeds {
+		p.feeds[i], cmd = p.feeds[i].Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	if p.profile.HasData() && p.profile.LoadedAt() != p.loaded {
+		p.loaded = p.profile.LoadedAt()
+		p.shown = p.profile.Data()
+		return p.sync(), tea.Batch(cmds...)
+	}
+	if p.theme != styles.Current() {
+		p = p.sync()
+	}
+	return p, tea.Batch(cmds...)
+}
+
+func (p ProfileTab) action(msg keymap.ActionMsg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.Action {
+	case keymap.RELOAD:
+		var feed tea.Cmd
+		p.profile, cmd = p.profile.Reload()
+		p.feeds = slices.Clone(p.feeds)
+		p.feeds[p.section], feed = p.feeds[p.section].Update(msg)
+		return p, tea.Batch(cmd, feed)
+	case keymap.FILTER:
+		sections := p.sections()
+		i := slices.Index(sections, p.section)
+		return p.show(sections[(i+1)%len(sections)])
+	case keymap.FOLLOW:
+		if !p.profile.HasData() || p.own() {
+			return p, nil
+		}
+		return p, toggleFollow(p.client, p.shown)
+	}
+	p.feeds = slices.Clone(p.feeds)
+	p.feeds[p.section], cmd = p.feeds[p.section].Update(msg)
+	return p, cmd
+}
+
+// mouse follows links and presses buttons in the header, everything below it goes to the posts.
+func (p ProfileTab) mouse(msg tea.MouseMsg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	p.hover = -1
+	if msg.Y >= len(p.header) {
+		// the posts see the mouse relative to their own top left corner
+		msg.Y -= len(p.header)
+		p.feeds = slices.Clone(p.feeds)
+		p.feeds[p.section], cmd = p.feeds[p.section].Update(msg)
+		return p, cmd
+	}
+	if msg.Y < 0 || msg.X < 0 {
+		return p, nil
+	}
+	p.hover = spanAt(p.spans, msg.Y, msg.X)
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return p, nil
+	}
+	switch {
+	case p.hover >= 0:
+		return p, p.spans[p.hover].Open()
+	case msg.Y == p.button && msg.X < lipgloss.Width(p.header[p.button]):
+		return p.action(keymap.ActionMsg{Action: keymap.FOLLOW})
+	case msg.Y == p.tabsRow:
+		left := 0
+		for _, i := range p.sections() {
+			right := left + lipgloss.Width(p.sectionLabel(i)) + 1
+			if msg.X >= left && msg.X < right {
+				return p.show(i)
+			}
+			left = right
+		}
 	}
 	return p, nil
 }
 
+// show switches to section i, loading its posts the first time it is shown.
+func (p ProfileTab) show(i int) (NamedModel, tea.Cmd) {
+	if i == p.section {
+		return p, nil
+	}
+	p.section = i
+	p = p.sync()
+	if p.started[i] {
+		return p, nil
+	}
+	p.started = slices.Clone(p.started)
+	p.started[i] = true
+	return p, p.feeds[i].Init()
+}
+
+// own reports whether this is the logged in account's profile.
+func (p ProfileTab) own() bool {
+	return p.actor == "" || (p.profile.HasData() && p.shown.Did == p.client.Did())
+}
+
+// sections lists the sections that can be shown on this profile.
+func (p ProfileTab) sections() []int {
+	var sections []int
+	for i, section := range profileSections {
+		if !section.own || p.own() {
+			sections = append(sections, i)
+		}
+	}
+	return sections
+}
+
+// Link returns the bsky.app link of the profile, once it is known.
+func (p ProfileTab) Link() string {
+	if !p.profile.HasData() {
+		return ""
+	}
+	return profileURL(p.shown.Handle)
+}
+
+// KeyHelp lists the profile, post and scrolling bindings.
+func (p ProfileTab) KeyHelp() []HelpGroup {
+	actions := []string{keymap.FILTER, keymap.SELECT, keymap.LIKE, keymap.REPOST, keymap.REPLY, keymap.QUOTE, keymap.RELOAD}
+	if !p.own() {
+		actions = append([]string{keymap.FOLLOW}, actions...)
+	}
+	return []HelpGroup{
+		{Title: "Profile", Bindings: keymap.Bindings(actions...)},
+		{Title: keymap.GROUP_SCROLL, Bindings: keymap.Group(keymap.GROUP_SCROLL)},
+		{Title: keymap.GROUP_SEARCH, Bindings: keymap.Group(keymap.GROUP_SEARCH)},
+	}
+}
+
 func (p ProfileTab) View() string {
-	if !p.loaded {
-		return "Loading..."
+	if !p.profile.HasData() {
+		// the loader animates its own spinner and error states
+		return p.profile.View(func(messages.ProfileMessage) string { return "" })
+	}
+	header := slices.Clone(p.header)
+	if p.hover >= 0 {
+		span := p.spans[p.hover]
+		header[span.Line] = highlight(header[span.Line], span, styles.Hover)
+	}
+	return strings.Join(header, "\n") + "\n" + p.feeds[p.section].View()
+}
+
+// sync draws the header again, and gives the posts under it what room is left.
+func (p ProfileTab) sync() ProfileTab {
+	p.theme = styles.Current()
+	p.header, p.spans, p.hover, p.button, p.tabsRow = nil, nil, -1, -1, -1
+	if p.profile.HasData() {
+		text := p.render(p.shown, max(1, p.width))
+		p.header = strings.Split(text, "\n")
+		p.spans = findSpans(text)
+		// the section tabs are the last line, under a blank line and the follow button
+		p.tabsRow = len(p.header) - 1
+		if !p.own() {
+			p.button = p.tabsRow - 2
+		}
+	}
+	p.feeds = slices.Clone(p.feeds)
+	for i := range p.feeds {
+		p.feeds[i] = p.feeds[i].Resize(p.width, max(1, p.height-len(p.header)))
+	}
+	return p
+}
+
+// render draws the header of a profile wrapped to width: the names, the description, the counts, who
+// follows them, the follow button and the section tabs.
+func (p ProfileTab) render(profile messages.ProfileMessage, width int) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	bold := lipgloss.NewStyle().Bold(true)
+	name := profile.DisplayName
+	if name == "" {
+		name = profile.Handle
+	}
+	handle := muted.Render("@" + profile.Handle)
+	if profile.Viewer.FollowedBy != "" {
+		handle += " " + lipgloss.NewStyle().Foreground(styles.Special).Render("follows you")
+	}
+	lines := []string{closeLinks(Wrap(bold.Render(name)+"\n"+handle, width))}
+	if profile.Description != "" {
+		lines = append(lines, renderDescription(profile.Description, width))
+	}
+	counts := fmt.Sprintf("%s %s  %s %s  %s %s",
+		bold.Render(fmt.Sprint(profile.FollowersCount)), muted.Render(plural(profile.FollowersCount, "follower", "followers")),
+		bold.Render(fmt.Sprint(profile.FollowsCount)), muted.Render("following"),
+		bold.Render(fmt.Sprint(profile.PostsCount)), muted.Render(plural(profile.PostsCount, "post", "posts")))
+	if !profile.CreatedAt.IsZero() {
+		counts += muted.Render("  joined " + profile.CreatedAt.Local().Format("January 2006"))
+	}
+	lines = append(lines, closeLinks(Wrap(counts, width)))
+	if known := knownFollowers(profile); known != "" {
+		lines = append(lines, closeLinks(Wrap(known, width)))
+	}
+	if !p.own() {
+		lines = append(lines, followButton(profile.Viewer.Following))
+	}
+	tabs := make([]string, 0, len(profileSections))
+	for _, i := range p.sections() {
+		tabs = append(tabs, p.sectionLabel(i))
+	}
+	lines = append(lines, "", ansi.Truncate(strings.Join(tabs, " "), width, ""))
+	return strings.Join(lines, "\n")
+}
+
+// sectionLabel is the tab of section i, highlighted if it is the one shown.
+func (p ProfileTab) sectionLabel(i int) string {
+	label := " " + profileSections[i].name + " "
+	if i == p.section {
+		return styles.Focus.Bold(true).Underline(true).Render(label)
+	}
+	return lipgloss.NewStyle().Foreground(styles.Muted).Render(label)
+}
+
+// renderDescription styles the links, mentions and hashtags in a description, which unlike a post has no
+// facets of its own. Mentions link to the handle, there is no need to look it up to show a profile.
+// Only the first PROFILE_BIO_LINES lines are shown.
+func renderDescription(description string, width int) string {
+	facets := richtext.Facets(description, func(handle string) (string, error) {
+		return handle, nil
+	})
+	lines := strings.Split(closeLinks(Wrap(renderText(description, facets), width)), "\n")
+	if len(lines) > PROFILE_BIO_LINES {
+		lines = lines[:PROFILE_BIO_LINES]
+		lines[len(lines)-1] = ansi.Truncate(lines[len(lines)-1], max(1, width-1), "") + ansi.ResetHyperlink() + "…"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// knownFollowers names the accounts the logged in account follows that follow this one.
+func knownFollowers(profile messages.ProfileMessage) string {
+	known := profile.Viewer.KnownFollowers
+	if known.Count == 0 || len(known.Followers) == 0 {
+		return ""
+	}
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	var names []string
+	for _, f := range known.Followers[:min(2, len(known.Followers))] {
+		name := f.DisplayName
+		if name == "" {
+			name = "@" + f.Handle
+		}
+		names = append(names, ansi.SetHyperlink(profileURL(f.Handle))+name+ansi.ResetHyperlink())
+	}
+	text := muted.Render("Followed by ") + strings.Join(names, muted.Render(", "))
+	if others := known.Count - len(names); others > 0 {
+		text += muted.Render(fmt.Sprintf(" and %d %s you follow", others, plural(others, "other", "others")))
+	}
+	return text
+}
+
+// followButton is the button that follows or unfollows, for the follow record there is, if any.
+func followButton(following string) string {
+	if following == "" {
+		return styles.Focus.Bold(true).Render("[ + Follow ]")
+	}
+	return lipgloss.NewStyle().Foreground(styles.Muted).Render("[ ✓ Following ]")
+}
+
+// fetchProfile runs off the UI goroutine through the loader.
+func (p ProfileTab) fetchProfile() (messages.ProfileMessage, error) {
+	actor := p.actor
+	if actor == "" {
+		actor = p.client.Did()
+	}
+	profile, err := p.client.GetProfile(actor)
+	if err != nil {
+		return profile, err
 	}
-	if p.Profile.LoadingError {
-		return fmt.Sprintf("Error: %s contacting %s", p.Profile.Error, p.Server())
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		logger.Debug("loaded profile", "profile", prettyPrintProfile(profile))
 	}
-	return fmt.Sprintf("%+v", p)
+	return profile, nil
 }
 
 func prettyPrintProfile(p messages.ProfileMessage) string {
@@ -0,0 +1,557 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+// The sections of the search tab.
+const (
+	SEARCH_POSTS = iota
+	SEARCH_PEOPLE
+	SEARCH_FEEDS
+)
+
+// searchSections are the names of the sections, in order.
+var searchSections = []string{"Posts", "People", "Feeds"}
+
+const (
+	// TYPEAHEAD_LIMIT is how many accounts are suggested while a search is typed.
+	TYPEAHEAD_LIMIT = 6
+	// TYPEAHEAD_DELAY is how long typing has to pause before accounts are suggested.
+	TYPEAHEAD_DELAY = 250 * time.Millisecond
+	// SEARCH_HEADER is the rows above the results: the query, the sections and a blank line.
+	SEARCH_HEADER = 3
+)
+
+// nextSearch tells the suggestions of one search tab from another's.
+var nextSearch atomic.Int64
+
+// typeaheadTickMsg is sent once typing pauses, seq says which change to the query it was for.
+type typeaheadTickMsg struct {
+	id  int64
+	seq int
+}
+
+// typeaheadMsg carries the accounts suggested for term.
+type typeaheadMsg struct {
+	id     int64
+	term   string
+	actors []messages.ProfileView
+	err    error
+}
+
+// SearchTab searches posts, people and feeds. While the query is typed, accounts that match it are
+// suggested, and the handle after from: or @ is completed from them.
+type SearchTab struct {
+	id      int64
+	client  *client.Client
+	input   textinput.Model
+	query   string
+	section int
+	sort    string
+	posts   FeedList
+	people  ResultList[messages.ProfileView]
+	feeds   ResultList[messages.GeneratorView]
+	started []bool
+	// the accounts suggested for what is being typed, the one picked, and which change to the query they are for
+	suggest []messages.ProfileView
+	choice  int
+	seq     int
+	term    string
+	w       int
+	h       int
+}
+
+// NewSearchTab searches for query straight away, or waits for one to be typed if it is empty.
+func NewSearchTab(query string, c *client.Client) SearchTab {
+	input := textinput.New()
+	input.Prompt = "Search: "
+	input.Placeholder = "words, from:handle, since:2024-01-31, lang:en"
+	input.SetValue(query)
+	s := SearchTab{
+		id:     nextSearch.Add(1),
+		client: c,
+		input:  input,
+		sort:   messages.SEARCH_SORT_TOP,
+		choice: -1,
+	}
+	if query == "" {
+		s.input.Focus()
+	}
+	return s.search(strings.TrimSpace(query))
+}
+
+// Params opens the search for what is being searched for now, not what the tab was opened with.
+func (s SearchTab) Params() messages.Params {
+	if s.query == "" {
+		return nil
+	}
+	return messages.Params{"q": s.query}
+}
+
+func (s SearchTab) Name() string {
+	if s.query != "" {
+		return "Search " + ansi.Truncate(s.query, 20, "…")
+	}
+	return "Search"
+}
+
+func (s SearchTab) Init() tea.Cmd {
+	cmd := s.initSection(s.section)
+	if s.input.Focused() {
+		return tea.Batch(cmd, textinput.Blink)
+	}
+	return cmd
+}
+
+// CapturesInput reports whether the query is being typed.
+func (s SearchTab) CapturesInput() bool {
+	return s.input.Focused()
+}
+
+// search makes new result lists for query. The section on screen should be fetched with initSection,
+// the others are fetched as they are shown.
+func (s SearchTab) search(query string) SearchTab {
+	c := s.client
+	s.query = query
+	s.started = make([]bool, len(searchSections))
+	s.started[s.section] = true
+	s.posts = s.searchPosts()
+	empty := "No one found"
+	if query == "" {
+		empty = "Type a name or handle to find people"
+	}
+	s.people = NewResultList("people", empty, func(cursor string) (ResultPage[messages.ProfileView], error) {
+		if query == "" {
+			return ResultPage[messages.ProfileView]{}, nil
+		}
+		page, err := c.SearchActors(query, FEED_PAGE_SIZE, cursor)
+		return ResultPage[messages.ProfileView]{Items: page.Actors, Cursor: page.Cursor}, err
+	}, renderActor)
+	s.feeds = NewResultList("feeds", "No feeds found", func(cursor string) (ResultPage[messages.GeneratorView], error) {
+		var page messages.FeedGeneratorsMessage
+		var err error
+		if query == "" {
+			// with nothing to search for, suggest some
+			page, err = c.GetSuggestedFeeds(FEED_PAGE_SIZE, cursor)
+		} else {
+			page, err = c.SearchFeeds(query, FEED_PAGE_SIZE, cursor)
+		}
+		return ResultPage[messages.GeneratorView]{Items: page.Feeds, Cursor: page.Cursor}, err
+	}, renderGenerator)
+	return s.resize()
+}
+
+// searchPosts makes a new list of the posts that match the query, in the order picked.
+func (s SearchTab) searchPosts() FeedList {
+	c, search := s.client, parsePostSearch(s.query, s.client.Did())
+	search.Sort = s.sort
+	return NewFeedList("posts", c, func(cursor string) (messages.FeedMessage, error) {
+		if search.Query == "" {
+			return messages.FeedMessage{}, nil
+		}
+		page, err := c.SearchPosts(search, FEED_PAGE_SIZE, cursor)
+		feed := messages.FeedMessage{Cursor: page.Cursor}
+		for _, post := range page.Posts {
+			feed.Feed = append(feed.Feed, messages.FeedViewPost{Post: post})
+		}
+		return feed, err
+	})
+}
+
+// initSection starts fetching the results of section.
+func (s SearchTab) initSection(section int) tea.Cmd {
+	switch section {
+	case SEARCH_POSTS:
+		return s.posts.Init()
+	case SEARCH_PEOPLE:
+		return s.people.Init()
+	}
+	return s.feeds.Init()
+}
+
+// show switches to section i, fetching its results the first time it is shown.
+func (s SearchTab) show(i int) (NamedModel, tea.Cmd) {
+	s.section = i
+	if s.started[i] {
+		return s, nil
+	}
+	s.started = slices.Clone(s.started)
+	s.started[i] = true
+	return s, s.initSection(i)
+}
+
+func (s SearchTab) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.w, s.h = msg.Width, msg.Height
+		return s.resize(), nil
+	case tea.KeyMsg:
+		if s.input.Focused() {
+			return s.key(msg)
+		}
+	case typeaheadTickMsg:
+		if msg.id != s.id || msg.seq != s.seq {
+			return s, nil
+		}
+		return s.typeahead()
+	case typeaheadMsg:
+		if msg.id != s.id || msg.term != s.term {
+			return s, nil
+		}
+		if msg.err != nil {
+			logger.Debug("typeahead failed", "term", msg.term, "err", msg.err)
+		}
+		s.suggest, s.choice = msg.actors, -1
+		return s, nil
+	case keymap.ActionMsg:
+		return s.action(msg)
+	case tea.MouseMsg:
+		return s.mouse(msg)
+	case messages.RefreshMsg, SearchMsg:
+		return s.updateCurrent(msg)
+	}
+	var people, feeds tea.Cmd
+	s.posts, cmd = s.posts.Update(msg)
+	s.people, people = s.people.Update(msg)
+	s.feeds, feeds = s.feeds.Update(msg)
+	return s, tea.Batch(cmd, people, feeds)
+}
+
+// key edits the query. Enter searches for it, or picks the suggested account that is highlighted, and
+// esc stops typing.
+func (s SearchTab) key(msg tea.KeyMsg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "esc":
+		s.input.Blur()
+		s.suggest, s.choice = nil, -1
+		return s, nil
+	case "up":
+		s.choice = max(s.choice-1, -1)
+		return s, nil
+	case "down":
+		s.choice = min(s.choice+1, len(s.suggest)-1)
+		return s, nil
+	case "enter":
+		if s.choice >= 0 && s.choice < len(s.suggest) {
+			return s.pick(s.suggest[s.choice])
+		}
+		s.input.Blur()
+		s.suggest, s.choice = nil, -1
+		query := strings.TrimSpace(s.input.Value())
+		if query == s.query {
+			return s, nil
+		}
+		s = s.search(query)
+		return s, tea.Batch(s.initSection(s.section), func() tea.Msg { return ParamsChangedMsg{} })
+	}
+	value := s.input.Value()
+	s.input, cmd = s.input.Update(msg)
+	if s.input.Value() == value {
+		return s, cmd
+	}
+	// suggest accounts once typing pauses
+	s.seq++
+	id, seq := s.id, s.seq
+	return s, tea.Batch(cmd, tea.Tick(TYPEAHEAD_DELAY, func(time.Time) tea.Msg {
+		return typeaheadTickMsg{id: id, seq: seq}
+	}))
+}
+
+// completing returns the handle being typed after from: or @ at the end of the query, if there is one.
+func (s SearchTab) completing() (string, string, bool) {
+	value := s.input.Value()
+	word := value[strings.LastIndexAny(value, " \t")+1:]
+	for _, prefix := range []string{"from:", "@"} {
+		if term, ok := strings.CutPrefix(word, prefix); ok {
+			return prefix, term, true
+		}
+	}
+	return "", "", false
+}
+
+// typeahead looks up accounts for the handle being completed, or for the whole query.
+func (s SearchTab) typeahead() (NamedModel, tea.Cmd) {
+	term := strings.TrimSpace(s.input.Value())
+	if _, handle, ok := s.completing(); ok {
+		term = handle
+	}
+	s.term = term
+	if term == "" {
+		s.suggest, s.choice = nil, -1
+		return s, nil
+	}
+	c, id := s.client, s.id
+	return s, func() tea.Msg {
+		actors, err := c.SearchActorsTypeahead(term, TYPEAHEAD_LIMIT)
+		return typeaheadMsg{id: id, term: term, actors: actors, err: err}
+	}
+}
+
+// pick completes the handle being typed with the account, or opens its profile if no handle is being typed.
+func (s SearchTab) pick(actor messages.ProfileView) (NamedModel, tea.Cmd) {
+	s.suggest, s.choice = nil, -1
+	prefix, handle, ok := s.completing()
+	if !ok {
+		return s, messages.Navigate(ROUTE_PROFILE, messages.Params{"actor": actor.Did})
+	}
+	value := s.input.Value()
+	s.input.SetValue(value[:len(value)-len(prefix)-len(handle)] + prefix + actor.Handle + " ")
+	s.input.CursorEnd()
+	return s, nil
+}
+
+func (s SearchTab) action(msg keymap.ActionMsg) (NamedModel, tea.Cmd) {
+	switch msg.Action {
+	case keymap.SEARCH_TAB:
+		s.input.CursorEnd()
+		return s, s.input.Focus()
+	case keymap.FILTER:
+		return s.show((s.section + 1) % len(searchSections))
+	case keymap.SORT:
+		if s.section != SEARCH_POSTS {
+			return s, nil
+		}
+		if s.sort == messages.SEARCH_SORT_LATEST {
+			s.sort = messages.SEARCH_SORT_TOP
+		} else {
+			s.sort = messages.SEARCH_SORT_LATEST
+		}
+		s.posts = s.searchPosts().Resize(s.w, max(1, s.h-SEARCH_HEADER))
+		return s, tea.Batch(s.posts.Init(), messages.SendStatusMsg("Showing the "+s.sort+" posts first"))
+	case keymap.SELECT:
+		switch s.section {
+		case SEARCH_PEOPLE:
+			if actor, ok := s.people.Focused(); ok {
+				return s, messages.Navigate(ROUTE_PROFILE, messages.Params{"actor": actor.Did})
+			}
+			return s, nil
+		case SEARCH_FEEDS:
+			if feed, ok := s.feeds.Focused(); ok {
+				return s, messages.Navigate(ROUTE_FEED, messages.Params{"uri": feed.URI})
+			}
+			return s, nil
+		}
+	}
+	return s.updateCurrent(msg)
+}
+
+// mouse focuses the query or switches sections for clicks above the results, everything else goes to them.
+func (s SearchTab) mouse(msg tea.MouseMsg) (NamedModel, tea.Cmd) {
+	if msg.Y >= SEARCH_HEADER {
+		if s.input.Focused() && len(s.suggest) > 0 {
+			// the suggestions cover the results while the query is typed
+			i := msg.Y - SEARCH_HEADER
+			if i < len(s.suggest) {
+				s.choice = i
+				if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+					return s.pick(s.suggest[i])
+				}
+			}
+			return s, nil
+		}
+		msg.Y -= SEARCH_HEADER
+		return s.updateCurrent(msg)
+	}
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return s, nil
+	}
+	switch msg.Y {
+	case 0:
+		s.input.CursorEnd()
+		return s, s.input.Focus()
+	case 1:
+		left := 0
+		for i := range searchSections {
+			right := left + lipgloss.Width(s.sectionLabel(i)) + 1
+			if msg.X >= left && msg.X < right {
+				return s.show(i)
+			}
+			left = right
+		}
+	}
+	return s, nil
+}
+
+// updateCurrent passes msg to the results of the section on screen.
+func (s SearchTab) updateCurrent(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch s.section {
+	case SEARCH_POSTS:
+		s.posts, cmd = s.posts.Update(msg)
+	case SEARCH_PEOPLE:
+		s.people, cmd = s.people.Update(msg)
+	case SEARCH_FEEDS:
+		s.feeds, cmd = s.feeds.Update(msg)
+	}
+	return s, cmd
+}
+
+// resize gives the results what room is left under the query and sections.
+func (s SearchTab) resize() SearchTab {
+	s.input.Width = max(1, s.w-lipgloss.Width(s.input.Prompt)-1)
+	h := max(1, s.h-SEARCH_HEADER)
+	s.posts = s.posts.Resize(s.w, h)
+	s.people = s.people.Resize(s.w, h)
+	s.feeds = s.feeds.Resize(s.w, h)
+	return s
+}
+
+// Link returns the bsky.app link of the focused result.
+func (s SearchTab) Link() string {
+	switch s.section {
+	case SEARCH_POSTS:
+		if item, ok := s.posts.Focused(); ok {
+			return postURL(item.Post.URI, item.Post.Author.Handle)
+		}
+	case SEARCH_PEOPLE:
+		if actor, ok := s.people.Focused(); ok {
+			return profileURL(actor.Handle)
+		}
+	case SEARCH_FEEDS:
+		if feed, ok := s.feeds.Focused(); ok {
+			return feedURL(feed.URI, feed.Creator.Handle)
+		}
+	}
+	return ""
+}
+
+// KeyHelp lists the search, result and scrolling bindings.
+func (s SearchTab) KeyHelp() []HelpGroup {
+	return []HelpGroup{
+		{Title: "Search", Bindings: keymap.Bindings(keymap.SEARCH_TAB, keymap.FILTER, keymap.SORT, keymap.SELECT, keymap.LIKE, keymap.REPOST, keymap.REPLY, keymap.QUOTE, keymap.RELOAD)},
+		{Title: keymap.GROUP_SCROLL, Bindings: keymap.Group(keymap.GROUP_SCROLL)},
+		{Title: keymap.GROUP_SEARCH, Bindings: keymap.Group(keymap.GROUP_SEARCH)},
+	}
+}
+
+// sectionLabel is the tab of section i, highlighted if it is the one shown.
+func (s SearchTab) sectionLabel(i int) string {
+	label := " " + searchSections[i] + " "
+	if i == s.section {
+		return styles.Focus.Bold(true).Underline(true).Render(label)
+	}
+	return lipgloss.NewStyle().Foreground(styles.Muted).Render(label)
+}
+
+func (s SearchTab) View() string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	tabs := make([]string, len(searchSections))
+	for i := range searchSections {
+		tabs[i] = s.sectionLabel(i)
+	}
+	bar := strings.Join(tabs, " ")
+	if s.section == SEARCH_POSTS {
+		bar += muted.Render("  " + s.sort + " first")
+	}
+	header := s.input.View() + "\n" + ansi.Truncate(bar, s.w, "") + "\n"
+	if s.input.Focused() && len(s.suggest) > 0 {
+		lines := make([]string, len(s.suggest))
+		for i, actor := range s.suggest {
+			line := lipgloss.NewStyle().Bold(true).Render(displayName(actor.Basic())) + " " + muted.Render("@"+actor.Handle)
+			if i == s.choice {
+				line = styles.Focus.Render("▌") + " " + line
+			} else {
+				line = "  " + line
+			}
+			lines[i] = ansi.Truncate(line, s.w, "…")
+		}
+		return header + "\n" + strings.Join(lines, "\n")
+	}
+	var body string
+	switch s.section {
+	case SEARCH_POSTS:
+		if s.query == "" {
+			body = muted.Render("Type what to search for and press enter. from:handle, since:date and lang:code narrow the posts down.")
+			body = Wrap(body, s.w)
+		} else {
+			body = s.posts.View()
+		}
+	case SEARCH_PEOPLE:
+		body = s.people.View()
+	case SEARCH_FEEDS:
+		body = s.feeds.View()
+	}
+	return header + "\n" + body
+}
+
+// parsePostSearch splits the from:, since: and lang: operators out of a post search. from:me is the logged
+// in account, did, and since: takes a date as well as a full time.
+func parsePostSearch(query, did string) client.PostSearch {
+	var search client.PostSearch
+	var words []string
+	for _, word := range strings.Fields(query) {
+		switch name, value, _ := strings.Cut(word, ":"); {
+		case name == "from" && value != "":
+			search.Author = strings.TrimPrefix(value, "@")
+			if search.Author == "me" {
+				search.Author = did
+			}
+		case name == "since" && value != "":
+			search.Since = value
+			if day, err := time.Parse(time.DateOnly, value); err == nil {
+				search.Since = day.Format(time.RFC3339)
+			}
+		case name == "lang" && value != "":
+			search.Lang = value
+		default:
+			words = append(words, word)
+		}
+	}
+	search.Query = strings.Join(words, " ")
+	if search.Query == "" && query != "" {
+		// the query can not be empty, the operators on their own still work as one
+		search.Query = query
+	}
+	return search
+}
+
+// renderActor draws an account in a list: the name, handle, whether they follow you, and their description.
+func renderActor(actor messages.ProfileView, width int) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	line := lipgloss.NewStyle().Bold(true).Render(displayName(actor.Basic())) + " " + muted.Render("@"+actor.Handle)
+	if actor.Viewer != nil && actor.Viewer.FollowedBy != "" {
+		line += " " + lipgloss.NewStyle().Foreground(styles.Special).Render("follows you")
+	}
+	text := closeLinks(Wrap(line, width))
+	if actor.Description != "" {
+		text += "\n" + renderSummary(actor.Description, width)
+	}
+	return text
+}
+
+// renderGenerator draws a custom feed in a list: its name, who made it, its likes and its description.
+func renderGenerator(feed messages.GeneratorView, width int) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	line := lipgloss.NewStyle().Bold(true).Render(feed.DisplayName) + " " +
+		muted.Render(fmt.Sprintf("by @%s · ♥ %d", feed.Creator.Handle, feed.LikeCount))
+	text := closeLinks(Wrap(line, width))
+	if feed.Description != "" {
+		text += "\n" + renderSummary(feed.Description, width)
+	}
+	return text
+}
+
+// renderSummary is the first couple of lines of a description, muted, for lists of things.
+func renderSummary(description string, width int) string {
+	lines := strings.Split(Wrap(strings.Join(strings.Fields(description), " "), width), "\n")
+	if len(lines) > 2 {
+		lines = append(lines[:1], ansi.Truncate(lines[1], max(1, width-1), "")+"…")
+	}
+	return lipgloss.NewStyle().Foreground(styles.Muted).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,592 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/haukened/tsky/internal/client"
+	"github.com/haukened/tsky/internal/config"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/loader"
+	"github.com/haukened/tsky/internal/tui/styles"
+)
+
+const (
+	// THREAD_DEPTH is how many levels of replies are loaded at a time.
+	THREAD_DEPTH = 6
+	// THREAD_PARENTS is how many posts above the opened one are loaded.
+	THREAD_PARENTS = 80
+	// THREAD_INDENT is how many levels deep replies are indented before they stop moving right.
+	THREAD_INDENT = 8
+)
+
+// The kinds of row in a thread.
+const (
+	ROW_POST = iota
+	// the parents go back further than were loaded
+	ROW_EARLIER
+	// a post has replies deeper than were loaded
+	ROW_MORE
+	// the replies of a collapsed post
+	ROW_HIDDEN
+)
+
+// threadRow is one item of a thread as it is laid out, a post or a line standing in for the posts around it.
+type threadRow struct {
+	kind int
+	node messages.ThreadPost
+	// 0 for the parents and the opened post, counting up through the replies
+	depth int
+	// the post this one replies to, for collapsing
+	parent string
+	anchor bool
+}
+
+// key identifies a row across layouts, so the focus stays on it when the thread changes around it.
+func (r threadRow) key() string {
+	return rowKey(r.kind, r.node.Key())
+}
+
+func rowKey(kind int, uri string) string {
+	return fmt.Sprintf("%d %s", kind, uri)
+}
+
+// ThreadTab is a post with the posts it replies to above it and the tree of replies to it below.
+// Replies can be collapsed, sorted, and loaded further than the first fetch went.
+type ThreadTab struct {
+	uri    string
+	did    string
+	client *client.Client
+	thread loader.Resource[messages.ThreadMessage]
+	loaded time.Time
+	tree   messages.ThreadPost
+	// the replies loaded past the depth of the first fetch, by the URI of the post they reply to
+	grafts    map[string][]messages.ThreadPost
+	more      loader.Resource[messages.ThreadMessage]
+	moreURI   string
+	collapsed map[string]bool
+	order     string
+	rows      []threadRow
+	body      Pager
+	shown     bool
+	theme     string
+	width     int
+}
+
+// NewThreadTab shows the thread around the post at uri, with the replies in the configured order.
+func NewThreadTab(uri string, c *client.Client, conf *config.Config) ThreadTab {
+	t := ThreadTab{
+		uri:    uri,
+		did:    c.Did(),
+		client: c,
+		order:  conf.ThreadOrder(),
+		body:   NewPager(),
+	}
+	t.thread = loader.New("thread", func() (messages.ThreadMessage, error) {
+		return c.GetPostThread(uri, THREAD_DEPTH, THREAD_PARENTS)
+	})
+	return t
+}
+
+func (t ThreadTab) Name() string {
+	return "Thread"
+}
+
+func (t ThreadTab) Init() tea.Cmd {
+	return t.thread.Init()
+}
+
+func (t ThreadTab) Update(msg tea.Msg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		t.width = msg.Width
+		t.body = t.body.Resize(msg.Width, msg.Height)
+		return t.sync(""), nil
+	case messages.RefreshMsg:
+		t.thread, cmd = t.thread.Reload()
+		return t, cmd
+	case keymap.ActionMsg:
+		return t.action(msg)
+	case SearchMsg:
+		t.body, cmd = t.body.Update(msg)
+		return t, cmd
+	case messages.InteractionMsg:
+		t.tree = interact(t.tree, msg)
+		return t.sync(""), nil
+	case messages.PostedMsg:
+		// a reply to a post in this thread belongs in it
+		if msg.Record.Reply != nil && contains(t.tree, msg.Record.Reply.Parent.URI) {
+			t.thread, cmd = t.thread.Reload()
+		}
+		return t, cmd
+	case tea.MouseMsg:
+		t.body, cmd = t.body.Update(msg)
+		if cmd == nil && msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			// a click on a line standing in for other posts brings them in
+			if i := t.body.Hovered(); i >= 0 && t.rows[i].kind != ROW_POST {
+				return t.selectRow(i)
+			}
+		}
+		return t, cmd
+	}
+	var more tea.Cmd
+	t.thread, cmd = t.thread.Update(msg)
+	t.more, more = t.more.Update(msg)
+	cmd = tea.Batch(cmd, more)
+	if t.thread.HasData() && t.thread.LoadedAt() != t.loaded {
+		t.loaded = t.thread.LoadedAt()
+		t.tree = t.thread.Data().Thread
+		// replies that were loaded further keep their place when the thread is reloaded
+		for uri, replies := range t.grafts {
+			t.tree = graft(t.tree, uri, replies)
+		}
+		return t.sync(""), cmd
+	}
+	if t.moreURI != "" && !t.more.IsLoading() {
+		uri := t.moreURI
+		t.moreURI = ""
+		if t.more.State() == loader.Failed {
+			return t.sync(""), tea.Batch(cmd, messages.SendErrorMsg(fmt.Sprintf("Unable to load replies: %s", t.more.Err())))
+		}
+		replies := t.more.Data().Thread.Replies
+		t.grafts = maps.Clone(t.grafts)
+		if t.grafts == nil {
+			t.grafts = map[string][]messages.ThreadPost{}
+		}
+		t.grafts[uri] = replies
+		t.tree = graft(t.tree, uri, replies)
+		return t.sync(""), cmd
+	}
+	if t.theme != styles.Current() || t.moreURI != "" {
+		// the loading replies spinner turns with every message
+		return t.sync(""), cmd
+	}
+	return t, cmd
+}
+
+func (t ThreadTab) action(msg keymap.ActionMsg) (NamedModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.Action {
+	case keymap.RELOAD:
+		t.thread, cmd = t.thread.Reload()
+		return t, cmd
+	case keymap.UP:
+		return t.move(-msg.Times()), nil
+	case keymap.DOWN:
+		return t.move(msg.Times()), nil
+	case keymap.SELECT:
+		return t.selectRow(t.body.Focused())
+	case keymap.LIKE, keymap.REPOST:
+		post := t.focusedPost()
+		if post == nil {
+			return t, nil
+		}
+		if msg.Action == keymap.LIKE {
+			return t, toggleLike(t.client, *post)
+		}
+		return t, toggleRepost(t.client, *post)
+	case keymap.REPLY, keymap.QUOTE:
+		post := t.focusedPost()
+		if post == nil {
+			return t, nil
+		}
+		param := "reply"
+		if msg.Action == keymap.QUOTE {
+			param = "quote"
+		}
+		return t, messages.Navigate(ROUTE_COMPOSE, messages.Params{param: post.URI})
+	case keymap.COLLAPSE:
+		return t.collapse()
+	case keymap.SORT:
+		i := slices.Index(config.ThreadSorts, t.order)
+		t.order = config.ThreadSorts[(i+1)%len(config.ThreadSorts)]
+		return t.sync(""), messages.SendStatusMsg("Replies sorted by " + sortName(t.order))
+	}
+	t.body, cmd = t.body.Update(msg)
+	return t, cmd
+}
+
+// move focuses the row n rows on from the focused one.
+func (t ThreadTab) move(n int) ThreadTab {
+	if len(t.rows) == 0 {
+		return t
+	}
+	i := t.body.Focused()
+	if i < 0 {
+		i = t.anchor()
+	}
+	t.body = t.body.Focus(min(max(i+n, 0), len(t.rows)-1))
+	return t
+}
+
+// selectRow opens the post on row i in a thread of its own, or brings in the posts the row stands in for.
+func (t ThreadTab) selectRow(i int) (NamedModel, tea.Cmd) {
+	if i < 0 || i >= len(t.rows) {
+		return t, nil
+	}
+	row := t.rows[i]
+	switch row.kind {
+	case ROW_POST:
+		if row.anchor || row.node.Post == nil {
+			return t, nil
+		}
+		return t, messages.Navigate(ROUTE_THREAD, messages.Params{"uri": row.node.Post.URI})
+	case ROW_EARLIER:
+		return t, messages.Navigate(ROUTE_THREAD, messages.Params{"uri": row.node.Post.Record.Reply.Parent.URI})
+	case ROW_MORE:
+		return t.loadMore(row.node.Key())
+	case ROW_HIDDEN:
+		t.collapsed = maps.Clone(t.collapsed)
+		delete(t.collapsed, row.node.Key())
+		return t.sync(rowKey(ROW_POST, row.node.Key())), nil
+	}
+	return t, nil
+}
+
+// collapse hides the replies of the focused post, or of the post it replies to if it has none.
+// On a post whose replies are hidden it shows them again.
+func (t ThreadTab) collapse() (NamedModel, tea.Cmd) {
+	i := t.body.Focused()
+	if i < 0 || i >= len(t.rows) {
+		return t, nil
+	}
+	row := t.rows[i]
+	key := row.node.Key()
+	switch {
+	case row.kind == ROW_HIDDEN || t.collapsed[key]:
+		return t.selectRow(i)
+	case row.kind == ROW_POST && len(row.node.Replies) > 0:
+	case row.parent != "":
+		key = row.parent
+	default:
+		return t, nil
+	}
+	t.collapsed = maps.Clone(t.collapsed)
+	if t.collapsed == nil {
+		t.collapsed = map[string]bool{}
+	}
+	t.collapsed[key] = true
+	return t.sync(rowKey(ROW_POST, key)), nil
+}
+
+// loadMore fetches the replies to the post at uri, one fetch at a time.
+func (t ThreadTab) loadMore(uri string) (NamedModel, tea.Cmd) {
+	if t.moreURI != "" {
+		return t, nil
+	}
+	c := t.client
+	t.more = loader.New("replies", func() (messages.ThreadMessage, error) {
+		return c.GetPostThread(uri, THREAD_DEPTH, 0)
+	})
+	t.moreURI = uri
+	return t.sync(""), t.more.Init()
+}
+
+// Link returns the bsky.app link of the focused post, or of the opened post if none is focused.
+func (t ThreadTab) Link() string {
+	post := t.focusedPost()
+	if post == nil {
+		return ""
+	}
+	return postURL(post.URI, post.Author.Handle)
+}
+
+// focusedPost is the focused post, or the opened post if no post is focused.
+func (t ThreadTab) focusedPost() *messages.PostView {
+	i := t.body.Focused()
+	if i < 0 || i >= len(t.rows) || t.rows[i].kind != ROW_POST || t.rows[i].node.Post == nil {
+		i = t.anchor()
+	}
+	if i < 0 {
+		return nil
+	}
+	return t.rows[i].node.Post
+}
+
+// KeyHelp lists the thread and scrolling bindings.
+func (t ThreadTab) KeyHelp() []HelpGroup {
+	return []HelpGroup{
+		{Title: "Thread", Bindings: keymap.Bindings(keymap.SELECT, keymap.LIKE, keymap.REPOST, keymap.REPLY, keymap.QUOTE, keymap.COLLAPSE, keymap.SORT, keymap.RELOAD)},
+		{Title: keymap.GROUP_SCROLL, Bindings: keymap.Group(keymap.GROUP_SCROLL)},
+		{Title: keymap.GROUP_SEARCH, Bindings: keymap.Group(keymap.GROUP_SEARCH)},
+	}
+}
+
+func (t ThreadTab) View() string {
+	if !t.thread.HasData() {
+		// the loader animates its own spinner and error states
+		return t.thread.View(func(messages.ThreadMessage) string { return "" })
+	}
+	return t.body.View()
+}
+
+// anchor returns the row of the opened post.
+func (t ThreadTab) anchor() int {
+	return slices.IndexFunc(t.rows, func(r threadRow) bool { return r.anchor })
+}
+
+// sync lays the thread out again and puts it in the body. The focus moves to the row with key if it is set,
+// otherwise it stays on the row it was on. The first time the opened post is focused.
+func (t ThreadTab) sync(key string) ThreadTab {
+	t.theme = styles.Current()
+	if !t.thread.HasData() {
+		return t
+	}
+	if i := t.body.Focused(); key == "" && i >= 0 && i < len(t.rows) {
+		key = t.rows[i].key()
+	}
+	t.rows = t.layout()
+	width := max(1, t.width-ITEM_GUTTER)
+	var lines []string
+	starts := make([]int, len(t.rows))
+	for i, row := range t.rows {
+		starts[i] = len(lines)
+		lines = append(lines, strings.Split(t.render(row, width), "\n")...)
+		// a blank line between posts
+		lines = append(lines, "")
+	}
+	t.body = t.body.SetContent(strings.Join(lines, "\n")).SetItems(starts)
+	if !t.shown {
+		t.shown = true
+		anchor := t.anchor()
+		t.body = t.body.ScrollTo(anchor).Focus(anchor)
+		return t
+	}
+	if i := slices.IndexFunc(t.rows, func(r threadRow) bool { return r.key() == key }); i >= 0 && i != t.body.Focused() {
+		t.body = t.body.Focus(i)
+	}
+	return t
+}
+
+// layout lays the thread out from the top: the parents, oldest first, the opened post, and the replies under it.
+func (t ThreadTab) layout() []threadRow {
+	var parents []messages.ThreadPost
+	for p := t.tree.Parent; p != nil; p = p.Parent {
+		parents = append(parents, *p)
+	}
+	slices.Reverse(parents)
+	top := t.tree
+	if len(parents) > 0 {
+		top = parents[0]
+	}
+	var rows []threadRow
+	if top.Post != nil && top.Post.Record.Reply != nil {
+		rows = append(rows, threadRow{kind: ROW_EARLIER, node: top})
+	}
+	for _, p := range parents {
+		rows = append(rows, threadRow{kind: ROW_POST, node: p})
+	}
+	rows = append(rows, threadRow{kind: ROW_POST, node: t.tree, anchor: true})
+	return t.replies(rows, t.tree, 1, 0)
+}
+
+// replies adds the replies to node at depth, level is how deep node is below the post its replies were fetched for.
+func (t ThreadTab) replies(rows []threadRow, node messages.ThreadPost, depth, level int) []threadRow {
+	key := node.Key()
+	if _, ok := t.grafts[key]; ok {
+		level = 0
+	}
+	switch {
+	case len(node.Replies) == 0:
+		if level >= THREAD_DEPTH && node.Post != nil && node.Post.ReplyCount > 0 {
+			rows = append(rows, threadRow{kind: ROW_MORE, node: node, depth: depth, parent: key})
+		}
+		return rows
+	case t.collapsed[key]:
+		return append(rows, threadRow{kind: ROW_HIDDEN, node: node, depth: depth, parent: key})
+	}
+	for _, reply := range sortReplies(node.Replies, t.order, t.did) {
+		rows = append(rows, threadRow{kind: ROW_POST, node: reply, depth: depth, parent: key})
+		rows = t.replies(rows, reply, depth+1, level+1)
+	}
+	return rows
+}
+
+// render draws a row at width, indented with a guide for each level of replies it is under.
+func (t ThreadTab) render(row threadRow, width int) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	indent := min(max(row.depth-1, 0), THREAD_INDENT)
+	guides := muted.Render(strings.Repeat("│ ", indent))
+	width = max(1, width-2*indent)
+	var text string
+	switch row.kind {
+	case ROW_POST:
+		text = renderThreadPost(row.node, width)
+		if row.anchor && row.node.Post != nil {
+			created := row.node.Post.Record.CreatedAt.Local().Format("3:04 PM · Jan 2, 2006")
+			text += "\n" + muted.Render(created+" · replies by "+sortName(t.order))
+		}
+	case ROW_EARLIER:
+		text = styles.Focus.Render("↑ Show earlier posts in the thread")
+	case ROW_MORE:
+		text = styles.Focus.Render("↓ Load more replies")
+		if t.moreURI == row.node.Key() {
+			// the row is drawn into the pager once, a spinner would never move
+			text = muted.Render("Loading replies…")
+		}
+	case ROW_HIDDEN:
+		n := countReplies(row.node)
+		text = styles.Focus.Render(fmt.Sprintf("+ %d hidden %s", n, plural(n, "reply", "replies")))
+	}
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = guides + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderThreadPost renders a post in a thread, or says why it can not be shown.
+func renderThreadPost(node messages.ThreadPost, width int) string {
+	if node.Post != nil {
+		return renderPostView(*node.Post, width)
+	}
+	muted := lipgloss.NewStyle().Foreground(styles.Muted).Italic(true)
+	switch {
+	case node.NotFound:
+		return muted.Render("Post not found, it may have been deleted")
+	case node.Blocked && node.Author != nil && node.Author.Viewer != nil && node.Author.Viewer.Blocking != "":
+		return muted.Render("Blocked post, you have blocked its author")
+	case node.Blocked && node.Author != nil && node.Author.Viewer != nil && node.Author.Viewer.BlockedBy:
+		return muted.Render("Blocked post, its author has blocked you")
+	case node.Blocked:
+		return muted.Render("Blocked post")
+	}
+	return muted.Render("This post can not be shown")
+}
+
+// sortReplies returns a copy of replies in order. Posts that can not be shown go last.
+// did is the logged in account, which counts as followed.
+func sortReplies(replies []messages.ThreadPost, order, did string) []messages.ThreadPost {
+	sorted := slices.Clone(replies)
+	slices.SortStableFunc(sorted, func(a, b messages.ThreadPost) int {
+		if (a.Post == nil) != (b.Post == nil) {
+			if a.Post == nil {
+				return 1
+			}
+			return -1
+		}
+		if a.Post == nil {
+			return 0
+		}
+		x, y := a.Post, b.Post
+		switch order {
+		case config.THREAD_SORT_NEWEST:
+			return y.Record.CreatedAt.Compare(x.Record.CreatedAt)
+		case config.THREAD_SORT_LIKES:
+			if x.LikeCount != y.LikeCount {
+				return y.LikeCount - x.LikeCount
+			}
+		case config.THREAD_SORT_FOLLOWED:
+			if fx, fy := followed(x.Author, did), followed(y.Author, did); fx != fy {
+				if fx {
+					return -1
+				}
+				return 1
+			}
+		}
+		return x.Record.CreatedAt.Compare(y.Record.CreatedAt)
+	})
+	return sorted
+}
+
+// followed reports whether the logged in account, did, follows author or is author.
+func followed(author messages.ProfileViewBasic, did string) bool {
+	return author.Did == did || (author.Viewer != nil && author.Viewer.Following != "")
+}
+
+// contains reports whether the post at uri is in the thread around t.
+func contains(t messages.ThreadPost, uri string) bool {
+	for p := t.Parent; p != nil; p = p.Parent {
+		if p.Key() == uri {
+			return true
+		}
+	}
+	return inReplies(t, uri)
+}
+
+// inReplies reports whether the post at uri is t or one of the replies under it.
+func inReplies(t messages.ThreadPost, uri string) bool {
+	if t.Key() == uri {
+		return true
+	}
+	for _, reply := range t.Replies {
+		if inReplies(reply, uri) {
+			return true
+		}
+	}
+	return false
+}
+
+// interact returns a copy of t with the change made to every post it is for, above and below t.
+func interact(t messages.ThreadPost, msg messages.InteractionMsg) messages.ThreadPost {
+	if t.Post != nil && t.Post.URI == msg.URI {
+		post := msg.Apply(*t.Post)
+		t.Post = &post
+	}
+	if t.Parent != nil {
+		parent := interact(*t.Parent, msg)
+		t.Parent = &parent
+	}
+	if len(t.Replies) > 0 {
+		replies := make([]messages.ThreadPost, len(t.Replies))
+		for i, reply := range t.Replies {
+			replies[i] = interact(reply, msg)
+		}
+		t.Replies = replies
+	}
+	return t
+}
+
+// countReplies counts every reply under node that was loaded.
+func countReplies(node messages.ThreadPost) int {
+	n := len(node.Replies)
+	for _, reply := range node.Replies {
+		n += countReplies(reply)
+	}
+	return n
+}
+
+// graft returns a copy of t with the replies of the post at uri replaced.
+func graft(t messages.ThreadPost, uri string, replies []messages.ThreadPost) messages.ThreadPost {
+	if t.Key() == uri {
+		t.Replies = replies
+		return t
+	}
+	if len(t.Replies) == 0 {
+		return t
+	}
+	grafted := make([]messages.ThreadPost, len(t.Replies))
+	for i, reply := range t.Replies {
+		grafted[i] = graft(reply, uri, replies)
+	}
+	t.Replies = grafted
+	return t
+}
+
+// sortName is how an order of replies is described.
+func sortName(order string) string {
+	switch order {
+	case config.THREAD_SORT_NEWEST:
+		return "newest"
+	case config.THREAD_SORT_LIKES:
+		return "most liked"
+	case config.THREAD_SORT_FOLLOWED:
+		return "followed first"
+	}
+	return "oldest"
+}
+
+// plural picks the singular or plural form of a word for n.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
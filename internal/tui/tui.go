@@ -4,152 +4,520 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/haukened/tsky/internal/auth"
 	"github.com/haukened/tsky/internal/config"
-	"github.com/haukened/tsky/internal/debug"
+	"github.com/haukened/tsky/internal/logging"
 	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tokensvc"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/haukened/tsky/internal/tui/loader"
+	"github.com/haukened/tsky/internal/tui/styles"
+	"github.com/haukened/tsky/internal/utils"
 )
 
-var (
-	skyBlue = lipgloss.Color("#2081FE")
-)
+var logger = logging.For("tui")
 
 type Model struct {
-	conf         *config.Config
-	models       []NamedModel
-	currentModel int
-	h            int
-	w            int
-	statusMsg    string
-	helpMsg      string
+	conf       *config.Config
+	changes    <-chan config.Change
+	router     Router
+	pending    *messages.NavigateMsg
+	layout     Layout
+	toasts     Toasts
+	refreshGen int
+	logs       LogsModel
+	showLogs   bool
+	help       HelpModel
+	showHelp   bool
+	history    MessagesModel
+	showHist   bool
+	keys       keymap.Reader
+	prompt     Prompt
+	palette    PaletteModel
+	sess       *tokensvc.Refresher
 }
 
-func NewModel(c *config.Config) Model {
-	return Model{
-		conf: c,
-		models: []NamedModel{
-			NewSplashModel(1),
-			NewLoginModel(c),
-			NewAuthModel(c),
-			NewAppView(c),
+// CheckConfig reports whether the theme and key bindings of c can be applied, so they are all
+// accepted or rejected together.
+func CheckConfig(c *config.Config) error {
+	if err := styles.Check(c.Theme); err != nil {
+		return err
+	}
+	return keymap.Check(c.Keymap, c.Keys)
+}
+
+// NewModel creates the root model, c should have passed CheckConfig.
+// changes may be nil if the config file is not being watched.
+func NewModel(c *config.Config, changes <-chan config.Change) Model {
+	if err := styles.Apply(c.Theme); err != nil {
+		logger.Warn("unable to apply theme", "err", err)
+	}
+	if err := keymap.Apply(c.Keymap, c.Keys); err != nil {
+		logger.Warn("unable to apply key bindings", "err", err)
+	}
+	// one session shared by authentication and every view
+	sess := tokensvc.New(c)
+	// every top level view is built fresh each time it is opened
+	router := NewRouter(map[string]RouteFactory{
+		ROUTE_SPLASH: func(messages.Params) (NamedModel, error) {
+			return NewSplashModel(1), nil
 		},
-		currentModel: 0,
-		statusMsg:    "",
-		helpMsg:      "",
+		ROUTE_LOGIN: func(params messages.Params) (NamedModel, error) {
+			return NewLoginModel(c, params["form"] != ""), nil
+		},
+		ROUTE_AUTH: func(messages.Params) (NamedModel, error) {
+			return NewAuthModel(c, sess), nil
+		},
+		ROUTE_APP: func(messages.Params) (NamedModel, error) {
+			return NewAppView(c, sess), nil
+		},
+	})
+	return Model{
+		conf:    c,
+		changes: changes,
+		router:  router,
+		logs:    NewLogsModel(),
+		help:    NewHelpModel(),
+		history: NewMessagesModel(),
+		prompt:  NewPrompt(),
+		palette: NewPaletteModel(),
+		sess:    sess,
 	}
 }
 
+// WithDeepLink opens link, an at:// URI, bsky.app URL or handle, as soon as the app view is shown.
+func (m Model) WithDeepLink(link string) (Model, error) {
+	msg, err := ParseLink(link)
+	if err != nil {
+		return m, err
+	}
+	m.pending = &msg
+	return m, nil
+}
+
 func (m Model) Init() tea.Cmd {
-	// initialize the first model
-	return m.models[m.currentModel].Init()
+	// open the splash screen, and start listening for config and refresh events
+	return tea.Batch(
+		messages.Replace(ROUTE_SPLASH, nil),
+		messages.WaitForConfig(m.changes),
+		messages.Refresh(m.conf.RefreshEvery(), m.refreshGen),
+	)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
+	// the shared loading spinner ticks on its own, no view needs to see it
+	if cmd, ok := loader.UpdateSpinner(msg); ok {
+		return m, cmd
+	}
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
-			return m, tea.Quit
+		return m.handleKey(msg)
+	case keymap.ActionMsg:
+		return m.handleAction(msg)
+	case SearchMsg:
+		return m.updateFocused(msg)
+	case messages.ConfigChangedMsg:
+		return m.applyConfig(msg.Config)
+	case messages.ConfigErrorMsg:
+		logger.Warn("rejected config change", "err", msg.Err)
+		cmds = append(cmds, messages.SendWarningMsg(fmt.Sprintf("Config not applied: %s", msg.Err)))
+		cmds = append(cmds, messages.WaitForConfig(m.changes))
+		return m, tea.Batch(cmds...)
+	case messages.RefreshMsg:
+		if msg.Gen != m.refreshGen {
+			// this tick belongs to a refresh interval that has since been replaced
+			return m, nil
 		}
+		cmds = append(cmds, messages.Refresh(m.conf.RefreshEvery(), m.refreshGen))
 	case tea.WindowSizeMsg:
-		m.w = msg.Width - 2
-		m.h = msg.Height - 1
-		return m, nil
-	case messages.StatusMsg:
-		m.statusMsg = string(msg)
-		return m, nil
-	case messages.HelpMsg:
-		m.helpMsg = string(msg)
-		return m, nil
-	case messages.NextMsg:
-		if m.currentModel < len(m.models)-1 {
-			debug.Debugf("Advancing from %s to %s", m.models[m.currentModel].Name(), m.models[m.currentModel+1].Name())
-			// reset the help message
-			m.helpMsg = ""
-			// get the next model
-			nextModel := m.models[m.currentModel+1]
-			// initialize the model
-			cmd = nextModel.Init()
-			cmds = append(cmds, cmd)
-			// update the current model
-			m.currentModel++
+		// the current view only ever sees the space inside the frame
+		m.layout = NewLayout(msg)
+		body := m.layout.Body()
+		m.logs = m.logs.Resize(body.Width, body.Height)
+		m.help = m.help.Resize(body.Width, body.Height)
+		m.history = m.history.Resize(body.Width, body.Height).Show(m.toasts.History())
+		m.palette = m.palette.Resize(body.Width, body.Height)
+		m.router, cmd = m.router.Update(body)
+		return m, cmd
+	case messages.ToastMsg:
+		m.toasts, cmd = m.toasts.Push(msg)
+		if m.showHist {
+			m.history = m.history.Show(m.toasts.History())
 		}
-		return m, tea.Batch(cmds...)
-	case messages.PrevMsg:
-		if m.currentModel > 0 {
-			debug.Debugf("Regressing from %s to %s", m.models[m.currentModel].Name(), m.models[m.currentModel-1].Name())
-			// reset the help message
-			m.helpMsg = ""
-			// get the previous model
-			prevModel := m.models[m.currentModel-1]
-			switch prevModel.(type) {
-			case LoginModel:
-				prevModel = NewLoginModel(m.conf)
-			case AuthModel:
-				prevModel = NewAuthModel(m.conf)
+		return m, cmd
+	case toastExpiredMsg:
+		m.toasts, cmd = m.toasts.Expire(msg)
+		return m, cmd
+	case messages.DismissMsg:
+		m.toasts, cmd = m.toasts.Dismiss()
+		return m, cmd
+	case messages.NavigateMsg:
+		return m.navigate(msg)
+	case tea.MouseMsg:
+		if m.onToast(msg) {
+			if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+				return m.handleAction(keymap.ActionMsg{Action: keymap.MESSAGES})
 			}
-			// initialize the model
-			cmd = prevModel.Init()
-			cmds = append(cmds, cmd)
-			// replace the current model
-			m.models[m.currentModel] = prevModel
-			// update the current model
-			m.currentModel--
+			return m, nil
 		}
-		return m, tea.Batch(cmds...)
+		// views work in their own coordinates, with 0,0 at the top left of the body
+		return m.updateFocused(m.layout.Translate(msg))
 	}
 
-	// update the current model
-	model, cmd := m.models[m.currentModel].Update(msg)
+	// update the current view
+	m.router, cmd = m.router.Update(msg)
 	cmds = append(cmds, cmd)
 
-	// sync the model back
-	m.models[m.currentModel] = model
+	// pick up anything that was logged while handling the message
+	if m.showLogs {
+		m.logs = m.logs.sync()
+	}
 
 	// Return the updated model and any commands
 	return m, tea.Batch(cmds...)
 }
 
+// applyConfig applies a reloaded config, keeping the current settings if any part of it is invalid.
+func (m Model) applyConfig(n *config.Config) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	cmds = append(cmds, messages.WaitForConfig(m.changes))
+	if err := CheckConfig(n); err != nil {
+		logger.Warn("rejected config change", "err", err)
+		cmds = append(cmds, messages.SendWarningMsg(fmt.Sprintf("Config not applied: %s", err)))
+		return m, tea.Batch(cmds...)
+	}
+	// both were checked, so neither can fail part way through
+	styles.Apply(n.Theme)
+	keymap.Apply(n.Keymap, n.Keys)
+	restartRefresh := n.RefreshEvery() != m.conf.RefreshEvery()
+	if !m.conf.Apply(n) {
+		// tsky saves the config itself when the session is refreshed, there is nothing to announce
+		return m, tea.Batch(cmds...)
+	}
+	logging.SetLevel(m.conf.Level())
+	logger.Info("applied config change", "theme", m.conf.Theme, "refresh", m.conf.RefreshEvery(), "level", m.conf.Level())
+	if restartRefresh {
+		// invalidate the ticker that is already scheduled and start a new one
+		m.refreshGen++
+		cmds = append(cmds, messages.Refresh(m.conf.RefreshEvery(), m.refreshGen))
+	}
+	cmds = append(cmds, messages.SendSuccessMsg("Config reloaded"))
+	return m, tea.Batch(cmds...)
+}
+
+// handleKey turns key presses into actions. Views that are taking text input get
+// the keys as they are, only quitting and the log viewer work everywhere.
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if keymap.Matches(msg, keymap.QUIT) {
+		return m, tea.Quit
+	}
+	if m.prompt.Active() {
+		var cmd tea.Cmd
+		m.prompt, cmd = m.prompt.Update(msg)
+		return m, cmd
+	}
+	if m.palette.Active() {
+		var cmd tea.Cmd
+		m.palette, cmd = m.palette.Update(msg)
+		return m, cmd
+	}
+	if keymap.Matches(msg, keymap.LOGS) {
+		return m.handleAction(keymap.ActionMsg{Action: keymap.LOGS})
+	}
+	if !m.showHelp && !m.showLogs && !m.showHist && capturesInput(m.router.Current()) {
+		return m.updateFocused(msg)
+	}
+	var action keymap.ActionMsg
+	var result keymap.Result
+	m.keys, action, result = m.keys.Read(msg)
+	switch result {
+	case keymap.Pending:
+		// wait for the rest of the count or sequence
+		return m, nil
+	case keymap.Complete:
+		return m.handleAction(action)
+	}
+	return m.updateFocused(msg)
+}
+
+// handleAction carries out the global actions, and passes the rest to whatever has focus.
+func (m Model) handleAction(msg keymap.ActionMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.Action {
+	case keymap.QUIT:
+		return m, tea.Quit
+	case keymap.LOGS:
+		m.showLogs = !m.showLogs
+		m.showHelp, m.showHist = false, false
+		body := m.layout.Body()
+		m.logs = m.logs.Resize(body.Width, body.Height)
+		return m, nil
+	case keymap.HELP:
+		m.showHelp = !m.showHelp
+		m.showLogs, m.showHist = false, false
+		m.help = m.help.Show(m.helpGroups())
+		return m, nil
+	case keymap.MESSAGES:
+		m.showHist = !m.showHist
+		m.showHelp, m.showLogs = false, false
+		m.history = m.history.Show(m.toasts.History())
+		return m, nil
+	case keymap.BACK:
+		if m.showHelp || m.showLogs || m.showHist {
+			m.showHelp, m.showLogs, m.showHist = false, false, false
+			return m, nil
+		}
+	case keymap.SEARCH:
+		m.prompt, cmd = m.prompt.Open()
+		return m, cmd
+	case keymap.COMMAND:
+		m.palette, cmd = m.palette.Open()
+		return m, cmd
+	case keymap.COPY_LINK:
+		link := linkOf(m.router.Current())
+		if link == "" {
+			return m, messages.SendErrorMsg("Nothing to copy a link to")
+		}
+		return m, func() tea.Msg {
+			utils.CopyToClipboard(link)
+			return messages.ToastMsg{Text: "Copied " + link, Severity: messages.SEVERITY_SUCCESS}
+		}
+	case keymap.TOGGLE_THEME:
+		return m.nextTheme()
+	case keymap.LOGOUT:
+		return m, m.logout(false)
+	case keymap.SWITCH_ACCOUNT:
+		return m, m.logout(true)
+	case keymap.OPEN:
+		link, err := ParseLink(msg.Arg)
+		if err != nil && isWebLink(msg.Arg) {
+			// links that are not to bsky.app are for the browser
+			return m, openBrowser(msg.Arg)
+		}
+		if err != nil {
+			return m, messages.SendErrorMsg(err.Error())
+		}
+		return m.navigate(link)
+	}
+	return m.updateFocused(msg)
+}
+
+// openBrowser opens a link in the web browser, off the UI goroutine.
+func openBrowser(link string) tea.Cmd {
+	return func() tea.Msg {
+		if err := utils.OpenBrowser(link); err != nil {
+			logger.Warn("unable to open browser", "link", link, "err", err)
+			return messages.ToastMsg{Text: fmt.Sprintf("Unable to open %s", link), Severity: messages.SEVERITY_ERROR}
+		}
+		return messages.ToastMsg{Text: "Opened " + link, Severity: messages.SEVERITY_SUCCESS}
+	}
+}
+
+// isWebLink reports whether link is for a web browser.
+func isWebLink(link string) bool {
+	return strings.HasPrefix(link, "https://") || strings.HasPrefix(link, "http://")
+}
+
+// nextTheme switches to the theme after the current one, and saves it as the configured theme.
+func (m Model) nextTheme() (tea.Model, tea.Cmd) {
+	names := styles.ThemeNames()
+	next := names[0]
+	for i, name := range names {
+		if name == styles.Current() {
+			next = names[(i+1)%len(names)]
+		}
+	}
+	if err := styles.Apply(next); err != nil {
+		return m, messages.SendErrorMsg(err.Error())
+	}
+	m.conf.Theme = next
+	m.help = m.help.Show(m.helpGroups())
+	// only what is saved leaves the UI goroutine, not the config itself
+	conf := m.conf
+	data, err := conf.Encode()
+	return m, func() tea.Msg {
+		if err == nil {
+			err = conf.Write(data)
+		}
+		if err != nil {
+			logger.Error("unable to save theme", "err", err)
+		}
+		return messages.ToastMsg{Text: "Theme: " + next, Severity: messages.SEVERITY_SUCCESS}
+	}
+}
+
+// logout ends the session and goes back to the login screen, forgetting who was
+// logged in too if the user is switching to another account.
+// The local session is cleared here, only revoking it and saving the config happen off the UI goroutine.
+func (m Model) logout(forget bool) tea.Cmd {
+	conf := m.conf
+	server := conf.Server
+	refreshJwt, _ := conf.Session()
+	conf.SetSession("", "", "")
+	m.sess.Reset()
+	logger.Info("logged out", "identifier", conf.Identifier)
+	if forget {
+		conf.Identifier = ""
+	}
+	data, err := conf.Encode()
+	return func() tea.Msg {
+		if err := auth.Revoke(server, refreshJwt); err != nil {
+			logger.Warn("logout incomplete", "err", err)
+		}
+		if err == nil {
+			err = conf.Write(data)
+		}
+		if err != nil {
+			logger.Error("unable to save config", "err", err)
+		}
+		return messages.NavigateMsg{Route: ROUTE_LOGIN, Replace: true}
+	}
+}
+
+// updateFocused passes msg to the open overlay, or the current view if there is none.
+func (m Model) updateFocused(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch {
+	case m.palette.Active():
+		m.palette, cmd = m.palette.Update(msg)
+	case m.showHelp:
+		var help NamedModel
+		help, cmd = m.help.Update(msg)
+		m.help = help.(HelpModel)
+	case m.showLogs:
+		var logs NamedModel
+		logs, cmd = m.logs.Update(msg)
+		m.logs = logs.(LogsModel)
+	case m.showHist:
+		var history NamedModel
+		history, cmd = m.history.Update(msg)
+		m.history = history.(MessagesModel)
+	default:
+		m.router, cmd = m.router.Update(msg)
+	}
+	return m, cmd
+}
+
+// helpGroups are the key bindings active right now, the global ones first.
+func (m Model) helpGroups() []HelpGroup {
+	global := HelpGroup{Title: keymap.GROUP_GLOBAL, Bindings: keymap.Group(keymap.GROUP_GLOBAL)}
+	return append([]HelpGroup{global}, keyHelp(m.router.Current())...)
+}
+
+// shortHelp is help itself followed by the bindings of the current view, the footer
+// cuts it short when it runs out of room.
+func (m Model) shortHelp() []key.Binding {
+	bindings := []key.Binding{keymap.Get(keymap.HELP)}
+	for _, g := range keyHelp(m.router.Current()) {
+		bindings = append(bindings, enabled(g.Bindings)...)
+	}
+	return bindings
+}
+
+// navigate opens top level routes itself, and passes any other route on to the app view.
+// Links opened before the app view exists wait until it does.
+func (m Model) navigate(msg messages.NavigateMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if m.router.Has(msg.Route) {
+		current, _ := m.router.Route()
+		logger.Debug("navigating", "from", current, "to", msg.Route)
+		m.router, cmd = m.router.Navigate(msg)
+		if msg.Route == ROUTE_APP && m.pending != nil {
+			pending := *m.pending
+			m.pending = nil
+			cmd = tea.Batch(cmd, func() tea.Msg { return pending })
+		}
+		return m, cmd
+	}
+	if route, _ := m.router.Route(); route != ROUTE_APP {
+		m.pending = &msg
+		return m, nil
+	}
+	m.router, cmd = m.router.Update(msg)
+	return m, cmd
+}
+
 func (m Model) View() string {
-	return m.Render(m.models[m.currentModel].View())
+	if m.palette.Active() {
+		return m.Render(m.palette.View())
+	}
+	if m.showHelp {
+		return m.Render(m.help.View())
+	}
+	if m.showLogs {
+		return m.Render(m.logs.View())
+	}
+	if m.showHist {
+		return m.Render(m.history.View())
+	}
+	return m.Render(m.router.View())
 }
 
 func (m Model) Render(s string) string {
 	doc := strings.Builder{}
+	body := m.layout.Body()
 	mainContent := lipgloss.NewStyle().
-		Height(m.h-1).
-		Width(m.w).
 		Border(lipgloss.RoundedBorder()).
 		BorderBottom(false).
-		BorderForeground(skyBlue).
+		BorderForeground(styles.Primary).
 		Padding(0, 1).
-		Align(lipgloss.Center, lipgloss.Center).
-		Render(s)
+		Render(Fit(s, body.Width, body.Height))
 	doc.WriteString(mainContent + "\n")
 	doc.WriteString(m.MkFooter())
 	return doc.String()
 }
 
+// status is what the footer shows on the right, the count or sequence being typed, or else the toast on screen.
+func (m Model) status() string {
+	if pending := m.keys.Pending(); pending != "" {
+		return lipgloss.NewStyle().Bold(true).Render(pending)
+	}
+	// leave at least half the footer for the help
+	return m.toasts.View(m.layout.Width / 2)
+}
+
+// onToast reports whether msg is over the toast in the footer, which sits left of the dimensions.
+func (m Model) onToast(msg tea.MouseMsg) bool {
+	if msg.Y != m.layout.Height-1 {
+		return false
+	}
+	right := m.layout.Width - lipgloss.Width(fmt.Sprintf("%dx%d", m.layout.Width, m.layout.Height)) - 3
+	return msg.X >= right-lipgloss.Width(m.status()) && msg.X < right
+}
+
 func (m Model) MkFooter() string {
-	dimensions := fmt.Sprintf("%dx%d", m.w, m.h)
-	borderStyle := lipgloss.NewStyle().Foreground(skyBlue)
-	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#5e5e5e")).Bold(true)
-	statusStyle := lipgloss.NewStyle().Bold(true)
-	wS, _ := lipgloss.Size(m.statusMsg)
-	wH, _ := lipgloss.Size(m.helpMsg)
+	dimensions := fmt.Sprintf("%dx%d", m.layout.Width, m.layout.Height)
+	borderStyle := lipgloss.NewStyle().Foreground(styles.Primary)
+	status := m.status()
+	wS, _ := lipgloss.Size(status)
 	wD, _ := lipgloss.Size(dimensions)
-	w := m.w - wS - wH - wD - 8
+	// the short help gets whatever room the status and dimensions leave, with a gap of at least one
+	h := help.New()
+	h.Width = max(0, m.layout.Width-wS-wD-11)
+	h.Styles.ShortKey = lipgloss.NewStyle().Foreground(styles.Muted).Bold(true)
+	h.Styles.ShortDesc = lipgloss.NewStyle().Foreground(styles.Muted)
+	h.Styles.ShortSeparator = lipgloss.NewStyle().Foreground(styles.Subtle)
+	h.Styles.Ellipsis = lipgloss.NewStyle().Foreground(styles.Muted)
+	shortHelp := h.ShortHelpView(m.shortHelp())
+	if m.prompt.Active() {
+		shortHelp = m.prompt.View()
+	}
+	wH, _ := lipgloss.Size(shortHelp)
+	w := m.layout.Width - wS - wH - wD - 10
 	sB := strings.Builder{}
 	sB.WriteString(borderStyle.Render("╰-"))
 	sB.WriteString(borderStyle.Bold(true).Render("tSky-"))
-	sB.WriteString(helpStyle.Render(m.helpMsg))
+	sB.WriteString(shortHelp)
 	for i := 0; i < w; i++ {
 		sB.WriteString(borderStyle.Render("─"))
 	}
-	sB.WriteString(statusStyle.Render(m.statusMsg))
+	sB.WriteString(status)
 	sB.WriteString(borderStyle.Render("─"))
 	sB.WriteString(borderStyle.Render(dimensions))
 	sB.WriteString(borderStyle.Render("-╯"))
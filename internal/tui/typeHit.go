@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+	"github.com/rivo/uniseg"
+)
+
+// spanPattern finds the things in rendered text that can be clicked: links, then mentions and hashtags
+// as the second and third groups, so an @ or # in the middle of a word or link is left alone.
+var spanPattern = regexp.MustCompile(`(?:https?|at)://[^\s<>"'()\[\]]+|(?:^|[^\w@/])(@[a-zA-Z0-9][a-zA-Z0-9-]*(?:\.[a-zA-Z0-9-]+)+)|(?:^|[^\w#&/])(#[\p{L}\p{N}_]*\p{L}[\p{L}\p{N}_]*)`)
+
+// Span is a link, mention or hashtag in rendered content, hit-tested against mouse events.
+// Line counts from the top of the content and Start and End are the columns it covers.
+// Text that was rendered as a hyperlink links to its URI, anything else that looks like a link to itself.
+type Span struct {
+	Line  int
+	Start int
+	End   int
+	Link  string
+}
+
+// Contains reports whether the cell at line and col is part of the span.
+func (s Span) Contains(line, col int) bool {
+	return line == s.Line && col >= s.Start && col < s.End
+}
+
+// Open follows the span. Links and mentions are opened like any other link, hashtags are searched for.
+func (s Span) Open() tea.Cmd {
+	link := s.Link
+	if tag, ok := strings.CutPrefix(link, hashtagURL("")); ok {
+		if tag, err := url.PathUnescape(tag); err == nil {
+			link = "#" + tag
+		}
+	}
+	if strings.HasPrefix(link, "#") {
+		return messages.Navigate(ROUTE_SEARCH, messages.Params{"q": link})
+	}
+	return func() tea.Msg { return keymap.ActionMsg{Action: keymap.OPEN, Arg: link} }
+}
+
+// findSpans returns every hyperlink in s, and every link, mention and hashtag in the text around them.
+func findSpans(s string) []Span {
+	var spans []Span
+	open := ""
+	for i, line := range strings.Split(s, "\n") {
+		var links []Span
+		links, open = hyperlinks(line, i, open)
+		spans = append(spans, links...)
+		plain := ansi.Strip(line)
+	match:
+		for _, m := range spanPattern.FindAllStringSubmatchIndex(plain, -1) {
+			start, end := m[0], m[1]
+			switch {
+			case m[2] >= 0:
+				start, end = m[2], m[3]
+			case m[4] >= 0:
+				start, end = m[4], m[5]
+			default:
+				// a link at the end of a sentence does not take the full stop with it
+				end = start + len(strings.TrimRight(plain[start:end], ".,;:!?"))
+			}
+			span := Span{
+				Line:  i,
+				Start: ansi.StringWidth(plain[:start]),
+				End:   ansi.StringWidth(plain[:end]),
+				Link:  plain[start:end],
+			}
+			for _, link := range links {
+				if span.Start < link.End && link.Start < span.End {
+					// the hyperlink already says where it goes
+					continue match
+				}
+			}
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// hyperlinks finds the OSC 8 hyperlinks on line i. A hyperlink still open at the end of a line carries
+// on at the start of the next, so open is the URI of the link open before the line, and after it.
+func hyperlinks(line string, i int, open string) ([]Span, string) {
+	var spans []Span
+	col, start := 0, 0
+	for len(line) > 0 {
+		if line[0] != ansi.ESC {
+			_, rest, width, _ := uniseg.FirstGraphemeClusterInString(line, -1)
+			col += width
+			line = rest
+			continue
+		}
+		n := escapeLen(line)
+		seq := line[:n]
+		line = line[n:]
+		params, ok := strings.CutPrefix(seq, "\x1b]8;")
+		if !ok {
+			continue
+		}
+		_, uri, _ := strings.Cut(strings.TrimRight(params, "\x07\x1b\\"), ";")
+		if open != "" && col > start {
+			spans = append(spans, Span{Line: i, Start: start, End: col, Link: open})
+		}
+		open, start = uri, col
+	}
+	if open != "" && col > start {
+		spans = append(spans, Span{Line: i, Start: start, End: col, Link: open})
+	}
+	return spans, open
+}
+
+// spanAt returns the index of the span covering line and col, or -1.
+func spanAt(spans []Span, line, col int) int {
+	for i, s := range spans {
+		if s.Contains(line, col) {
+			return i
+		}
+	}
+	return -1
+}
+
+// highlight restyles the columns of line that span covers.
+func highlight(line string, span Span, style lipgloss.Style) string {
+	text := CutLeft(ansi.Truncate(ansi.Strip(line), span.End, ""), span.Start)
+	return ansi.Truncate(line, span.Start, "") + ansi.ResetStyle + style.Render(text) + CutLeft(line, span.End)
+}
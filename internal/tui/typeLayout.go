@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/haukened/tsky/internal/tui/styles"
+	"github.com/rivo/uniseg"
+)
+
+// The frame drawn around the current view takes a border and a column of padding
+// on each side, a top border, and the footer line underneath.
+const (
+	FRAME_WIDTH  = 4
+	FRAME_HEIGHT = 2
+	// the body starts after the left border and padding, under the top border
+	BODY_X = 2
+	BODY_Y = 1
+)
+
+// Layout splits the terminal into the framed body the current view draws in and the footer.
+type Layout struct {
+	Width  int
+	Height int
+}
+
+func NewLayout(msg tea.WindowSizeMsg) Layout {
+	return Layout{Width: msg.Width, Height: msg.Height}
+}
+
+// Body is the exact size available to the current view.
+func (l Layout) Body() tea.WindowSizeMsg {
+	return tea.WindowSizeMsg{
+		Width:  max(0, l.Width-FRAME_WIDTH),
+		Height: max(0, l.Height-FRAME_HEIGHT),
+	}
+}
+
+// Translate moves a mouse event from terminal coordinates into body coordinates.
+func (l Layout) Translate(msg tea.MouseMsg) tea.MouseMsg {
+	msg.X -= BODY_X
+	msg.Y -= BODY_Y
+	return msg
+}
+
+// Fit pads or cuts s to exactly w columns by h lines, so a view can never push the frame out of shape.
+func Fit(s string, w, h int) string {
+	if w <= 0 || h <= 0 {
+		return ""
+	}
+	lines := strings.Split(s, "\n")
+	if len(lines) > h {
+		lines = lines[:h]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, w, "…")
+	}
+	return lipgloss.NewStyle().Width(w).Height(h).Render(strings.Join(lines, "\n"))
+}
+
+// Center places s in the middle of a w by h region, for views that do not fill the screen.
+func Center(s string, w, h int) string {
+	if w <= 0 || h <= 0 {
+		return s
+	}
+	return lipgloss.Place(w, h, lipgloss.Center, lipgloss.Center, s)
+}
+
+// Wrap word wraps s to w columns, long words are broken so nothing runs past the edge.
+func Wrap(s string, w int) string {
+	if w <= 0 {
+		return s
+	}
+	return ansi.Wrap(s, w, " -")
+}
+
+// CutLeft drops the first n columns of s. Escape sequences are kept, so the rest is styled as before.
+// A wide character cut in half is replaced by spaces.
+func CutLeft(s string, n int) string {
+	var sb strings.Builder
+	col := 0
+	for len(s) > 0 {
+		if s[0] == ansi.ESC {
+			end := escapeLen(s)
+			sb.WriteString(s[:end])
+			s = s[end:]
+			continue
+		}
+		cluster, rest, width, _ := uniseg.FirstGraphemeClusterInString(s, -1)
+		switch {
+		case col >= n:
+			sb.WriteString(cluster)
+		case col+width > n:
+			sb.WriteString(strings.Repeat(" ", col+width-n))
+		}
+		col += width
+		s = rest
+	}
+	return sb.String()
+}
+
+// escapeLen is the length of the escape sequence at the start of s, CSI and OSC sequences
+// run to their terminator and anything else is the escape and one more byte.
+func escapeLen(s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+	case ']':
+		for i := 2; i < len(s); i++ {
+			if s[i] == ansi.BEL {
+				return i + 1
+			}
+			if s[i] == ansi.ESC && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+	default:
+		return 2
+	}
+	return len(s)
+}
+
+// tabBarHeight is how many lines the tab bar takes from the top of the app view.
+func tabBarHeight() int {
+	return lipgloss.Height(styles.Tab.Render(""))
+}
@@ -1,6 +1,10 @@
 package tui
 
-import tea "github.com/charmbracelet/bubbletea"
+import (
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/haukened/tsky/internal/messages"
+)
 
 type NamedModel interface {
 	Name() string
@@ -8,3 +12,77 @@ type NamedModel interface {
 	Update(msg tea.Msg) (NamedModel, tea.Cmd)
 	View() string
 }
+
+// InputCapturer is implemented by views that can have a focused text input. While it
+// reports true, keys like esc and backspace go to the view instead of navigating back.
+type InputCapturer interface {
+	CapturesInput() bool
+}
+
+// capturesInput reports whether m currently has a focused text input.
+func capturesInput(m NamedModel) bool {
+	c, ok := m.(InputCapturer)
+	return ok && c.CapturesInput()
+}
+
+// Closer is implemented by views that close themselves once they are done, like the composer
+// once it has posted. The router takes them off its stack as soon as they report true.
+type Closer interface {
+	Closed() bool
+}
+
+// closed reports whether m is done and should be taken off the stack.
+func closed(m NamedModel) bool {
+	c, ok := m.(Closer)
+	return ok && c.Closed()
+}
+
+// Reopener is implemented by views whose parameters change while they are open, like a search that
+// is typed into. Params returns the parameters that would open the view as it is now.
+type Reopener interface {
+	Params() messages.Params
+}
+
+// ParamsChangedMsg is sent by a Reopener when its parameters change, so the tabs are saved with them.
+type ParamsChangedMsg struct{}
+
+// paramsOf returns the parameters that open m as it is now, opened is what it was opened with.
+func paramsOf(m NamedModel, opened messages.Params) messages.Params {
+	if r, ok := m.(Reopener); ok {
+		return r.Params()
+	}
+	return opened
+}
+
+// HelpGroup is a titled set of key bindings, as listed in the help overlay.
+type HelpGroup struct {
+	Title    string
+	Bindings []key.Binding
+}
+
+// KeyHelper is implemented by views with key bindings of their own. They are listed in the
+// help overlay and the most important ones in the footer.
+type KeyHelper interface {
+	KeyHelp() []HelpGroup
+}
+
+// Linker is implemented by views that show something with a bsky.app link.
+type Linker interface {
+	Link() string
+}
+
+// linkOf returns the link to what m shows, or "" if it has none.
+func linkOf(m NamedModel) string {
+	if l, ok := m.(Linker); ok {
+		return l.Link()
+	}
+	return ""
+}
+
+// keyHelp returns the key bindings of m, if it has any.
+func keyHelp(m NamedModel) []HelpGroup {
+	if h, ok := m.(KeyHelper); ok {
+		return h.KeyHelp()
+	}
+	return nil
+}
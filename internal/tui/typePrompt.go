@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SearchMsg asks the view on screen to find query in what it is showing.
+type SearchMsg struct {
+	Query string
+}
+
+// Prompt is the line in the footer a search is typed into.
+type Prompt struct {
+	input  textinput.Model
+	active bool
+}
+
+func NewPrompt() Prompt {
+	input := textinput.New()
+	input.Prompt = "/"
+	return Prompt{input: input}
+}
+
+// Open starts a new search.
+func (p Prompt) Open() (Prompt, tea.Cmd) {
+	p.active = true
+	p.input.Reset()
+	return p, p.input.Focus()
+}
+
+// Active reports whether a search is being typed.
+func (p Prompt) Active() bool {
+	return p.active
+}
+
+// Update edits the line. Enter sends the search, esc abandons it.
+func (p Prompt) Update(msg tea.KeyMsg) (Prompt, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.Type {
+	case tea.KeyEsc:
+		return p.close(), nil
+	case tea.KeyEnter:
+		query := strings.TrimSpace(p.input.Value())
+		p = p.close()
+		if query == "" {
+			return p, nil
+		}
+		return p, func() tea.Msg { return SearchMsg{Query: query} }
+	case tea.KeyBackspace:
+		if p.input.Value() == "" {
+			// backspace on an empty line closes it, like vim
+			return p.close(), nil
+		}
+	}
+	p.input, cmd = p.input.Update(msg)
+	return p, cmd
+}
+
+func (p Prompt) close() Prompt {
+	p.active = false
+	p.input.Blur()
+	return p
+}
+
+func (p Prompt) View() string {
+	return p.input.View()
+}
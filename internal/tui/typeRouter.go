@@ -0,0 +1,295 @@
+package tui
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/haukened/tsky/internal/messages"
+	"github.com/haukened/tsky/internal/tui/keymap"
+)
+
+// Route names, views are always opened by name so they can be linked to and restored.
+const (
+	ROUTE_SPLASH        = "splash"
+	ROUTE_LOGIN         = "login"
+	ROUTE_AUTH          = "auth"
+	ROUTE_APP           = "app"
+	ROUTE_HOME          = "home"
+	ROUTE_PROFILE       = "profile"
+	ROUTE_THREAD        = "thread"
+	ROUTE_COMPOSE       = "compose"
+	ROUTE_NOTIFICATIONS = "notifications"
+	ROUTE_SEARCH        = "search"
+	ROUTE_FEED          = "feed"
+	ROUTE_FEEDS         = "feeds"
+	ROUTE_LIST          = "list"
+	ROUTE_LISTS         = "lists"
+)
+
+// BSKY_APP_HOST is the web app deep links point at, and links are copied for.
+const BSKY_APP_HOST = "bsky.app"
+
+// RouteFactory builds a fresh view for a route from its parameters.
+type RouteFactory func(params messages.Params) (NamedModel, error)
+
+// routeEntry is a view on a router's back stack, along with how it was opened.
+type routeEntry struct {
+	route  string
+	params messages.Params
+	model  NamedModel
+}
+
+// Router owns a back stack of views. Views are pushed and popped by route name,
+// and only the view on top of the stack receives messages.
+type Router struct {
+	routes map[string]RouteFactory
+	stack  []routeEntry
+	size   *tea.WindowSizeMsg
+}
+
+func NewRouter(routes map[string]RouteFactory) Router {
+	return Router{routes: routes}
+}
+
+// Has reports whether this router knows how to build route.
+func (r Router) Has(route string) bool {
+	_, ok := r.routes[route]
+	return ok
+}
+
+// Navigate handles a NavigateMsg, pushing a new view or replacing the current one.
+func (r Router) Navigate(msg messages.NavigateMsg) (Router, tea.Cmd) {
+	factory, ok := r.routes[msg.Route]
+	if !ok {
+		logger.Warn("unknown route", "route", msg.Route)
+		return r, messages.SendErrorMsg(fmt.Sprintf("Nothing to open for %s", msg.Route))
+	}
+	model, err := factory(msg.Params)
+	if err != nil {
+		logger.Warn("unable to open route", "route", msg.Route, "params", msg.Params, "err", err)
+		return r, messages.SendErrorMsg(err.Error())
+	}
+	// a new view starts out knowing how much room it has
+	model, sizeCmd := r.resize(model)
+	entry := routeEntry{route: msg.Route, params: msg.Params, model: model}
+	// copy the stack, routers are values and an older copy may still be referenced
+	stack := append([]routeEntry{}, r.stack...)
+	if msg.Replace && len(stack) > 0 {
+		logger.Debug("replacing route", "from", stack[len(stack)-1].route, "to", msg.Route)
+		stack[len(stack)-1] = entry
+	} else {
+		logger.Debug("pushing route", "route", msg.Route, "depth", len(stack)+1)
+		stack = append(stack, entry)
+	}
+	r.stack = stack
+	return r, tea.Batch(sizeCmd, model.Init())
+}
+
+// Back pops the current view, it reports false if there is nothing to go back to.
+func (r Router) Back() (Router, bool) {
+	if len(r.stack) < 2 {
+		return r, false
+	}
+	logger.Debug("popping route", "route", r.stack[len(r.stack)-1].route, "depth", len(r.stack)-1)
+	r.stack = append([]routeEntry{}, r.stack[:len(r.stack)-1]...)
+	return r, true
+}
+
+// Depth returns how many views are on the stack.
+func (r Router) Depth() int {
+	return len(r.stack)
+}
+
+// Current returns the view on top of the stack, or nil if nothing has been opened yet.
+func (r Router) Current() NamedModel {
+	if len(r.stack) == 0 {
+		return nil
+	}
+	return r.stack[len(r.stack)-1].model
+}
+
+// Route returns the name and parameters of the view on top of the stack.
+func (r Router) Route() (string, messages.Params) {
+	if len(r.stack) == 0 {
+		return "", nil
+	}
+	top := r.stack[len(r.stack)-1]
+	return top.route, paramsOf(top.model, top.params)
+}
+
+// Root returns the name and parameters of the view at the bottom of the stack, the one the tab was opened with.
+func (r Router) Root() (string, messages.Params) {
+	if len(r.stack) == 0 {
+		return "", nil
+	}
+	return r.stack[0].route, paramsOf(r.stack[0].model, r.stack[0].params)
+}
+
+// Last returns the name and parameters of the view nearest the top of the stack whose route matches,
+// or nothing if none does.
+func (r Router) Last(match func(route string) bool) (string, messages.Params) {
+	for i := len(r.stack) - 1; i >= 0; i-- {
+		if match(r.stack[i].route) {
+			return r.stack[i].route, paramsOf(r.stack[i].model, r.stack[i].params)
+		}
+	}
+	return "", nil
+}
+
+// Update passes input and refresh ticks to the current view. Everything else, like the results
+// of loading data, goes to every view on the stack so nothing is missed by a view that is out of sight.
+func (r Router) Update(msg tea.Msg) (Router, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		r.size = &size
+	}
+	if len(r.stack) == 0 {
+		return r, nil
+	}
+	stack := append([]routeEntry{}, r.stack...)
+	first := 0
+	if visibleOnly(msg) {
+		first = len(stack) - 1
+	}
+	var cmds []tea.Cmd
+	for i := first; i < len(stack); i++ {
+		model, cmd := stack[i].model.Update(msg)
+		stack[i].model = model
+		cmds = append(cmds, cmd)
+	}
+	// views that are done close themselves, wherever they are on the stack
+	open := stack[:0]
+	for i, entry := range stack {
+		if closed(entry.model) && (len(open) > 0 || i < len(stack)-1) {
+			logger.Debug("closing route", "route", entry.route)
+			continue
+		}
+		open = append(open, entry)
+	}
+	r.stack = open
+	return r, tea.Batch(cmds...)
+}
+
+// visibleOnly reports whether msg is only meant for the view on screen: keys, the mouse, actions,
+// searches, and refresh ticks, which would otherwise reload every view that was ever opened.
+func visibleOnly(msg tea.Msg) bool {
+	switch msg.(type) {
+	case tea.KeyMsg, tea.MouseMsg, keymap.ActionMsg, SearchMsg, messages.RefreshMsg:
+		return true
+	}
+	return false
+}
+
+// resize tells model the size of the last window size message, if there has been one.
+func (r Router) resize(model NamedModel) (NamedModel, tea.Cmd) {
+	if r.size == nil {
+		return model, nil
+	}
+	return model.Update(*r.size)
+}
+
+// View renders the current view.
+func (r Router) View() string {
+	if current := r.Current(); current != nil {
+		return current.View()
+	}
+	return ""
+}
+
+// profileURL is the bsky.app link to the profile of actor, a handle or DID.
+func profileURL(actor string) string {
+	return fmt.Sprintf("https://%s/profile/%s", BSKY_APP_HOST, actor)
+}
+
+// hashtagURL is the bsky.app link to the posts tagged with tag.
+func hashtagURL(tag string) string {
+	return fmt.Sprintf("https://%s/hashtag/%s", BSKY_APP_HOST, url.PathEscape(tag))
+}
+
+// feedURL is the bsky.app link to the custom feed at uri, published by actor.
+func feedURL(uri, actor string) string {
+	rkey := uri[strings.LastIndex(uri, "/")+1:]
+	return fmt.Sprintf("https://%s/profile/%s/feed/%s", BSKY_APP_HOST, actor, rkey)
+}
+
+// listURL is the bsky.app link to the list at uri, made by actor.
+func listURL(uri, actor string) string {
+	rkey := uri[strings.LastIndex(uri, "/")+1:]
+	return fmt.Sprintf("https://%s/profile/%s/lists/%s", BSKY_APP_HOST, actor, rkey)
+}
+
+// postURL is the bsky.app link to the post at uri, written by actor.
+func postURL(uri, actor string) string {
+	rkey := uri[strings.LastIndex(uri, "/")+1:]
+	return fmt.Sprintf("https://%s/profile/%s/post/%s", BSKY_APP_HOST, actor, rkey)
+}
+
+// ParseLink turns a deep link into the route that shows it. It understands at:// URIs,
+// bsky.app URLs, handles, DIDs and hashtags.
+func ParseLink(link string) (messages.NavigateMsg, error) {
+	link = strings.TrimSpace(link)
+	switch {
+	case strings.HasPrefix(link, "at://"):
+		parts := strings.Split(strings.TrimPrefix(link, "at://"), "/")
+		switch {
+		case len(parts) == 1:
+			return profileLink(parts[0]), nil
+		case len(parts) == 3 && parts[1] == "app.bsky.feed.post":
+			return messages.NavigateMsg{Route: ROUTE_THREAD, Params: messages.Params{"uri": link}}, nil
+		case len(parts) == 3 && parts[1] == messages.GENERATOR_COLLECTION:
+			return feedLink(link), nil
+		case len(parts) == 3 && parts[1] == messages.LIST_COLLECTION:
+			return listLink(link), nil
+		}
+	case strings.HasPrefix(link, "https://") || strings.HasPrefix(link, "http://"):
+		u, err := url.Parse(link)
+		if err != nil {
+			return messages.NavigateMsg{}, err
+		}
+		if u.Host != BSKY_APP_HOST {
+			break
+		}
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		switch {
+		case len(parts) == 2 && parts[0] == "profile":
+			return profileLink(parts[1]), nil
+		case len(parts) == 4 && parts[0] == "profile" && parts[2] == "post":
+			uri := fmt.Sprintf("at://%s/app.bsky.feed.post/%s", parts[1], parts[3])
+			return messages.NavigateMsg{Route: ROUTE_THREAD, Params: messages.Params{"uri": uri}}, nil
+		case len(parts) == 4 && parts[0] == "profile" && parts[2] == "feed":
+			return feedLink(fmt.Sprintf("at://%s/%s/%s", parts[1], messages.GENERATOR_COLLECTION, parts[3])), nil
+		case len(parts) == 1 && parts[0] == "feeds":
+			return messages.NavigateMsg{Route: ROUTE_FEEDS}, nil
+		case len(parts) == 4 && parts[0] == "profile" && parts[2] == "lists":
+			return listLink(fmt.Sprintf("at://%s/%s/%s", parts[1], messages.LIST_COLLECTION, parts[3])), nil
+		case len(parts) == 3 && parts[0] == "profile" && parts[2] == "lists":
+			return messages.NavigateMsg{Route: ROUTE_LISTS, Params: messages.Params{"actor": parts[1]}}, nil
+		case len(parts) == 2 && parts[0] == "hashtag":
+			return searchLink("#" + parts[1]), nil
+		case len(parts) == 1 && parts[0] == "search":
+			return searchLink(u.Query().Get("q")), nil
+		}
+	case strings.HasPrefix(link, "#"):
+		return searchLink(link), nil
+	case strings.HasPrefix(link, "did:"), strings.HasPrefix(link, "@"), validateHandleSyntax(link) == nil:
+		return profileLink(link), nil
+	}
+	return messages.NavigateMsg{}, fmt.Errorf("don't know how to open %q", link)
+}
+
+func feedLink(uri string) messages.NavigateMsg {
+	return messages.NavigateMsg{Route: ROUTE_FEED, Params: messages.Params{"uri": uri}}
+}
+
+func listLink(uri string) messages.NavigateMsg {
+	return messages.NavigateMsg{Route: ROUTE_LIST, Params: messages.Params{"uri": uri}}
+}
+
+func searchLink(query string) messages.NavigateMsg {
+	return messages.NavigateMsg{Route: ROUTE_SEARCH, Params: messages.Params{"q": query}}
+}
+
+func profileLink(actor string) messages.NavigateMsg {
+	return messages.NavigateMsg{Route: ROUTE_PROFILE, Params: messages.Params{"actor": strings.TrimPrefix(actor, "@")}}
+}
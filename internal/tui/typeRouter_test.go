@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/haukened/tsky/internal/messages"
+)
+
+func TestParseLink(t *testing.T) {
+	const (
+		post = "at://did:plc:alice/app.bsky.feed.post/3kabc"
+		feed = "at://did:plc:alice/app.bsky.feed.generator/whats-hot"
+		list = "at://did:plc:alice/app.bsky.graph.list/3kdef"
+	)
+	tests := []struct {
+		link    string
+		route   string
+		params  messages.Params
+		wantErr bool
+	}{
+		{link: "at://did:plc:alice", route: ROUTE_PROFILE, params: messages.Params{"actor": "did:plc:alice"}},
+		{link: post, route: ROUTE_THREAD, params: messages.Params{"uri": post}},
+		{link: feed, route: ROUTE_FEED, params: messages.Params{"uri": feed}},
+		{link: list, route: ROUTE_LIST, params: messages.Params{"uri": list}},
+		{link: "at://did:plc:alice/app.bsky.actor.profile/self", wantErr: true},
+		{link: "https://bsky.app/profile/alice.test", route: ROUTE_PROFILE, params: messages.Params{"actor": "alice.test"}},
+		{link: " https://bsky.app/profile/did:plc:alice/ ", route: ROUTE_PROFILE, params: messages.Params{"actor": "did:plc:alice"}},
+		{link: "https://bsky.app/profile/did:plc:alice/post/3kabc", route: ROUTE_THREAD, params: messages.Params{"uri": post}},
+		{link: "https://bsky.app/profile/did:plc:alice/feed/whats-hot", route: ROUTE_FEED, params: messages.Params{"uri": feed}},
+		{link: "https://bsky.app/profile/did:plc:alice/lists/3kdef", route: ROUTE_LIST, params: messages.Params{"uri": list}},
+		{link: "https://bsky.app/profile/alice.test/lists", route: ROUTE_LISTS, params: messages.Params{"actor": "alice.test"}},
+		{link: "https://bsky.app/feeds", route: ROUTE_FEEDS},
+		{link: "https://bsky.app/hashtag/golang", route: ROUTE_SEARCH, params: messages.Params{"q": "#golang"}},
+		{link: "https://bsky.app/search?q=from%3Ame", route: ROUTE_SEARCH, params: messages.Params{"q": "from:me"}},
+		{link: "https://bsky.app/settings", wantErr: true},
+		{link: "https://example.com/profile/alice.test", wantErr: true},
+		{link: "@alice.test", route: ROUTE_PROFILE, params: messages.Params{"actor": "alice.test"}},
+		{link: "#golang", route: ROUTE_SEARCH, params: messages.Params{"q": "#golang"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseLink(tt.link)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseLink(%q) = %+v, want an error", tt.link, got)
+			}
+			continue
+		}
+		want := messages.NavigateMsg{Route: tt.route, Params: tt.params}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseLink(%q) = %+v, %v, want %+v", tt.link, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"github.com/atotto/clipboard"
+	"github.com/muesli/termenv"
+)
+
+// CopyToClipboard puts s on the system clipboard. If there is no clipboard tool to use, e.g. over
+// ssh, it asks the terminal to do it with an OSC 52 escape sequence instead.
+func CopyToClipboard(s string) {
+	if err := clipboard.WriteAll(s); err == nil {
+		return
+	}
+	termenv.Copy(s)
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// RelativeTime formats t the way bluesky clients do, e.g. 45s, 12m, 3h, 5d,
+// falling back to a date once t is more than a week old.
+func RelativeTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", max(0, int(d.Seconds())))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 7*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case t.Year() == time.Now().Year():
+		return t.Local().Format("Jan 2")
+	default:
+		return t.Local().Format("Jan 2, 2006")
+	}
+}
@@ -1,20 +1,22 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/haukened/tsky/internal/cli"
 	"github.com/haukened/tsky/internal/config"
-	"github.com/haukened/tsky/internal/debug"
+	"github.com/haukened/tsky/internal/logging"
 	"github.com/haukened/tsky/internal/tui"
 	"github.com/haukened/tsky/internal/utils"
 )
 
 var Version string = "dev"
 
-const LOG_FILE = "./tsky_debug.log"
+const LOG_FILE = "tsky.log"
 
 func dontPanic(err error) {
 	if err != nil {
@@ -24,21 +26,61 @@ func dontPanic(err error) {
 }
 
 func main() {
+	logLevel := flag.String("log-level", "", "minimum level to log: debug, info, warn or error")
+	open := flag.String("open", "", "at:// URI, bsky.app URL or handle to open once logged in")
+	flag.Usage = func() {
+		cli.Run(nil, []string{"help"})
+		fmt.Fprintln(os.Stderr, "\nflags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
 	utils.SetVersion(Version)
 	c, err := config.New("~/.config/tsky/config.yaml")
 	dontPanic(err)
 	err = c.Load()
 	dontPanic(err)
-	if c.Debug {
-		debug.SetDebug(true)
-		logFile, err := os.OpenFile(LOG_FILE, os.O_TRUNC|os.O_RDWR|os.O_CREATE, 0644)
+	if *logLevel != "" {
+		_, err = logging.ParseLevel(*logLevel)
+		dontPanic(err)
+		c.LevelOverride = *logLevel
+	}
+	err = c.Validate()
+	dontPanic(err)
+
+	stateDir, err := config.StateDir()
+	dontPanic(err)
+	logFile, err := logging.Setup(logging.Options{
+		Level: c.Level(),
+		Path:  filepath.Join(stateDir, LOG_FILE),
+	})
+	dontPanic(err)
+	defer logFile.Close()
+	logger := logging.For("main")
+	logger.Info("starting tsky", "version", Version, "level", c.Level())
+
+	// any arguments left after the global flags name a non interactive command
+	if flag.NArg() > 0 {
+		code := cli.Run(c, flag.Args())
+		logFile.Close()
+		os.Exit(code)
+	}
+
+	// the theme and key bindings are rejected here the same way as when the file is edited later
+	err = tui.CheckConfig(c)
+	dontPanic(err)
+
+	// watch the config file so edits apply without a restart
+	changes, err := c.Watch()
+	if err != nil {
+		logger.Warn("not watching config file", "err", err)
+	}
+	model := tui.NewModel(c, changes)
+	if *open != "" {
+		model, err = model.WithDeepLink(*open)
 		dontPanic(err)
-		defer logFile.Close()
-		log.SetOutput(logFile)
-		log.SetFlags(log.LstdFlags | log.Lshortfile)
-		log.Println("Starting tsky")
 	}
-	p := tea.NewProgram(tui.NewModel(c), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseAllMotion())
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v", err)
 		os.Exit(1)